@@ -101,6 +101,103 @@ func (x *KeyResponse) GetKey() string {
 	return ""
 }
 
+// count must be between 1 and 1000, an empty pool means the default pool
+type GetKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int32                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Pool          string                 `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetKeysRequest) Reset() {
+	*x = GetKeysRequest{}
+	mi := &file_key_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetKeysRequest) ProtoMessage() {}
+
+func (x *GetKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_key_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetKeysRequest.ProtoReflect.Descriptor instead.
+func (*GetKeysRequest) Descriptor() ([]byte, []int) {
+	return file_key_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetKeysRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetKeysRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type KeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysResponse) Reset() {
+	*x = KeysResponse{}
+	mi := &file_key_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysResponse) ProtoMessage() {}
+
+func (x *KeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_key_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysResponse.ProtoReflect.Descriptor instead.
+func (*KeysResponse) Descriptor() ([]byte, []int) {
+	return file_key_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KeysResponse) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
 var File_key_proto protoreflect.FileDescriptor
 
 const file_key_proto_rawDesc = "" +
@@ -108,11 +205,17 @@ const file_key_proto_rawDesc = "" +
 	"\tkey.proto\x12\x03key\"\a\n" +
 	"\x05Empty\"\x1f\n" +
 	"\vKeyResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key24\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\":\n" +
+	"\x0eGetKeysRequest\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x05R\x05count\x12\x12\n" +
+	"\x04pool\x18\x02 \x01(\tR\x04pool\"\"\n" +
+	"\fKeysResponse\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys2g\n" +
 	"\n" +
 	"KeyService\x12&\n" +
 	"\x06GetKey\x12\n" +
-	".key.Empty\x1a\x10.key.KeyResponseB\x17Z\x15shortly-proto/gen;keyb\x06proto3"
+	".key.Empty\x1a\x10.key.KeyResponse\x121\n" +
+	"\aGetKeys\x12\x13.key.GetKeysRequest\x1a\x11.key.KeysResponseB\x17Z\x15shortly-proto/gen;keyb\x06proto3"
 
 var (
 	file_key_proto_rawDescOnce sync.Once
@@ -126,16 +229,20 @@ func file_key_proto_rawDescGZIP() []byte {
 	return file_key_proto_rawDescData
 }
 
-var file_key_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_key_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_key_proto_goTypes = []any{
-	(*Empty)(nil),       // 0: key.Empty
-	(*KeyResponse)(nil), // 1: key.KeyResponse
+	(*Empty)(nil),          // 0: key.Empty
+	(*KeyResponse)(nil),    // 1: key.KeyResponse
+	(*GetKeysRequest)(nil), // 2: key.GetKeysRequest
+	(*KeysResponse)(nil),   // 3: key.KeysResponse
 }
 var file_key_proto_depIdxs = []int32{
 	0, // 0: key.KeyService.GetKey:input_type -> key.Empty
-	1, // 1: key.KeyService.GetKey:output_type -> key.KeyResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	2, // 1: key.KeyService.GetKeys:input_type -> key.GetKeysRequest
+	1, // 2: key.KeyService.GetKey:output_type -> key.KeyResponse
+	3, // 3: key.KeyService.GetKeys:output_type -> key.KeysResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -152,7 +259,7 @@ func file_key_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_key_proto_rawDesc), len(file_key_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
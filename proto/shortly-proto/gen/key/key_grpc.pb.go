@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KeyService_GetKey_FullMethodName = "/key.KeyService/GetKey"
+	KeyService_GetKey_FullMethodName  = "/key.KeyService/GetKey"
+	KeyService_GetKeys_FullMethodName = "/key.KeyService/GetKeys"
 )
 
 // KeyServiceClient is the client API for KeyService service.
@@ -27,6 +28,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type KeyServiceClient interface {
 	GetKey(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*KeyResponse, error)
+	GetKeys(ctx context.Context, in *GetKeysRequest, opts ...grpc.CallOption) (*KeysResponse, error)
 }
 
 type keyServiceClient struct {
@@ -47,11 +49,22 @@ func (c *keyServiceClient) GetKey(ctx context.Context, in *Empty, opts ...grpc.C
 	return out, nil
 }
 
+func (c *keyServiceClient) GetKeys(ctx context.Context, in *GetKeysRequest, opts ...grpc.CallOption) (*KeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KeysResponse)
+	err := c.cc.Invoke(ctx, KeyService_GetKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyServiceServer is the server API for KeyService service.
 // All implementations must embed UnimplementedKeyServiceServer
 // for forward compatibility.
 type KeyServiceServer interface {
 	GetKey(context.Context, *Empty) (*KeyResponse, error)
+	GetKeys(context.Context, *GetKeysRequest) (*KeysResponse, error)
 	mustEmbedUnimplementedKeyServiceServer()
 }
 
@@ -65,6 +78,9 @@ type UnimplementedKeyServiceServer struct{}
 func (UnimplementedKeyServiceServer) GetKey(context.Context, *Empty) (*KeyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetKey not implemented")
 }
+func (UnimplementedKeyServiceServer) GetKeys(context.Context, *GetKeysRequest) (*KeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeys not implemented")
+}
 func (UnimplementedKeyServiceServer) mustEmbedUnimplementedKeyServiceServer() {}
 func (UnimplementedKeyServiceServer) testEmbeddedByValue()                    {}
 
@@ -104,6 +120,24 @@ func _KeyService_GetKey_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyService_GetKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyServiceServer).GetKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyService_GetKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyServiceServer).GetKeys(ctx, req.(*GetKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KeyService_ServiceDesc is the grpc.ServiceDesc for KeyService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +149,10 @@ var KeyService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetKey",
 			Handler:    _KeyService_GetKey_Handler,
 		},
+		{
+			MethodName: "GetKeys",
+			Handler:    _KeyService_GetKeys_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "key.proto",
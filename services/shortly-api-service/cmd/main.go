@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"shortly-api-service/config"
+	"shortly-api-service/internal/background"
+	"shortly-api-service/internal/clicks"
 	"shortly-api-service/internal/clients"
 	"shortly-api-service/internal/database"
+	"shortly-api-service/internal/handlers"
+	"shortly-api-service/internal/keys"
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/metrics"
+	"shortly-api-service/internal/middlewares"
 	"shortly-api-service/internal/redis"
+	"shortly-api-service/internal/repository"
 	"shortly-api-service/internal/routes"
 	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/webhooks"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -39,7 +53,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	defer redis.RedisClient.Close()
+	if err := lib.InitGeoLocator(); err != nil {
+		utils.Log.Error("❌ Failed to initialize GeoIP lookups", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize Gin server
 	server := gin.Default()
@@ -48,27 +65,132 @@ func main() {
 	clients.InitKGSClient()
 
 	// Middleware
+	server.Use(metrics.Middleware())
+
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "X-Workspace-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Prometheus scrape endpoint
+	server.GET("/metrics", metrics.Handler())
+
+	urls := repository.NewPostgresUrlRepository(database.DB)
+	users := repository.NewPostgresUserRepository(database.DB)
+	analytics := repository.NewPostgresAnalyticsRepository(database.DB)
+	apiKeys := repository.NewPostgresApiKeyRepository(database.DB)
+	workspaces := repository.NewPostgresWorkspaceRepository(database.DB)
+	denylist := repository.NewRedisTokenDenylist(redis.RedisClient)
+
+	hooks := repository.NewPostgresWebhookRepository(database.DB)
+	emitter := webhooks.NewEmitter(hooks)
+
+	// Outbound webhook deliveries
+	webhooks.Start(hooks)
+
+	// Buffered click ingestion
+	clicks.Start(analytics, emitter)
+
+	keySource, err := keys.NewFromConfig(database.DB, urls)
+
+	if err != nil {
+		utils.Log.Error("❌ Invalid key source configuration", "error", err)
+		os.Exit(1)
+	}
+
+	utils.Log.Info("✅ Key source ready", "source", config.AppConfig.KEY_SOURCE, "fallback", config.AppConfig.KEY_SOURCE == keys.SourceKGS && config.AppConfig.KEY_SOURCE_FALLBACK)
+
+	// Cache writes and other work requests leave behind, drained on shutdown
+	tasks := background.New(2 * time.Second)
+
+	// Handlers backed by PostgreSQL and the Redis cache
+	app := &handlers.Server{
+		Urls:       urls,
+		Users:      users,
+		Sessions:   repository.NewPostgresSessionRepository(database.DB),
+		ApiKeys:    apiKeys,
+		Analytics:  analytics,
+		Domains:    repository.NewPostgresDomainRepository(database.DB),
+		Rules:      repository.NewPostgresRedirectRuleRepository(database.DB),
+		Workspaces: workspaces,
+		Webhooks:   hooks,
+		Cache:      repository.NewRedisLinkCache(redis.RedisClient),
+		Profiles:   repository.NewRedisProfileCache(redis.RedisClient),
+		Denylist:   denylist,
+		Keys:       keySource,
+		Clicks:     clicks.Queue,
+		Events:     emitter,
+		Tasks:      tasks,
+	}
+
+	auth := middlewares.NewAuth(users, apiKeys, denylist, workspaces, tasks)
+
 	api := server.Group("/api/v1")
 
 	// Routes
-	routes.UrlRouter(api)
-	routes.AuthRouter(api)
+	routes.UrlRouter(api, app, auth)
+	routes.AuthRouter(api, app, auth)
 	routes.HealthRouter(api)
-	routes.ProfileRouter(api)
-	routes.AnalyticsRouter(api)
+	routes.ProfileRouter(api, app, auth)
+	routes.AnalyticsRouter(api, app, auth)
+	routes.DomainRouter(api, app, auth)
+	routes.ApiKeyRouter(api, app, auth)
+	routes.WorkspaceRouter(api, app, auth)
+	routes.WebhookRouter(api, app, auth)
+
+	httpServer := &http.Server{
+		Addr:    ":" + config.AppConfig.PORT,
+		Handler: server,
+	}
+
+	go func() {
+		utils.Log.Info("🚀 Server is running", "port", config.AppConfig.PORT)
+
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.Log.Error("Failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	utils.Log.Info("Shutting down server", "timeout", config.AppConfig.SHUTDOWN_TIMEOUT)
+
+	handlers.MarkShuttingDown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.AppConfig.SHUTDOWN_TIMEOUT)
+	defer cancel()
 
-	utils.Log.Info("🚀 Server is running", "port", config.AppConfig.PORT)
+	// Stop accepting connections and wait for in-flight requests
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		utils.Log.Error("Server forced to shutdown", "error", err)
+	}
+
+	// Requests are done, let the cache writes they started finish
+	if err := tasks.Wait(shutdownCtx); err != nil {
+		utils.Log.Error("Failed to finish background tasks", "error", err)
+	}
 
-	if err := server.Run(":" + config.AppConfig.PORT); err != nil {
-		utils.Log.Error("Failed to start server", "error", err)
+	// Flush buffered clicks once no more redirects can come in
+	if err := clicks.Queue.Close(shutdownCtx); err != nil {
+		utils.Log.Error("Failed to drain click pipeline", "error", err)
 	}
 
+	// Clicks flushed above may have queued deliveries, pending ones survive restarts
+	if err := webhooks.Deliveries.Close(shutdownCtx); err != nil {
+		utils.Log.Error("Failed to stop webhook dispatcher", "error", err)
+	}
+
+	// Nothing uses the connections anymore, close them in reverse order of opening
+	clients.CloseKGSClient()
+	redis.CloseRedis()
+	database.CloseDB()
+
+	utils.Log.Info("Server stopped")
+
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +19,56 @@ type Config struct {
 	JWT_SECRET       string
 	REDIS_ADDR       string
 	KGS_GRPC_ADDRESS string
+
+	// Public prefix of short links, the short key is appended to it
+	SHORT_URL_BASE string
+
+	ACCESS_TOKEN_TTL  time.Duration
+	REFRESH_TOKEN_TTL time.Duration
+
+	BULK_SHORTEN_LIMIT  int
+	KGS_KEY_BUFFER_SIZE int
+	// KGS key pool links draw their keys from, empty for KGS's default pool
+	KGS_KEY_POOL string
+
+	// Deadline of one KGS call with all of its attempts, retry attempts and
+	// circuit breaker of the KGS client
+	KGS_CALL_TIMEOUT     time.Duration
+	KGS_MAX_ATTEMPTS     int
+	KGS_BREAKER_FAILURES int
+	KGS_BREAKER_COOLDOWN time.Duration
+
+	// TLS to KGS when a CA is set, with a client certificate for mTLS, and
+	// the shared service token sent with every call
+	KGS_TLS_CA_FILE     string
+	KGS_TLS_CERT_FILE   string
+	KGS_TLS_KEY_FILE    string
+	KGS_TLS_SERVER_NAME string
+	KGS_AUTH_TOKEN      string
+
+	// Where short keys come from: kgs, local or sequence
+	KEY_SOURCE          string
+	KEY_SOURCE_FALLBACK bool
+	KGS_KEY_TIMEOUT     time.Duration
+	LOCAL_KEY_LENGTH    int
+
+	CLICK_QUEUE_SIZE     int
+	CLICK_BATCH_SIZE     int
+	CLICK_FLUSH_INTERVAL time.Duration
+	CLICK_GEO_TIMEOUT    time.Duration
+
+	GEOIP_CITY_DB       string
+	GEOIP_ASN_DB        string
+	GEOIP_HTTP_FALLBACK bool
+
+	WEBHOOK_WORKERS       int
+	WEBHOOK_MAX_ATTEMPTS  int
+	WEBHOOK_TIMEOUT       time.Duration
+	WEBHOOK_POLL_INTERVAL time.Duration
+	WEBHOOK_ALLOW_PRIVATE bool
+
+	HEALTH_CHECK_TIMEOUT time.Duration
+	SHUTDOWN_TIMEOUT     time.Duration
 }
 
 var AppConfig Config
@@ -39,6 +91,63 @@ func Init() error {
 		JWT_SECRET:       GetEnvOrPanic("JWT_SECRET"),
 		REDIS_ADDR:       GetEnvOrPanic("REDIS_ADDR"),
 		KGS_GRPC_ADDRESS: GetEnvOrPanic("KGS_GRPC_ADDRESS"),
+
+		SHORT_URL_BASE: GetEnvOrDefault("SHORT_URL_BASE", "http://localhost:"+os.Getenv("PORT")+"/api/v1/url/redirect"),
+
+		ACCESS_TOKEN_TTL:  GetEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		REFRESH_TOKEN_TTL: GetEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		BULK_SHORTEN_LIMIT:  GetEnvAsInt("BULK_SHORTEN_LIMIT", 1000),
+		KGS_KEY_BUFFER_SIZE: GetEnvAsInt("KGS_KEY_BUFFER_SIZE", 100),
+		KGS_KEY_POOL:        GetEnvOrDefault("KGS_KEY_POOL", ""),
+
+		KGS_CALL_TIMEOUT:     GetEnvAsDuration("KGS_CALL_TIMEOUT", time.Second),
+		KGS_MAX_ATTEMPTS:     GetEnvAsInt("KGS_MAX_ATTEMPTS", 3),
+		KGS_BREAKER_FAILURES: GetEnvAsInt("KGS_BREAKER_FAILURES", 5),
+		KGS_BREAKER_COOLDOWN: GetEnvAsDuration("KGS_BREAKER_COOLDOWN", 10*time.Second),
+
+		KGS_TLS_CA_FILE:     GetEnvOrDefault("KGS_TLS_CA_FILE", ""),
+		KGS_TLS_CERT_FILE:   GetEnvOrDefault("KGS_TLS_CERT_FILE", ""),
+		KGS_TLS_KEY_FILE:    GetEnvOrDefault("KGS_TLS_KEY_FILE", ""),
+		KGS_TLS_SERVER_NAME: GetEnvOrDefault("KGS_TLS_SERVER_NAME", ""),
+		KGS_AUTH_TOKEN:      GetEnvOrDefault("KGS_AUTH_TOKEN", ""),
+
+		KEY_SOURCE:          GetEnvOrDefault("KEY_SOURCE", "kgs"),
+		KEY_SOURCE_FALLBACK: GetEnvAsBool("KEY_SOURCE_FALLBACK", true),
+		LOCAL_KEY_LENGTH:    GetEnvAsInt("LOCAL_KEY_LENGTH", 8),
+
+		CLICK_QUEUE_SIZE:     GetEnvAsInt("CLICK_QUEUE_SIZE", 10000),
+		CLICK_BATCH_SIZE:     GetEnvAsInt("CLICK_BATCH_SIZE", 500),
+		CLICK_FLUSH_INTERVAL: GetEnvAsDuration("CLICK_FLUSH_INTERVAL", 2*time.Second),
+		CLICK_GEO_TIMEOUT:    GetEnvAsDuration("CLICK_GEO_TIMEOUT", 500*time.Millisecond),
+
+		GEOIP_CITY_DB:       GetEnvOrDefault("GEOIP_CITY_DB", ""),
+		GEOIP_ASN_DB:        GetEnvOrDefault("GEOIP_ASN_DB", ""),
+		GEOIP_HTTP_FALLBACK: GetEnvAsBool("GEOIP_HTTP_FALLBACK", false),
+
+		WEBHOOK_WORKERS:       GetEnvAsInt("WEBHOOK_WORKERS", 4),
+		WEBHOOK_MAX_ATTEMPTS:  GetEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WEBHOOK_TIMEOUT:       GetEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WEBHOOK_POLL_INTERVAL: GetEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		WEBHOOK_ALLOW_PRIVATE: GetEnvAsBool("WEBHOOK_ALLOW_PRIVATE", false),
+
+		HEALTH_CHECK_TIMEOUT: GetEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		SHUTDOWN_TIMEOUT:     GetEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+	}
+
+	// The fallback waits for a whole KGS call, retries included, by default
+	AppConfig.KGS_KEY_TIMEOUT = GetEnvAsDuration("KGS_KEY_TIMEOUT", AppConfig.KGS_CALL_TIMEOUT)
+
+	if AppConfig.KGS_KEY_TIMEOUT < AppConfig.KGS_CALL_TIMEOUT {
+		return fmt.Errorf("KGS_KEY_TIMEOUT (%s) must not be shorter than KGS_CALL_TIMEOUT (%s), retried KGS calls would always be cut off", AppConfig.KGS_KEY_TIMEOUT, AppConfig.KGS_CALL_TIMEOUT)
+	}
+
+	if (AppConfig.KGS_TLS_CERT_FILE == "") != (AppConfig.KGS_TLS_KEY_FILE == "") {
+		return fmt.Errorf("KGS_TLS_CERT_FILE and KGS_TLS_KEY_FILE must be set together")
+	}
+
+	if AppConfig.KGS_TLS_CERT_FILE != "" && AppConfig.KGS_TLS_CA_FILE == "" {
+		return fmt.Errorf("KGS_TLS_CERT_FILE requires KGS_TLS_CA_FILE to verify KGS")
 	}
 
 	return nil
@@ -54,3 +163,63 @@ func GetEnvOrPanic(key string) string {
 
 	return value
 }
+
+func GetEnvOrDefault(key string, fallback string) string {
+
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func GetEnvAsInt(key string, fallback int) int {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		panic(fmt.Sprintf("❌ Invalid integer for environment variable %s: %s", key, value))
+	}
+
+	return parsed
+}
+
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+
+	if err != nil {
+		panic(fmt.Sprintf("❌ Invalid duration for environment variable %s: %s", key, value))
+	}
+
+	return parsed
+}
+
+func GetEnvAsBool(key string, fallback bool) bool {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+
+	if err != nil {
+		panic(fmt.Sprintf("❌ Invalid boolean for environment variable %s: %s", key, value))
+	}
+
+	return parsed
+}
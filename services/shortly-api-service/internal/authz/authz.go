@@ -0,0 +1,151 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shortly-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Permission string
+
+const (
+	UrlsRead        Permission = "urls:read"
+	UrlsWrite       Permission = "urls:write"
+	AnalyticsRead   Permission = "analytics:read"
+	WorkspaceRead   Permission = "workspace:read"
+	WorkspaceManage Permission = "workspace:manage"
+	WebhooksManage  Permission = "webhooks:manage"
+)
+
+// WorkspaceHeader selects the workspace a request acts in. Without it the
+// request acts on the caller's personal links.
+const WorkspaceHeader = "X-Workspace-ID"
+
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner:  {UrlsRead, UrlsWrite, AnalyticsRead, WorkspaceRead, WorkspaceManage, WebhooksManage},
+	models.RoleEditor: {UrlsRead, UrlsWrite, AnalyticsRead, WorkspaceRead},
+	models.RoleViewer: {UrlsRead, AnalyticsRead, WorkspaceRead},
+}
+
+var (
+	ErrInvalidWorkspace = errors.New("invalid workspace id")
+	ErrNotMember        = errors.New("workspace not found")
+)
+
+// Scope is who a request acts for: the user's personal space, or a
+// workspace the user is a member of with a given role.
+type Scope struct {
+	UserID      uint
+	WorkspaceID *uint
+	Role        string
+}
+
+const contextKey = "authz_scope"
+
+// Members looks up workspace roles, repository.WorkspaceRepository in
+// production. MemberRole returns ErrNotMember for non-members.
+type Members interface {
+	MemberRole(ctx context.Context, workspaceID uint, userID uint) (string, error)
+}
+
+// Resolve builds the scope of a request. workspace is the raw workspace id,
+// empty for the personal space where the user is always the owner.
+func Resolve(ctx context.Context, members Members, userID uint, workspace string) (Scope, error) {
+
+	scope := Scope{UserID: userID, Role: models.RoleOwner}
+
+	if workspace == "" {
+		return scope, nil
+	}
+
+	workspaceID, err := strconv.ParseUint(workspace, 10, 64)
+
+	if err != nil || workspaceID == 0 {
+		return scope, ErrInvalidWorkspace
+	}
+
+	role, err := members.MemberRole(ctx, uint(workspaceID), userID)
+
+	if err != nil {
+		return scope, err
+	}
+
+	id := uint(workspaceID)
+	scope.WorkspaceID = &id
+	scope.Role = role
+
+	return scope, nil
+}
+
+func (s Scope) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[s.Role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Urls restricts a query to the links visible in this scope.
+func (s Scope) Urls(db *gorm.DB) *gorm.DB {
+	if s.WorkspaceID != nil {
+		return db.Where("workspace_id = ?", *s.WorkspaceID)
+	}
+	return db.Where("workspace_id IS NULL AND user_id = ?", strconv.FormatUint(uint64(s.UserID), 10))
+}
+
+// Owns reports whether url is visible in this scope, the in-memory
+// counterpart of Urls.
+func (s Scope) Owns(url models.Url) bool {
+	if s.WorkspaceID != nil {
+		return url.WorkspaceID != nil && *url.WorkspaceID == *s.WorkspaceID
+	}
+	return url.WorkspaceID == nil && url.UserID != nil && *url.UserID == strconv.FormatUint(uint64(s.UserID), 10)
+}
+
+// Webhooks restricts a query to the webhooks registered in this scope.
+func (s Scope) Webhooks(db *gorm.DB) *gorm.DB {
+	if s.WorkspaceID != nil {
+		return db.Where("workspace_id = ?", *s.WorkspaceID)
+	}
+	return db.Where("workspace_id IS NULL AND user_id = ?", s.UserID)
+}
+
+// OwnsWebhook reports whether hook is registered in this scope, the
+// in-memory counterpart of Webhooks.
+func (s Scope) OwnsWebhook(hook models.Webhook) bool {
+	if s.WorkspaceID != nil {
+		return hook.WorkspaceID != nil && *hook.WorkspaceID == *s.WorkspaceID
+	}
+	return hook.WorkspaceID == nil && hook.UserID == s.UserID
+}
+
+// Assign stamps a new link with this scope. The creator is kept as UserID
+// for auditing, ownership comes from the workspace.
+func (s Scope) Assign(url *models.Url) {
+	userID := strconv.FormatUint(uint64(s.UserID), 10)
+	url.UserID = &userID
+	url.WorkspaceID = s.WorkspaceID
+}
+
+func Set(ctx *gin.Context, scope Scope) {
+	ctx.Set(contextKey, scope)
+}
+
+// FromContext returns the scope stored by the Authorize middleware.
+func FromContext(ctx *gin.Context) (Scope, bool) {
+
+	value, exists := ctx.Get(contextKey)
+
+	if !exists {
+		return Scope{}, false
+	}
+
+	scope, ok := value.(Scope)
+	return scope, ok
+}
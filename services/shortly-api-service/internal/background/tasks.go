@@ -0,0 +1,58 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/utils"
+)
+
+// Tasks runs work a request starts but doesn't wait for, such as cache
+// writes, and lets shutdown wait for it before the connections it uses are
+// closed. Every task is bounded by timeout.
+type Tasks struct {
+	wg      sync.WaitGroup
+	timeout time.Duration
+}
+
+func New(timeout time.Duration) *Tasks {
+	return &Tasks{timeout: timeout}
+}
+
+// Go runs task in the background, logging its error under name. The task's
+// context is cancelled after the timeout, not when the request ends.
+func (t *Tasks) Go(name string, task func(ctx context.Context) error) {
+
+	t.wg.Add(1)
+
+	go func() {
+		defer t.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+		defer cancel()
+
+		if err := task(ctx); err != nil {
+			utils.Log.Error("Background task failed", "task", name, "error", err)
+		}
+	}()
+}
+
+// Wait blocks until every started task has returned or ctx expires.
+func (t *Tasks) Wait(ctx context.Context) error {
+
+	done := make(chan struct{})
+
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks not finished: %w", ctx.Err())
+	}
+}
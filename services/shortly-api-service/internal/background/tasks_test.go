@@ -0,0 +1,79 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/utils"
+)
+
+func TestWaitDrainsTasks(t *testing.T) {
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	tasks := New(time.Second)
+
+	var finished atomic.Int32
+
+	for range 3 {
+		tasks.Go("sleep", func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			finished.Add(1)
+			return nil
+		})
+	}
+
+	tasks.Go("fail", func(ctx context.Context) error {
+		return errors.New("cache is down")
+	})
+
+	if err := tasks.Wait(context.Background()); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	if n := finished.Load(); n != 3 {
+		t.Fatalf("%d tasks finished before Wait returned, want 3", n)
+	}
+}
+
+func TestTaskTimeout(t *testing.T) {
+
+	tasks := New(20 * time.Millisecond)
+
+	tasks.Go("block", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	// The task's own deadline ends it, Wait doesn't need to give up
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := tasks.Wait(ctx); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+}
+
+func TestWaitGivesUpWithContext(t *testing.T) {
+
+	tasks := New(time.Minute)
+	release := make(chan struct{})
+	defer close(release)
+
+	tasks.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := tasks.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+}
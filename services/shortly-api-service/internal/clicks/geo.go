@@ -0,0 +1,143 @@
+package clicks
+
+import (
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/lib"
+)
+
+const (
+	geoCacheSize = 10000
+	geoWorkers   = 16
+)
+
+var unknownGeo = lib.GeoInfo{Country: "Unknown"}
+
+// geoResolver looks up visitor locations for the pipeline. Lookups run in
+// the background, at most geoWorkers at a time, and a flush only waits
+// timeout for them: a slow locator costs a batch its locations, never the
+// batch itself. Answers that arrive late are cached for the next batches.
+type geoResolver struct {
+	locate  func(ip string) lib.GeoInfo
+	timeout time.Duration
+	workers chan struct{}
+
+	mu       sync.Mutex
+	cache    map[string]lib.GeoInfo
+	order    []string
+	inflight map[string]chan struct{}
+}
+
+func newGeoResolver(locate func(ip string) lib.GeoInfo, timeout time.Duration) *geoResolver {
+	return &geoResolver{
+		locate:   locate,
+		timeout:  timeout,
+		workers:  make(chan struct{}, geoWorkers),
+		cache:    make(map[string]lib.GeoInfo),
+		inflight: make(map[string]chan struct{}),
+	}
+}
+
+// resolve returns the location of every address in ips, "Unknown" for those
+// not resolved within the timeout.
+func (g *geoResolver) resolve(ips []string) map[string]lib.GeoInfo {
+
+	locations := make(map[string]lib.GeoInfo, len(ips))
+	waiting := make(map[string]chan struct{})
+
+	g.mu.Lock()
+
+	for _, ip := range ips {
+
+		if _, ok := locations[ip]; ok {
+			continue
+		}
+
+		if info, ok := g.cache[ip]; ok {
+			locations[ip] = info
+			continue
+		}
+
+		locations[ip] = unknownGeo
+
+		if done, ok := g.inflight[ip]; ok {
+			waiting[ip] = done
+			continue
+		}
+
+		// Every worker is busy, the address is tried again in a later batch
+		select {
+		case g.workers <- struct{}{}:
+		default:
+			continue
+		}
+
+		done := make(chan struct{})
+		g.inflight[ip] = done
+		waiting[ip] = done
+
+		go g.lookup(ip, done)
+	}
+
+	g.mu.Unlock()
+
+	if len(waiting) == 0 {
+		return locations
+	}
+
+	deadline := time.NewTimer(g.timeout)
+	defer deadline.Stop()
+
+	for _, done := range waiting {
+		select {
+		case <-done:
+		case <-deadline.C:
+			return g.collect(locations, waiting)
+		}
+	}
+
+	return g.collect(locations, waiting)
+}
+
+// collect fills in the addresses whose lookup has finished by now.
+func (g *geoResolver) collect(locations map[string]lib.GeoInfo, waiting map[string]chan struct{}) map[string]lib.GeoInfo {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for ip := range waiting {
+		if info, ok := g.cache[ip]; ok {
+			locations[ip] = info
+		}
+	}
+
+	return locations
+}
+
+func (g *geoResolver) lookup(ip string, done chan struct{}) {
+
+	info := g.locate(ip)
+
+	g.mu.Lock()
+
+	// Failed lookups aren't kept, the address is tried again next time
+	if info != unknownGeo {
+
+		// Oldest entries make room first
+		if len(g.cache) >= geoCacheSize {
+			delete(g.cache, g.order[0])
+			g.order = g.order[1:]
+		}
+
+		g.cache[ip] = info
+		g.order = append(g.order, ip)
+	}
+
+	delete(g.inflight, ip)
+
+	g.mu.Unlock()
+
+	<-g.workers
+	close(done)
+}
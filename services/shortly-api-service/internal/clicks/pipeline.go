@@ -0,0 +1,432 @@
+package clicks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/webhooks"
+)
+
+// Event is everything the redirect handler captures about a click. It is
+// copied out of the request so nothing touches the gin.Context afterwards.
+type Event struct {
+	UrlID     uint
+	ClickedAt time.Time
+	IPAddress string
+	UserAgent string
+	Referrer  string
+
+	// Counted is set when the redirect already incremented clicks itself
+	// (links with max_clicks), so the pipeline only stores the event.
+	Counted bool
+}
+
+type Stats struct {
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Enqueued uint64 `json:"enqueued"`
+	Dropped  uint64 `json:"dropped"`
+	Flushed  uint64 `json:"flushed"`
+	Retrying int64  `json:"retrying"`
+	Failed   uint64 `json:"failed"`
+	LagMs    int64  `json:"lag_ms"`
+}
+
+// maxFlushAttempts is how often a batch is written before its events are
+// given up on, one attempt per flush interval.
+const maxFlushAttempts = 5
+
+// User agents are stored in full otherwise, nothing bounds what clients send
+const maxUserAgentLength = 512
+
+// storedBatch is a flushed batch ready to be written, kept for another
+// attempt when the write fails.
+type storedBatch struct {
+	rows     []models.Analytics
+	clicks   []webhooks.Click
+	counts   []bool // whether the row adds to its link's click count
+	attempts int
+}
+
+func (b *storedBatch) add(row models.Analytics, click webhooks.Click, counts bool) {
+	b.rows = append(b.rows, row)
+	b.clicks = append(b.clicks, click)
+	b.counts = append(b.counts, counts)
+}
+
+// increments sums the click count updates of the batch per link.
+func (b *storedBatch) increments() map[uint]int {
+
+	increments := make(map[uint]int)
+
+	for i, click := range b.clicks {
+		if b.counts[i] {
+			increments[click.UrlID]++
+		}
+	}
+
+	return increments
+}
+
+// Store writes flushed batches, repository.AnalyticsRepository in production.
+type Store interface {
+	RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error
+}
+
+// Notifier is told about every stored batch, *webhooks.Emitter in production.
+type Notifier interface {
+	EmitClicks(batch []webhooks.Click)
+}
+
+// Pipeline buffers click events in a bounded queue and writes them to
+// PostgreSQL in batches, so redirects never wait on the database.
+type Pipeline struct {
+	store         Store
+	notifier      Notifier
+	events        chan Event
+	batchSize     int
+	flushInterval time.Duration
+	geo           *geoResolver
+
+	// Only touched by the run goroutine
+	retries []storedBatch
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	flushed  atomic.Uint64
+	retrying atomic.Int64
+	failed   atomic.Uint64
+	lagMs    atomic.Int64
+}
+
+var Queue *Pipeline
+
+func Start(store Store, notifier Notifier) {
+
+	Queue = NewPipeline(
+		store,
+		notifier,
+		lib.LookupGeo,
+		config.AppConfig.CLICK_QUEUE_SIZE,
+		config.AppConfig.CLICK_BATCH_SIZE,
+		config.AppConfig.CLICK_FLUSH_INTERVAL,
+		config.AppConfig.CLICK_GEO_TIMEOUT,
+	)
+
+	utils.Log.Info("✅ Click ingestion pipeline started",
+		"capacity", config.AppConfig.CLICK_QUEUE_SIZE,
+		"batch_size", config.AppConfig.CLICK_BATCH_SIZE,
+	)
+}
+
+// NewPipeline starts the worker. locate resolves a visitor IP, lib.LookupGeo
+// in production; a flush waits at most geoTimeout for it.
+func NewPipeline(store Store, notifier Notifier, locate func(ip string) lib.GeoInfo, capacity int, batchSize int, flushInterval time.Duration, geoTimeout time.Duration) *Pipeline {
+
+	p := &Pipeline{
+		store:         store,
+		notifier:      notifier,
+		events:        make(chan Event, capacity),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		geo:           newGeoResolver(locate, geoTimeout),
+		done:          make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+// Enqueue never blocks. When the queue is full or closed the event is
+// dropped and counted.
+func (p *Pipeline) Enqueue(event Event) bool {
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case p.events <- event:
+		p.enqueued.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting events and waits until everything queued has been
+// flushed or ctx expires.
+func (p *Pipeline) Close(ctx context.Context) error {
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		utils.Log.Info("Click pipeline drained", "flushed", p.flushed.Load(), "dropped", p.dropped.Load())
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("click pipeline not drained, %d events still queued: %w", len(p.events), ctx.Err())
+	}
+}
+
+func (p *Pipeline) Stats() Stats {
+	return Stats{
+		Queued:   len(p.events),
+		Capacity: cap(p.events),
+		Enqueued: p.enqueued.Load(),
+		Dropped:  p.dropped.Load(),
+		Flushed:  p.flushed.Load(),
+		Retrying: p.retrying.Load(),
+		Failed:   p.failed.Load(),
+		LagMs:    p.lagMs.Load(),
+	}
+}
+
+func (p *Pipeline) run() {
+
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, p.batchSize)
+	lastDropped := uint64(0)
+
+	for {
+		select {
+
+		case event, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				p.retryFailed(true)
+				return
+			}
+
+			batch = append(batch, event)
+
+			if len(batch) >= p.batchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			p.retryFailed(false)
+
+			if len(batch) > 0 {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+
+			if dropped := p.dropped.Load(); dropped > lastDropped {
+				utils.Log.Warn("Click events dropped, queue is full", "dropped", dropped-lastDropped)
+				lastDropped = dropped
+			}
+		}
+	}
+}
+
+func (p *Pipeline) flush(batch []Event) {
+
+	if len(batch) == 0 {
+		return
+	}
+
+	ips := make([]string, 0, len(batch))
+
+	for _, event := range batch {
+		ips = append(ips, event.IPAddress)
+	}
+
+	locations := p.geo.resolve(ips)
+
+	stored := storedBatch{
+		rows:   make([]models.Analytics, 0, len(batch)),
+		clicks: make([]webhooks.Click, 0, len(batch)),
+		counts: make([]bool, 0, len(batch)),
+	}
+
+	for _, event := range batch {
+
+		geo := locations[event.IPAddress]
+
+		userAgent := clean(event.UserAgent, maxUserAgentLength)
+
+		device, browser, os := lib.ParseUserAgent(userAgent)
+
+		row := models.Analytics{
+			UrlID:     strconv.FormatUint(uint64(event.UrlID), 10),
+			ClickedAt: event.ClickedAt,
+			IPAddress: clean(event.IPAddress, 64),
+			UserAgent: userAgent,
+			Referrer:  clean(event.Referrer, 255),
+			Country:   clean(geo.Country, 100),
+			Region:    clean(geo.Region, 100),
+			City:      clean(geo.City, 100),
+			ASN:       geo.ASN,
+			ASOrg:     clean(geo.ASOrg, 255),
+			Device:    clean(device, 50),
+			Browser:   clean(browser, 50),
+			OS:        clean(os, 50),
+		}
+
+		stored.add(row, webhooks.Click{
+			UrlID:     event.UrlID,
+			ClickedAt: event.ClickedAt,
+			Country:   row.Country,
+			Device:    row.Device,
+			Browser:   row.Browser,
+			OS:        row.OS,
+			Referrer:  row.Referrer,
+		}, !event.Counted)
+	}
+
+	if !p.write(&stored) {
+		p.keep(stored)
+	}
+}
+
+// write stores the batch and announces it, reporting whether it went through.
+// When the batch fails its rows are written one at a time, so a row the
+// database rejects only holds back itself; batch is left with the rows that
+// still failed.
+func (p *Pipeline) write(batch *storedBatch) bool {
+
+	batch.attempts++
+
+	err := p.store.RecordClicks(context.Background(), batch.rows, batch.increments())
+
+	if err == nil {
+		p.announce(batch)
+		return true
+	}
+
+	utils.Log.Error("Failed to flush click events", "count", len(batch.rows), "attempt", batch.attempts, "error", err)
+
+	if len(batch.rows) == 1 {
+		return false
+	}
+
+	failed := storedBatch{attempts: batch.attempts}
+
+	for i := range batch.rows {
+
+		single := storedBatch{}
+		single.add(batch.rows[i], batch.clicks[i], batch.counts[i])
+
+		if err := p.store.RecordClicks(context.Background(), single.rows, single.increments()); err != nil {
+			failed.add(batch.rows[i], batch.clicks[i], batch.counts[i])
+			continue
+		}
+
+		p.announce(&single)
+	}
+
+	*batch = failed
+
+	if len(failed.rows) > 0 {
+		utils.Log.Error("Failed to write click events one by one", "count", len(failed.rows), "attempt", failed.attempts)
+		return false
+	}
+
+	return true
+}
+
+func (p *Pipeline) announce(batch *storedBatch) {
+
+	p.flushed.Add(uint64(len(batch.rows)))
+	p.lagMs.Store(time.Since(batch.rows[0].ClickedAt).Milliseconds())
+
+	p.notifier.EmitClicks(batch.clicks)
+}
+
+// keep holds a failed batch for the next flush interval. Held events are
+// bounded by the queue capacity, the oldest batches are given up first.
+func (p *Pipeline) keep(batch storedBatch) {
+
+	p.retries = append(p.retries, batch)
+	p.retrying.Add(int64(len(batch.rows)))
+
+	for len(p.retries) > 1 && p.retrying.Load() > int64(cap(p.events)) {
+		p.retrying.Add(-int64(len(p.retries[0].rows)))
+		p.giveUp(p.retries[0], "retry buffer is full")
+		p.retries = p.retries[1:]
+	}
+}
+
+// retryFailed writes the held batches again, in order. On the last call,
+// when the pipeline closes, whatever still fails is given up.
+func (p *Pipeline) retryFailed(last bool) {
+
+	remaining := p.retries[:0]
+
+	for i, batch := range p.retries {
+
+		p.retrying.Add(-int64(len(batch.rows)))
+
+		if p.write(&batch) {
+			continue
+		}
+
+		if last || batch.attempts >= maxFlushAttempts {
+			p.giveUp(batch, "too many failed attempts")
+			continue
+		}
+
+		// The store is still failing, the rest waits for the next interval
+		remaining = append(remaining, batch)
+		remaining = append(remaining, p.retries[i+1:]...)
+		p.retrying.Add(int64(len(batch.rows)))
+		break
+	}
+
+	p.retries = remaining
+}
+
+func (p *Pipeline) giveUp(batch storedBatch, reason string) {
+	p.failed.Add(uint64(len(batch.rows)))
+	utils.Log.Error("Dropping click events", "count", len(batch.rows), "attempts", batch.attempts, "reason", reason)
+}
+
+// clean makes value safe to store as text: invalid UTF-8 is replaced, NUL
+// bytes Postgres refuses are dropped, and it is cut to limit characters
+// without splitting one.
+func clean(value string, limit int) string {
+
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	value = strings.ReplaceAll(value, "\x00", "")
+
+	count := 0
+
+	for i := range value {
+		if count == limit {
+			return value[:i]
+		}
+		count++
+	}
+
+	return value
+}
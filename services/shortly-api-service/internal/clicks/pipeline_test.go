@@ -0,0 +1,248 @@
+package clicks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/webhooks"
+)
+
+// flakyStore fails its first `failures` writes, then stores everything.
+type flakyStore struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	rows     []models.Analytics
+}
+
+func (s *flakyStore) RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+
+	if s.calls <= s.failures {
+		return errors.New("database is down")
+	}
+
+	s.rows = append(s.rows, events...)
+
+	return nil
+}
+
+func (s *flakyStore) stored() []models.Analytics {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.Analytics(nil), s.rows...)
+}
+
+// pickyStore rejects any write containing a row of the bad link, the way
+// Postgres fails a whole insert over one row it can't store.
+type pickyStore struct {
+	flakyStore
+	bad string
+}
+
+func (s *pickyStore) RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error {
+
+	for _, event := range events {
+		if event.UrlID == s.bad {
+			return errors.New("invalid byte sequence for encoding \"UTF8\"")
+		}
+	}
+
+	return s.flakyStore.RecordClicks(ctx, events, increments)
+}
+
+type countingNotifier struct {
+	mu     sync.Mutex
+	clicks int
+}
+
+func (n *countingNotifier) EmitClicks(batch []webhooks.Click) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.clicks += len(batch)
+}
+
+func knownGeo(ip string) lib.GeoInfo {
+	return lib.GeoInfo{Country: "Testland"}
+}
+
+func newTestPipeline(t *testing.T, store Store, notifier Notifier, locate func(ip string) lib.GeoInfo) *Pipeline {
+
+	t.Helper()
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	p := NewPipeline(store, notifier, locate, 100, 10, 10*time.Millisecond, 50*time.Millisecond)
+
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	return p
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailedBatchIsRetried(t *testing.T) {
+
+	store := &flakyStore{failures: 2}
+	notifier := &countingNotifier{}
+	p := newTestPipeline(t, store, notifier, knownGeo)
+
+	for i := 0; i < 3; i++ {
+		p.Enqueue(Event{UrlID: 1, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+	}
+
+	waitFor(t, "the retried batch", func() bool { return len(store.stored()) == 3 })
+
+	stats := p.Stats()
+
+	if stats.Flushed != 3 || stats.Failed != 0 || stats.Retrying != 0 {
+		t.Fatalf("stats = %+v, want 3 flushed and nothing failed or retrying", stats)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+
+	if notifier.clicks != 3 {
+		t.Fatalf("announced %d clicks, want 3", notifier.clicks)
+	}
+}
+
+func TestFailedBatchIsGivenUp(t *testing.T) {
+
+	store := &flakyStore{failures: maxFlushAttempts}
+	p := newTestPipeline(t, store, &countingNotifier{}, knownGeo)
+
+	p.Enqueue(Event{UrlID: 1, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+
+	waitFor(t, "the batch to be given up", func() bool { return p.Stats().Failed == 1 })
+
+	if stats := p.Stats(); stats.Flushed != 0 || stats.Retrying != 0 {
+		t.Fatalf("stats = %+v, want nothing flushed or retrying", stats)
+	}
+
+	// The store works again, later clicks aren't held back
+	p.Enqueue(Event{UrlID: 1, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+
+	waitFor(t, "the next batch", func() bool { return len(store.stored()) == 1 })
+}
+
+func TestSlowGeoLookupDoesNotHoldTheFlush(t *testing.T) {
+
+	release := make(chan struct{})
+
+	slowGeo := func(ip string) lib.GeoInfo {
+		<-release
+		return lib.GeoInfo{Country: "Testland"}
+	}
+
+	store := &flakyStore{}
+	p := newTestPipeline(t, store, &countingNotifier{}, slowGeo)
+
+	p.Enqueue(Event{UrlID: 1, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+
+	waitFor(t, "the first click", func() bool { return len(store.stored()) == 1 })
+
+	if country := store.stored()[0].Country; country != "Unknown" {
+		t.Fatalf("country %q, want Unknown while the lookup is pending", country)
+	}
+
+	close(release)
+
+	// The late answer is cached for the next clicks from the address
+	waitFor(t, "the cached location", func() bool {
+
+		p.geo.mu.Lock()
+		defer p.geo.mu.Unlock()
+
+		_, ok := p.geo.cache["203.0.113.7"]
+
+		return ok
+	})
+
+	p.Enqueue(Event{UrlID: 1, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+
+	waitFor(t, "the second click", func() bool { return len(store.stored()) == 2 })
+
+	if country := store.stored()[1].Country; country != "Testland" {
+		t.Fatalf("country %q, want Testland", country)
+	}
+}
+
+func TestBadRowDoesNotSinkTheBatch(t *testing.T) {
+
+	store := &pickyStore{bad: "13"}
+	p := newTestPipeline(t, store, &countingNotifier{}, knownGeo)
+
+	for _, urlID := range []uint{1, 13, 2} {
+		p.Enqueue(Event{UrlID: urlID, ClickedAt: time.Now(), IPAddress: "203.0.113.7"})
+	}
+
+	waitFor(t, "the bad row to be given up", func() bool { return p.Stats().Failed == 1 })
+
+	stored := store.stored()
+
+	if len(stored) != 2 || stored[0].UrlID != "1" || stored[1].UrlID != "2" {
+		t.Fatalf("stored %+v, want the clicks of links 1 and 2", stored)
+	}
+
+	if stats := p.Stats(); stats.Flushed != 2 || stats.Retrying != 0 {
+		t.Fatalf("stats = %+v, want 2 flushed and nothing retrying", stats)
+	}
+}
+
+func TestClean(t *testing.T) {
+
+	cases := []struct {
+		value string
+		limit int
+		want  string
+	}{
+		{"Mozilla/5.0", 50, "Mozilla/5.0"},
+		{"héllo", 2, "hé"},
+		{"日本語のリファラ", 3, "日本語"},
+		{"bad\xffbyte", 50, "bad\uFFFDbyte"},
+		{"nul\x00byte", 50, "nulbyte"},
+	}
+
+	for _, c := range cases {
+		if got := clean(c.value, c.limit); got != c.want {
+			t.Fatalf("clean(%q, %d) = %q, want %q", c.value, c.limit, got, c.want)
+		}
+	}
+
+	long := clean(strings.Repeat("é", 1000), maxUserAgentLength)
+
+	if !utf8.ValidString(long) || utf8.RuneCountInString(long) != maxUserAgentLength {
+		t.Fatalf("long value cut to %d runes, valid %v", utf8.RuneCountInString(long), utf8.ValidString(long))
+	}
+}
@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/metrics"
+	"shortly-api-service/internal/utils"
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Circuit breaker states, also the values of the kgs_circuit_state gauge
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+var breakerStateNames = map[int]string{
+	breakerClosed:   "closed",
+	breakerOpen:     "open",
+	breakerHalfOpen: "half-open",
+}
+
+// Breaker stops calling KGS after threshold consecutive failures. While open
+// every call fails fast with Unavailable, so callers fall back right away
+// instead of waiting for their deadline. After cooldown a single trial call
+// is let through: success closes the breaker, failure opens it again.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     int
+	failures  int
+	openedAt  time.Time
+	trial     bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// UnaryClientInterceptor guards the key service calls. Health checks go
+// around the breaker so readiness keeps reporting the real KGS status.
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		if b.threshold <= 0 || !strings.HasPrefix(method, "/"+key.KeyService_ServiceDesc.ServiceName+"/") {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if !b.allow() {
+			return status.Error(codes.Unavailable, "KGS circuit breaker is open")
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		b.record(err)
+
+		return err
+	}
+}
+
+func (b *Breaker) allow() bool {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		// One trial call at a time
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) record(err error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+
+	// The caller gave up, that says nothing about KGS
+	if status.Code(err) == codes.Canceled {
+		return
+	}
+
+	if !isKGSFailure(err) {
+		b.failures = 0
+		b.setState(breakerClosed)
+		return
+	}
+
+	b.failures++
+
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+// setState records a transition, the caller holds the lock.
+func (b *Breaker) setState(state int) {
+
+	if b.state == state {
+		return
+	}
+
+	if state == breakerOpen {
+		utils.Log.Warn("KGS circuit breaker opened", "failures", b.failures, "cooldown", b.cooldown)
+	} else {
+		utils.Log.Info("KGS circuit breaker "+breakerStateNames[state], "previous", breakerStateNames[b.state])
+	}
+
+	b.state = state
+	metrics.KGSCircuitState.Set(float64(state))
+}
+
+// isKGSFailure tells whether err says KGS is unhealthy. Errors caused by the
+// caller, such as a cancelled request or an invalid argument, don't count.
+func isKGSFailure(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,215 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/utils"
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blockingKGS hands out numbered keys once release is closed.
+type blockingKGS struct {
+	release chan struct{}
+	calls   atomic.Int32
+	next    atomic.Int64
+	err     error
+}
+
+func (k *blockingKGS) GetKey(ctx context.Context, in *key.Empty, opts ...grpc.CallOption) (*key.KeyResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "GetKey")
+}
+
+func (k *blockingKGS) GetKeys(ctx context.Context, in *key.GetKeysRequest, opts ...grpc.CallOption) (*key.KeysResponse, error) {
+
+	k.calls.Add(1)
+
+	select {
+	case <-k.release:
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+
+	if k.err != nil {
+		return nil, k.err
+	}
+
+	keys := make([]string, 0, in.Count)
+
+	for range in.Count {
+		keys = append(keys, "k"+strconv.FormatInt(k.next.Add(1), 10))
+	}
+
+	return &key.KeysResponse{Keys: keys}, nil
+}
+
+func newTestKeyBuffer(kgs *blockingKGS, size int) *KeyBuffer {
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	return NewKeyBuffer(kgs, "", size, 5*time.Second)
+}
+
+func TestKeyBufferSharesOneRefill(t *testing.T) {
+
+	kgs := &blockingKGS{release: make(chan struct{})}
+	buffer := newTestKeyBuffer(kgs, 100)
+
+	var wg sync.WaitGroup
+	taken := make(chan string, 20)
+
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			keys, err := buffer.Take(context.Background(), 1)
+
+			if err != nil {
+				t.Errorf("take: %v", err)
+				return
+			}
+
+			taken <- keys[0]
+		}()
+	}
+
+	// Everyone is waiting on the single call in flight
+	for kgs.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(kgs.release)
+
+	wg.Wait()
+	close(taken)
+
+	if calls := kgs.calls.Load(); calls != 1 {
+		t.Fatalf("%d GetKeys calls, want 1", calls)
+	}
+
+	seen := make(map[string]bool)
+
+	for key := range taken {
+		if seen[key] {
+			t.Fatalf("key %s handed out twice", key)
+		}
+		seen[key] = true
+	}
+
+	if len(seen) != 20 {
+		t.Fatalf("%d keys handed out, want 20", len(seen))
+	}
+}
+
+func TestKeyBufferWaiterKeepsItsDeadline(t *testing.T) {
+
+	kgs := &blockingKGS{release: make(chan struct{})}
+	buffer := newTestKeyBuffer(kgs, 100)
+
+	go buffer.Take(context.Background(), 1)
+
+	for kgs.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	if _, err := buffer.Take(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the waiter's own deadline", err)
+	}
+
+	if waited := time.Since(start); waited > time.Second {
+		t.Fatalf("waiter blocked %s behind the refill", waited)
+	}
+
+	close(kgs.release)
+}
+
+func TestKeyBufferRefillAfterCanceledCaller(t *testing.T) {
+
+	kgs := &blockingKGS{release: make(chan struct{})}
+	buffer := newTestKeyBuffer(kgs, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := make(chan error, 1)
+
+	go func() {
+		_, err := buffer.Take(ctx, 1)
+		first <- err
+	}()
+
+	for kgs.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan error, 1)
+
+	go func() {
+		_, err := buffer.Take(context.Background(), 1)
+		second <- err
+	}()
+
+	// The caller that went to KGS gives up, the waiter refills for itself
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-first; err == nil {
+		t.Fatal("canceled caller got keys")
+	}
+
+	close(kgs.release)
+
+	if err := <-second; err != nil {
+		t.Fatalf("waiter: %v", err)
+	}
+}
+
+func TestKeyBufferSharesRefillError(t *testing.T) {
+
+	kgs := &blockingKGS{release: make(chan struct{}), err: status.Error(codes.Unavailable, "KGS is down")}
+	buffer := newTestKeyBuffer(kgs, 10)
+
+	errs := make(chan error, 5)
+
+	for range 5 {
+		go func() {
+			_, err := buffer.Take(context.Background(), 1)
+			errs <- err
+		}()
+	}
+
+	for kgs.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(kgs.release)
+
+	for range 5 {
+		if err := <-errs; status.Code(err) != codes.Unavailable {
+			t.Fatalf("got %v, want Unavailable", err)
+		}
+	}
+
+	if calls := kgs.calls.Load(); calls != 1 {
+		t.Fatalf("%d GetKeys calls, want 1", calls)
+	}
+}
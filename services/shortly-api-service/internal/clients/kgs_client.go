@@ -1,29 +1,295 @@
 package clients
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"shortly-api-service/config"
 	"shortly-api-service/internal/utils"
 	"shortly-proto/gen/key"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
 )
 
+// Upper bound KGS accepts for a single GetKeys call
+const maxKeysPerCall = 1000
+
 var KGSClient key.KeyServiceClient
 
+var kgsConn *grpc.ClientConn
+
+// KGSHealth asks KGS for the serving status of its key service
+var KGSHealth healthpb.HealthClient
+
+var KeyPool *KeyBuffer
+
+// kgsServiceConfig balances calls round robin over every resolved KGS
+// replica and retries key requests that fail with UNAVAILABLE, which KGS
+// answers while a pool is refilling and gRPC reports for dead connections.
+const kgsServiceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"methodConfig": [{
+		"name": [{"service": "%s"}],
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
 func InitKGSClient() {
 
-	conn, err := grpc.NewClient(
-		config.AppConfig.KGS_GRPC_ADDRESS,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	target, resolvers := kgsTarget(config.AppConfig.KGS_GRPC_ADDRESS)
+
+	breaker := NewBreaker(config.AppConfig.KGS_BREAKER_FAILURES, config.AppConfig.KGS_BREAKER_COOLDOWN)
+
+	creds, err := kgsTransportCredentials()
+
+	if err != nil {
+		utils.Log.Error("Failed to load KGS TLS credentials", "error", err)
+		panic(err)
+	}
+
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithResolvers(resolvers...),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(kgsServiceConfig, key.KeyService_ServiceDesc.ServiceName, max(config.AppConfig.KGS_MAX_ATTEMPTS, 1))),
+		grpc.WithUnaryInterceptor(breaker.UnaryClientInterceptor()),
+	}
+
+	if config.AppConfig.KGS_AUTH_TOKEN != "" {
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials{
+			token:  config.AppConfig.KGS_AUTH_TOKEN,
+			secure: config.AppConfig.KGS_TLS_CA_FILE != "",
+		}))
+	}
+
+	conn, err := grpc.NewClient(target, options...)
 
 	if err != nil {
 		utils.Log.Error("Failed to connect to KGS gRPC service", "error", err)
 		panic(err)
 	}
 
+	kgsConn = conn
 	KGSClient = key.NewKeyServiceClient(conn)
+	KGSHealth = healthpb.NewHealthClient(conn)
+
+	KeyPool = NewKeyBuffer(KGSClient, config.AppConfig.KGS_KEY_POOL, config.AppConfig.KGS_KEY_BUFFER_SIZE, config.AppConfig.KGS_CALL_TIMEOUT)
+
+	utils.Log.Info("Connected to KGS gRPC service", "target", target, "tls", config.AppConfig.KGS_TLS_CA_FILE != "")
+}
+
+// kgsTarget turns KGS_GRPC_ADDRESS into a dial target. A comma separated
+// list of replicas is served by a static resolver, anything else (a single
+// host:port or a resolver URI such as dns:///kgs:50051) is dialled as is.
+func kgsTarget(address string) (string, []resolver.Builder) {
+
+	if !strings.Contains(address, ",") {
+		return address, nil
+	}
+
+	addresses := make([]resolver.Address, 0)
+
+	for _, addr := range strings.Split(address, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, resolver.Address{Addr: addr})
+		}
+	}
+
+	replicas := manual.NewBuilderWithScheme("kgs")
+	replicas.InitialState(resolver.State{Addresses: addresses})
+
+	return replicas.Scheme() + ":///kgs", []resolver.Builder{replicas}
+}
+
+func CloseKGSClient() {
+
+	if kgsConn == nil {
+		return
+	}
+
+	if err := kgsConn.Close(); err != nil {
+		utils.Log.Warn("Error closing KGS connection", "error", err)
+	} else {
+		utils.Log.Info("KGS connection closed")
+	}
+}
+
+// KeyBuffer keeps a local pool of keys reserved from KGS so most short links
+// can be created without a network hop. Keys still in the buffer when the
+// process exits are never issued; KGS has plenty to spare.
+type KeyBuffer struct {
+	mu        sync.Mutex
+	client    key.KeyServiceClient
+	pool      string
+	size      int
+	timeout   time.Duration
+	keys      []string
+	refilling *refill
+}
+
+// refill is a trip to KGS in flight. Callers finding the buffer short while
+// one runs wait for it instead of holding the lock or calling KGS too.
+type refill struct {
+	done chan struct{}
+	err  error
+}
+
+// NewKeyBuffer returns a buffer refilled size keys at a time from the KGS
+// key pool named pool, an empty name meaning KGS's default pool.
+// A size of zero disables buffering and every call goes straight to KGS.
+// Each call to KGS, retries included, is bounded by timeout on top of the
+// caller's own deadline.
+func NewKeyBuffer(client key.KeyServiceClient, pool string, size int, timeout time.Duration) *KeyBuffer {
+	return &KeyBuffer{
+		client:  client,
+		pool:    pool,
+		size:    size,
+		timeout: timeout,
+	}
+}
+
+// Next returns a single unused key.
+func (b *KeyBuffer) Next(ctx context.Context) (string, error) {
+
+	keys, err := b.Take(ctx, 1)
+
+	if err != nil {
+		return "", err
+	}
+
+	return keys[0], nil
+}
+
+// Take returns n unused keys, refilling the buffer from KGS when it runs short.
+func (b *KeyBuffer) Take(ctx context.Context, n int) ([]string, error) {
+
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	if b.size == 0 {
+		keys, err := b.fetch(ctx, n)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	for {
+		b.mu.Lock()
+
+		if len(b.keys) >= n {
+			taken := make([]string, n)
+			copy(taken, b.keys[:n])
+			b.keys = b.keys[n:]
+
+			b.mu.Unlock()
+
+			return taken, nil
+		}
+
+		if current := b.refilling; current != nil {
+			b.mu.Unlock()
+
+			select {
+			case <-current.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			// Only the caller that went to KGS gave up, try again with ours
+			if current.err != nil && !isCanceled(current.err) {
+				return nil, current.err
+			}
+
+			continue
+		}
+
+		current := &refill{done: make(chan struct{})}
+		b.refilling = current
+		count := max(n-len(b.keys), b.size)
+
+		b.mu.Unlock()
+
+		fetched, err := b.fetch(ctx, count)
+
+		b.mu.Lock()
+		b.keys = append(b.keys, fetched...)
+		b.refilling = nil
+		b.mu.Unlock()
+
+		current.err = err
+		close(current.done)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CheckKGS fails unless KGS reports its key service as SERVING.
+func CheckKGS(ctx context.Context) error {
+
+	res, err := KGSHealth.Check(ctx, &healthpb.HealthCheckRequest{
+		Service: key.KeyService_ServiceDesc.ServiceName,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("KGS reports %s", res.GetStatus())
+	}
+
+	return nil
+}
+
+// fetch reserves n keys from KGS. On failure it still returns the keys
+// reserved so far, so the buffer can keep them instead of dropping them.
+func (b *KeyBuffer) fetch(ctx context.Context, n int) ([]string, error) {
+
+	keys := make([]string, 0, n)
+
+	for len(keys) < n {
+		callCtx, cancel := context.WithTimeout(ctx, b.timeout)
+
+		res, err := b.client.GetKeys(callCtx, &key.GetKeysRequest{
+			Count: int32(min(n-len(keys), maxKeysPerCall)),
+			Pool:  b.pool,
+		})
+
+		cancel()
+
+		if err != nil {
+			return keys, err
+		}
+
+		keys = append(keys, res.Keys...)
+	}
+
+	utils.Log.Info("Reserved keys from KGS", "pool", b.pool, "count", len(keys))
+
+	return keys, nil
+}
 
-	utils.Log.Info("Connected to KGS gRPC service")
+// isCanceled tells whether err comes from a caller giving up rather than KGS.
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled
 }
@@ -0,0 +1,73 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"shortly-api-service/config"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// kgsTransportCredentials returns TLS credentials when a KGS CA is
+// configured, with a client certificate for mTLS when one is set too, and
+// plaintext otherwise.
+func kgsTransportCredentials() (credentials.TransportCredentials, error) {
+
+	cfg := config.AppConfig
+
+	if cfg.KGS_TLS_CA_FILE == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	pem, err := os.ReadFile(cfg.KGS_TLS_CA_FILE)
+
+	if err != nil {
+		return nil, fmt.Errorf("read KGS CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.KGS_TLS_CA_FILE)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    pool,
+		ServerName: cfg.KGS_TLS_SERVER_NAME,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.KGS_TLS_CERT_FILE != "" {
+
+		cert, err := tls.LoadX509KeyPair(cfg.KGS_TLS_CERT_FILE, cfg.KGS_TLS_KEY_FILE)
+
+		if err != nil {
+			return nil, fmt.Errorf("load KGS client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// tokenCredentials sends the shared KGS service token with every call.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity keeps the token off plaintext connections once
+// TLS is configured, while still allowing it on local plaintext setups.
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
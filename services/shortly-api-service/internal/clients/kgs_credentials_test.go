@@ -0,0 +1,339 @@
+package clients
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const kgsServerName = "kgs.internal"
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+
+	t.Helper()
+
+	privateKey := newTestKey(t)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+
+	return &testCA{cert: cert, key: privateKey}
+}
+
+func (ca *testCA) issue(t *testing.T, commonName string) tls.Certificate {
+
+	t.Helper()
+
+	privateKey := newTestKey(t)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	if err != nil {
+		t.Fatalf("serial: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &privateKey.PublicKey, ca.key)
+
+	if err != nil {
+		t.Fatalf("issue %s: %v", commonName, err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return pool
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	return privateKey
+}
+
+func writeTestFile(t *testing.T, name string, block *pem.Block) string {
+
+	t.Helper()
+
+	if err := os.WriteFile(name, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return name
+}
+
+// useKgsTLS points the KGS_TLS_* settings at files for ca and client.
+func useKgsTLS(t *testing.T, ca *testCA, client *tls.Certificate) {
+
+	t.Helper()
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	dir := t.TempDir()
+
+	config.AppConfig.KGS_TLS_CA_FILE = writeTestFile(t, filepath.Join(dir, "ca.pem"), &pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	config.AppConfig.KGS_TLS_SERVER_NAME = kgsServerName
+	config.AppConfig.KGS_TLS_CERT_FILE = ""
+	config.AppConfig.KGS_TLS_KEY_FILE = ""
+
+	if client != nil {
+
+		keyDER, err := x509.MarshalECPrivateKey(client.PrivateKey.(*ecdsa.PrivateKey))
+
+		if err != nil {
+			t.Fatalf("marshal key: %v", err)
+		}
+
+		config.AppConfig.KGS_TLS_CERT_FILE = writeTestFile(t, filepath.Join(dir, "client.pem"), &pem.Block{Type: "CERTIFICATE", Bytes: client.Certificate[0]})
+		config.AppConfig.KGS_TLS_KEY_FILE = writeTestFile(t, filepath.Join(dir, "client-key.pem"), &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+}
+
+type keyService struct {
+	key.UnimplementedKeyServiceServer
+	token string
+}
+
+// GetKey only answers callers that sent the expected service token.
+func (k keyService) GetKey(ctx context.Context, req *key.Empty) (*key.KeyResponse, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get("authorization"); len(values) != 1 || values[0] != "Bearer "+k.token {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid service credentials")
+	}
+
+	return &key.KeyResponse{Key: "abc123"}, nil
+}
+
+// startKGS serves the key service over TLS, requiring a client certificate
+// signed by clientCA when one is given.
+func startKGS(t *testing.T, serverCA *testCA, clientCA *testCA, token string) string {
+
+	t.Helper()
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{serverCA.issue(t, kgsServerName)},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCA != nil {
+		tlsConfig.ClientCAs = clientCA.pool()
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+
+	key.RegisterKeyServiceServer(server, keyService{token: token})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+// callKGS dials address the way InitKGSClient does and asks for one key.
+func callKGS(t *testing.T, address string, token string) error {
+
+	t.Helper()
+
+	creds, err := kgsTransportCredentials()
+
+	if err != nil {
+		t.Fatalf("transport credentials: %v", err)
+	}
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials{token: token, secure: true}))
+	}
+
+	conn, err := grpc.NewClient(address, options...)
+
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = key.NewKeyServiceClient(conn).GetKey(ctx, &key.Empty{})
+
+	return err
+}
+
+func TestKgsMutualTLS(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	client := ca.issue(t, "shortly-api")
+
+	useKgsTLS(t, ca, &client)
+
+	address := startKGS(t, ca, ca, "s3cret")
+
+	if err := callKGS(t, address, "s3cret"); err != nil {
+		t.Fatalf("mTLS call with the right token: %v", err)
+	}
+
+	if err := callKGS(t, address, "guess"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("wrong token: got %v, want Unauthenticated", err)
+	}
+
+	if err := callKGS(t, address, ""); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("missing token: got %v, want Unauthenticated", err)
+	}
+}
+
+func TestKgsClientCertificateRequired(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+
+	// Server side TLS only, KGS asks for a certificate the client doesn't have
+	useKgsTLS(t, ca, nil)
+
+	address := startKGS(t, ca, ca, "s3cret")
+
+	if err := callKGS(t, address, "s3cret"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+}
+
+func TestKgsClientCertificateFromUntrustedCA(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	rogue := newTestCA(t, "rogue CA")
+	client := rogue.issue(t, "shortly-api")
+
+	useKgsTLS(t, ca, &client)
+
+	address := startKGS(t, ca, ca, "s3cret")
+
+	if err := callKGS(t, address, "s3cret"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+}
+
+func TestKgsServerFromUntrustedCA(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	rogue := newTestCA(t, "rogue CA")
+
+	// The API only trusts ca, the server presents a rogue certificate
+	useKgsTLS(t, ca, nil)
+
+	address := startKGS(t, rogue, nil, "s3cret")
+
+	if err := callKGS(t, address, "s3cret"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+}
+
+func TestKgsTransportCredentialsPlaintext(t *testing.T) {
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	config.AppConfig.KGS_TLS_CA_FILE = ""
+
+	creds, err := kgsTransportCredentials()
+
+	if err != nil {
+		t.Fatalf("transport credentials: %v", err)
+	}
+
+	if protocol := creds.Info().SecurityProtocol; protocol != "insecure" {
+		t.Fatalf("security protocol %q, want insecure", protocol)
+	}
+
+	// A token marked secure is never sent over the plaintext connection
+	if _, err := grpc.NewClient("127.0.0.1:1", grpc.WithTransportCredentials(creds), grpc.WithPerRPCCredentials(tokenCredentials{token: "s3cret", secure: true})); err == nil {
+		t.Fatal("secure token credentials accepted on a plaintext connection")
+	}
+}
+
+func TestKgsTransportCredentialsMissingCA(t *testing.T) {
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	config.AppConfig.KGS_TLS_CA_FILE = filepath.Join(t.TempDir(), "missing.pem")
+
+	if _, err := kgsTransportCredentials(); err == nil {
+		t.Fatal("missing CA file accepted")
+	}
+}
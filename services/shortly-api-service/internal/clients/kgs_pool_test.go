@@ -0,0 +1,179 @@
+package clients
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/utils"
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	base62    = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowercase = "abcdefghijklmnopqrstuvwxyz"
+)
+
+type testPool struct {
+	length   int
+	alphabet string
+}
+
+// poolService hands out keys shaped like the KGS pool the request names,
+// the default pool when it names none.
+type poolService struct {
+	key.UnimplementedKeyServiceServer
+	pools map[string]testPool
+}
+
+func (s poolService) GetKeys(ctx context.Context, req *key.GetKeysRequest) (*key.KeysResponse, error) {
+
+	name := req.GetPool()
+
+	if name == "" {
+		name = "default"
+	}
+
+	pool, ok := s.pools[name]
+
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown key pool %q", req.GetPool())
+	}
+
+	keys := make([]string, 0, req.GetCount())
+
+	for range req.GetCount() {
+
+		var b strings.Builder
+
+		for range pool.length {
+			i, _ := rand.Int(rand.Reader, big.NewInt(int64(len(pool.alphabet))))
+			b.WriteByte(pool.alphabet[i.Int64()])
+		}
+
+		keys = append(keys, b.String())
+	}
+
+	return &key.KeysResponse{Keys: keys}, nil
+}
+
+// startPoolKGS serves poolService in plaintext and points the API's KGS
+// client settings at it.
+func startPoolKGS(t *testing.T) {
+
+	t.Helper()
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	server := grpc.NewServer()
+
+	key.RegisterKeyServiceServer(server, poolService{pools: map[string]testPool{
+		"default": {length: 6, alphabet: base62},
+		"branded": {length: 7, alphabet: lowercase},
+	}})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	config.AppConfig.KGS_GRPC_ADDRESS = listener.Addr().String()
+	config.AppConfig.KGS_KEY_BUFFER_SIZE = 10
+	config.AppConfig.KGS_CALL_TIMEOUT = 5 * time.Second
+	config.AppConfig.KGS_MAX_ATTEMPTS = 3
+	config.AppConfig.KGS_TLS_CA_FILE = ""
+	config.AppConfig.KGS_AUTH_TOKEN = ""
+}
+
+// takeFromPool connects the way the API does at startup, with KGS_KEY_POOL
+// set to pool, and takes n keys through the key buffer.
+func takeFromPool(t *testing.T, pool string, n int) ([]string, error) {
+
+	t.Helper()
+
+	config.AppConfig.KGS_KEY_POOL = pool
+
+	InitKGSClient()
+	t.Cleanup(CloseKGSClient)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return KeyPool.Take(ctx, n)
+}
+
+func assertShape(t *testing.T, keys []string, n int, length int, alphabet string) {
+
+	t.Helper()
+
+	if len(keys) != n {
+		t.Fatalf("got %d keys, want %d", len(keys), n)
+	}
+
+	for _, k := range keys {
+
+		if len(k) != length {
+			t.Fatalf("key %q has length %d, want %d", k, len(k), length)
+		}
+
+		for _, c := range k {
+			if !strings.ContainsRune(alphabet, c) {
+				t.Fatalf("key %q has %q outside the pool's alphabet", k, c)
+			}
+		}
+	}
+}
+
+func TestKeyBufferUsesConfiguredPool(t *testing.T) {
+
+	startPoolKGS(t)
+
+	// More than a buffer's worth, so the refill asks for the pool as well
+	keys, err := takeFromPool(t, "branded", 25)
+
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+
+	assertShape(t, keys, 25, 7, lowercase)
+}
+
+func TestKeyBufferDefaultPool(t *testing.T) {
+
+	startPoolKGS(t)
+
+	keys, err := takeFromPool(t, "", 5)
+
+	if err != nil {
+		t.Fatalf("take: %v", err)
+	}
+
+	assertShape(t, keys, 5, 6, base62)
+}
+
+func TestKeyBufferUnknownPool(t *testing.T) {
+
+	startPoolKGS(t)
+
+	if _, err := takeFromPool(t, "missing", 1); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+}
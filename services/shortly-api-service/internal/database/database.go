@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"shortly-api-service/config"
+	"shortly-api-service/internal/metrics"
 	"shortly-api-service/internal/utils"
 
 	"gorm.io/driver/postgres"
@@ -47,6 +48,10 @@ func ConnectDB() error {
 	sqlDB.SetMaxIdleConns(15)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
+	if err := metrics.InstrumentDB(db); err != nil {
+		return fmt.Errorf("❌ Failed to register database metrics: %w", err)
+	}
+
 	utils.Log.Info("✅ Database connected successfully")
 
 	DB = db
@@ -54,3 +59,23 @@ func ConnectDB() error {
 	return nil
 
 }
+
+func CloseDB() {
+
+	if DB == nil {
+		return
+	}
+
+	sqlDB, err := DB.DB()
+
+	if err != nil {
+		utils.Log.Warn("Error getting database pool", "error", err)
+		return
+	}
+
+	if err := sqlDB.Close(); err != nil {
+		utils.Log.Warn("Error closing database pool", "error", err)
+	} else {
+		utils.Log.Info("Database connection closed")
+	}
+}
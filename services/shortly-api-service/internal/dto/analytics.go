@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type AnalyticsResponse struct {
 	IPAddress string `json:"ipAddress"`
 	OS        string `json:"os"`
@@ -9,4 +11,41 @@ type AnalyticsResponse struct {
 	ClickedAt string `json:"clickedAt"`
 	Referrer  string `json:"referrer"`
 	Country   string `json:"country"`
+	Region    string `json:"region"`
+	City      string `json:"city"`
+	ASN       uint   `json:"asn"`
+	ASOrg     string `json:"asOrg"`
+}
+
+type PaginationDTO struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"totalPages"`
+}
+
+type AnalyticsBucketDTO struct {
+	Time   time.Time `json:"time"`
+	Clicks int64     `json:"clicks"`
+}
+
+type AnalyticsTimeseriesDTO struct {
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	Interval string               `json:"interval"`
+	Total    int64                `json:"total"`
+	Buckets  []AnalyticsBucketDTO `json:"buckets"`
+}
+
+type AnalyticsBreakdownItemDTO struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+type AnalyticsBreakdownDTO struct {
+	Dimension string                      `json:"dimension"`
+	From      time.Time                   `json:"from"`
+	To        time.Time                   `json:"to"`
+	Total     int64                       `json:"total"`
+	Items     []AnalyticsBreakdownItemDTO `json:"items"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type ApiKeyDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateApiKeyDTO is only returned once, the plain key can't be recovered later
+type CreateApiKeyDTO struct {
+	ApiKeyDTO
+	Key string `json:"key"`
+}
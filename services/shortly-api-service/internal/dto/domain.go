@@ -0,0 +1,13 @@
+package dto
+
+import "time"
+
+type DomainResponseDTO struct {
+	ID         uint       `json:"id"`
+	Host       string     `json:"host"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	TXTRecord  string     `json:"txt_record"`
+	TXTValue   string     `json:"txt_value"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type DependencyStatusDTO struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
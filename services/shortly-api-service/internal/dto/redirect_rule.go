@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type RedirectRuleDTO struct {
+	ID          uint       `json:"id"`
+	Position    int        `json:"position"`
+	Destination string     `json:"destination"`
+	Devices     []string   `json:"devices"`
+	OS          []string   `json:"os"`
+	Countries   []string   `json:"countries"`
+	Languages   []string   `json:"languages"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -3,26 +3,55 @@ package dto
 import "time"
 
 type CreateUrlResponseDTO struct {
-	ID          uint   `json:"id"`
-	OriginalURL string `json:"original_url"`
-	ShortKey    string `json:"short_url"`
-	Title       string `json:"title"`
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ShortKey    string     `json:"short_url"`
+	Domain      string     `json:"domain,omitempty"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`
+	Title       string     `json:"title"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   *int       `json:"max_clicks,omitempty"`
+	Protected   bool       `json:"password_protected"`
 }
 
 type GetUrlResponseDTO struct {
-	ID          uint      `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortKey    string    `json:"short_url"`
-	Title       string    `json:"title"`
-	Clicks      int       `json:"clicks"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ShortKey    string     `json:"short_url"`
+	Domain      string     `json:"domain,omitempty"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`
+	Title       string     `json:"title"`
+	Clicks      int        `json:"clicks"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   *int       `json:"max_clicks,omitempty"`
+	Protected   bool       `json:"password_protected"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 type UpdateUrlResponseDTO struct {
-	ID          uint      `json:"id"`
-	OriginalURL string    `json:"original_url"`
-	ShortKey    string    `json:"short_url"`
-	Title       string    `json:"title"`
-	Clicks      int       `json:"clicks"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ShortKey    string     `json:"short_url"`
+	Title       string     `json:"title"`
+	Clicks      int        `json:"clicks"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxClicks   *int       `json:"max_clicks,omitempty"`
+	Protected   bool       `json:"password_protected"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type BulkUrlResultDTO struct {
+	Index           int               `json:"index"`
+	Status          string            `json:"status"`
+	OriginalURL     string            `json:"original_url"`
+	ID              uint              `json:"id,omitempty"`
+	ShortKey        string            `json:"short_url,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	ValidationError map[string]string `json:"validation_error,omitempty"`
+}
+
+type BulkUrlResponseDTO struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []BulkUrlResultDTO `json:"results"`
 }
@@ -0,0 +1,61 @@
+package dto
+
+import "time"
+
+type WebhookDTO struct {
+	ID          uint      `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	WorkspaceID *uint     `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateWebhookDTO is only returned once, the secret isn't listed afterwards
+type CreateWebhookDTO struct {
+	WebhookDTO
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryDTO struct {
+	ID             uint       `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookEventDTO is the body POSTed to webhook endpoints
+type WebhookEventDTO struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookUrlDataDTO struct {
+	ID          uint       `json:"id"`
+	OriginalURL string     `json:"original_url"`
+	ShortKey    string     `json:"short_url"`
+	DomainID    uint       `json:"domain_id,omitempty"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`
+	Title       string     `json:"title"`
+	Clicks      int        `json:"clicks"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type WebhookClickDataDTO struct {
+	UrlID     uint      `json:"url_id"`
+	ShortKey  string    `json:"short_url"`
+	ClickedAt time.Time `json:"clicked_at"`
+	Country   string    `json:"country"`
+	Device    string    `json:"device"`
+	Browser   string    `json:"browser"`
+	OS        string    `json:"os"`
+	Referrer  string    `json:"referrer"`
+}
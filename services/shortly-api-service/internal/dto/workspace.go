@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type WorkspaceDTO struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMemberDTO struct {
+	UserID   uint      `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -2,80 +2,61 @@ package handlers
 
 import (
 	"net/http"
-	"shortly-api-service/internal/database"
+	"slices"
+	"strconv"
+	"time"
+
 	"shortly-api-service/internal/dto"
 	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
 	"shortly-api-service/internal/utils"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetAnalytics(ctx *gin.Context) {
-
-	idInterface, exists := ctx.Get("id")
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	maxAnalyticsRange     = 366 * 24 * time.Hour
+	maxHourlyRange        = 31 * 24 * time.Hour
+)
 
-	if !exists {
-		utils.Log.Error("Id not found in context")
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Unauthorized: Id is missing from context",
-		})
-		return
-	}
+func (s *Server) GetAnalytics(ctx *gin.Context) {
 
-	urlId := ctx.Param("urlId")
+	url, ok := s.ownedAnalyticsUrl(ctx)
 
-	if urlId == "" {
-		utils.Log.Error("Missing urlId in request path")
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Missing urlId in path",
-		})
+	if !ok {
 		return
 	}
 
-	id, ok := idInterface.(int)
+	from, to, ok := analyticsRange(ctx, maxAnalyticsRange)
 
 	if !ok {
-		utils.Log.Error("Failed to assert id type from context")
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Internal server error",
-		})
 		return
 	}
 
-	idStr := strconv.Itoa(id)
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
 
-	if err := database.DB.Where("id = ?", idStr).Error; err != nil {
-		utils.Log.Error("User not found", "error", err)
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "User not found",
-		})
+	if page < 1 {
+		page = 1
 	}
 
-	var analytics []models.Analytics
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	analytics, total, err := s.Analytics.List(ctx.Request.Context(), url.ID, from, to, (page-1)*limit, limit)
 
-	if err := database.DB.Where("url_id  = ?", urlId).Order("clicked_at desc").Find(&analytics).Error; err != nil {
+	if err != nil {
 		utils.Log.Error("Failed to fetch analytics", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to fetch analytics",
 		})
-	}
-
-	if len(analytics) == 0 {
-		utils.Log.Warn("No analytics found for urlId", "urlId", urlId)
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "No analytics found for this URL",
-		})
 		return
 	}
 
-	var response []dto.AnalyticsResponse = make([]dto.AnalyticsResponse, 0)
+	var response []dto.AnalyticsResponse = make([]dto.AnalyticsResponse, 0, len(analytics))
 
 	for _, a := range analytics {
 		response = append(response, dto.AnalyticsResponse{
@@ -86,6 +67,10 @@ func GetAnalytics(ctx *gin.Context) {
 			UserAgent: a.UserAgent,
 			Referrer:  a.Referrer,
 			Country:   a.Country,
+			Region:    a.Region,
+			City:      a.City,
+			ASN:       a.ASN,
+			ASOrg:     a.ASOrg,
 			ClickedAt: a.ClickedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
@@ -93,7 +78,233 @@ func GetAnalytics(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    response,
+		"pagination": dto.PaginationDTO{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+		"message": "Analytics retrieved successfully",
+	})
+
+}
+
+func (s *Server) GetAnalyticsTimeseries(ctx *gin.Context) {
+
+	url, ok := s.ownedAnalyticsUrl(ctx)
+
+	if !ok {
+		return
+	}
+
+	interval := ctx.DefaultQuery("interval", "day")
+
+	var step time.Duration
+	var maxRange time.Duration
+
+	switch interval {
+	case "day":
+		step, maxRange = 24*time.Hour, maxAnalyticsRange
+	case "hour":
+		step, maxRange = time.Hour, maxHourlyRange
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "interval must be either day or hour",
+		})
+		return
+	}
+
+	from, to, ok := analyticsRange(ctx, maxRange)
+
+	if !ok {
+		return
+	}
+
+	rows, err := s.Analytics.Timeseries(ctx.Request.Context(), url.ID, from, to, interval)
+
+	if err != nil {
+		utils.Log.Error("Failed to aggregate analytics", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch analytics",
+		})
+		return
+	}
+
+	counts := make(map[int64]int64, len(rows))
+
+	for _, row := range rows {
+		counts[row.Bucket.Unix()] = row.Clicks
+	}
+
+	// Emit every bucket in the range so charts don't have to fill gaps
+	buckets := make([]dto.AnalyticsBucketDTO, 0)
+	var total int64
+
+	for t := from.UTC().Truncate(step); t.Before(to); t = t.Add(step) {
+		clicks := counts[t.Unix()]
+		total += clicks
+		buckets = append(buckets, dto.AnalyticsBucketDTO{Time: t, Clicks: clicks})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": dto.AnalyticsTimeseriesDTO{
+			From:     from,
+			To:       to,
+			Interval: interval,
+			Total:    total,
+			Buckets:  buckets,
+		},
+		"message": "Analytics retrieved successfully",
+	})
+}
+
+func (s *Server) GetAnalyticsBreakdown(ctx *gin.Context) {
+
+	url, ok := s.ownedAnalyticsUrl(ctx)
+
+	if !ok {
+		return
+	}
+
+	dimension := ctx.Param("dimension")
+
+	if !slices.Contains(repository.AnalyticsDimensions, dimension) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "dimension must be one of country, region, city, device, browser, os or referrer",
+		})
+		return
+	}
+
+	from, to, ok := analyticsRange(ctx, maxAnalyticsRange)
+
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	rows, total, err := s.Analytics.Breakdown(ctx.Request.Context(), url.ID, from, to, dimension, limit)
+
+	if err != nil {
+		utils.Log.Error("Failed to aggregate analytics", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch analytics",
+		})
+		return
+	}
+
+	items := make([]dto.AnalyticsBreakdownItemDTO, 0, len(rows))
+
+	for _, row := range rows {
+		items = append(items, dto.AnalyticsBreakdownItemDTO{Value: row.Value, Clicks: row.Clicks})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": dto.AnalyticsBreakdownDTO{
+			Dimension: dimension,
+			From:      from,
+			To:        to,
+			Total:     total,
+			Items:     items,
+		},
 		"message": "Analytics retrieved successfully",
 	})
+}
+
+// ownedAnalyticsUrl loads the :urlId URL through the shared URL policy, so
+// analytics of links outside the caller's scope answer 404.
+func (s *Server) ownedAnalyticsUrl(ctx *gin.Context) (models.Url, bool) {
+
+	urlId, err := strconv.ParseUint(ctx.Param("urlId"), 10, 64)
+
+	if err != nil {
+		utils.Log.Error("Invalid urlId in request path", "urlId", ctx.Param("urlId"))
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid urlId in path",
+		})
+		return models.Url{}, false
+	}
+
+	return s.findScopedUrl(ctx, repository.UrlFilter{ID: uint(urlId)})
+}
+
+// analyticsRange reads the from/to query parameters (RFC 3339 or YYYY-MM-DD,
+// a date-only "to" including the whole day), defaulting to the last 30 days.
+func analyticsRange(ctx *gin.Context, maxRange time.Duration) (time.Time, time.Time, bool) {
+
+	to := time.Now().UTC()
+
+	if value := ctx.Query("to"); value != "" {
+		parsed, dateOnly, err := parseAnalyticsTime(value)
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "to must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+
+		if dateOnly {
+			parsed = parsed.Add(24 * time.Hour)
+		}
+
+		to = parsed
+	}
+
+	from := to.Add(-defaultAnalyticsRange)
+
+	if value := ctx.Query("from"); value != "" {
+		parsed, _, err := parseAnalyticsTime(value)
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "from must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "from must be before to",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+
+	if to.Sub(from) > maxRange {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Requested date range is too large",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+func parseAnalyticsTime(value string) (time.Time, bool, error) {
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
 
+	return t, true, err
 }
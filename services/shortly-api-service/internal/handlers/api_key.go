@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyPrefix       = "shk_"
+	apiKeyDisplayChars = 12
+	maxApiKeysPerUser  = 25
+)
+
+func (s *Server) CreateApiKey(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	var data validators.CreateApiKeyValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+
+	validationErrors := validators.ValidateCreateApiKeyData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	active, err := s.ApiKeys.CountActive(ctx.Request.Context(), uint(id))
+
+	if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if active >= maxApiKeysPerUser {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "API key limit reached, revoke an unused key first",
+		})
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+
+	if err != nil {
+		utils.Log.Error("Could not generate API key", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	key := apiKeyPrefix + secret
+
+	apiKey := models.ApiKey{
+		UserID:    uint(id),
+		Name:      data.Name,
+		Prefix:    key[:apiKeyDisplayChars],
+		KeyHash:   utils.HashToken(key),
+		Scopes:    strings.Join(data.Scopes, ","),
+		ExpiresAt: data.ExpiresAt,
+	}
+
+	if err := s.ApiKeys.Create(ctx.Request.Context(), &apiKey); err != nil {
+		utils.Log.Error("Failed to create API key", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create API key",
+		})
+		return
+	}
+
+	utils.Log.Info("API key created", "api_key_id", apiKey.ID, "userID", id, "scopes", apiKey.Scopes)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": dto.CreateApiKeyDTO{
+			ApiKeyDTO: toApiKeyDTO(apiKey),
+			Key:       key,
+		},
+		"message": "API key created, copy it now as it won't be shown again",
+	})
+}
+
+func (s *Server) GetApiKeys(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	apiKeys, err := s.ApiKeys.ListActive(ctx.Request.Context(), uint(id))
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch API keys", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch API keys",
+		})
+		return
+	}
+
+	response := make([]dto.ApiKeyDTO, 0, len(apiKeys))
+
+	for _, apiKey := range apiKeys {
+		response = append(response, toApiKeyDTO(apiKey))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "API keys retrieved successfully",
+	})
+}
+
+func (s *Server) RevokeApiKey(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	keyID, err := strconv.ParseUint(ctx.Param("keyId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "API key not found",
+		})
+		return
+	}
+
+	err = s.ApiKeys.Revoke(ctx.Request.Context(), uint(id), uint(keyID))
+
+	if errors.Is(err, repository.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "API key not found",
+		})
+		return
+	}
+
+	if err != nil {
+		utils.Log.Error("Failed to revoke API key", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke API key",
+		})
+		return
+	}
+
+	utils.Log.Info("API key revoked", "api_key_id", ctx.Param("keyId"), "userID", id)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "API key revoked successfully",
+	})
+}
+
+func toApiKeyDTO(apiKey models.ApiKey) dto.ApiKeyDTO {
+	return dto.ApiKeyDTO{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.ScopeList(),
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
@@ -1,20 +1,21 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
-	"shortly-api-service/internal/database"
+	"shortly-api-service/config"
 	"shortly-api-service/internal/dto"
 	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
 	"shortly-api-service/internal/utils"
 	"shortly-api-service/internal/validators"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func Signup(ctx *gin.Context) {
+func (s *Server) Signup(ctx *gin.Context) {
 
 	var data validators.SignupValidator
 
@@ -41,16 +42,14 @@ func Signup(ctx *gin.Context) {
 		return
 	}
 
-	var existingUser models.User
-
-	if err := database.DB.Where("email = ?", data.Email).First(&existingUser).Error; err == nil {
+	if _, err := s.Users.FindByEmail(ctx.Request.Context(), data.Email); err == nil {
 		utils.Log.Warn("Signup attempt with existing email", "email", data.Email)
 		ctx.JSON(http.StatusConflict, gin.H{
 			"success": false,
 			"error":   "User already exists",
 		})
 		return
-	} else if err != gorm.ErrRecordNotFound {
+	} else if !errors.Is(err, repository.ErrNotFound) {
 		utils.Log.Error("Database error", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -76,7 +75,7 @@ func Signup(ctx *gin.Context) {
 		Password: hashPassword,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := s.Users.Create(ctx.Request.Context(), &user); err != nil {
 		utils.Log.Error("Failed to create user", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -100,7 +99,7 @@ func Signup(ctx *gin.Context) {
 
 }
 
-func Signin(ctx *gin.Context) {
+func (s *Server) Signin(ctx *gin.Context) {
 
 	var data validators.SigninValidator
 
@@ -126,9 +125,9 @@ func Signin(ctx *gin.Context) {
 		return
 	}
 
-	var user models.User
+	user, err := s.Users.FindByEmail(ctx.Request.Context(), data.Email)
 
-	if err := database.DB.Where("email = ?", data.Email).First(&user).Error; err != nil {
+	if err != nil {
 		utils.Log.Warn("Signin attempt with unregistered email", "email", data.Email)
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -148,10 +147,10 @@ func Signin(ctx *gin.Context) {
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	tokens, err := s.startSession(ctx, user)
 
 	if err != nil {
-		utils.Log.Error("Could not generate token")
+		utils.Log.Error("Could not generate token", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Could not generate token",
@@ -159,7 +158,7 @@ func Signin(ctx *gin.Context) {
 		return
 	}
 
-	ctx.SetCookie("token", token, 86400, "/", "", true, true)
+	setAuthCookies(ctx, tokens)
 
 	utils.Log.Info("User login attempt",
 		"email", data.Email,
@@ -168,8 +167,10 @@ func Signin(ctx *gin.Context) {
 	)
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"token":   token,
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(config.AppConfig.ACCESS_TOKEN_TTL.Seconds()),
 		"data": dto.UserDTO{
 			ID:        user.ID,
 			Email:     user.Email,
@@ -181,8 +182,39 @@ func Signin(ctx *gin.Context) {
 
 }
 
-func Logout(ctx *gin.Context) {
-	ctx.SetCookie("token", "", -1, "/", "", true, true)
+// Logout revokes the session behind the presented access or refresh token.
+// It always clears the cookies, even when the tokens are already invalid.
+func (s *Server) Logout(ctx *gin.Context) {
+
+	var sessions []models.Session
+
+	if claims, err := utils.VerifyToken(utils.ExtractToken(ctx)); err == nil {
+		if sessionID, ok := claims["sid"].(float64); ok {
+			if session, err := s.Sessions.FindActive(ctx.Request.Context(), uint(sessionID)); err == nil {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	if refreshToken, err := ctx.Cookie(refreshCookieName); err == nil && refreshToken != "" {
+		session, err := s.Sessions.FindByRefreshHash(ctx.Request.Context(), utils.HashToken(refreshToken))
+		if err == nil && session.RevokedAt == nil {
+			if len(sessions) == 0 || sessions[0].ID != session.ID {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	if err := s.revokeSessions(ctx, sessions); err != nil {
+		utils.Log.Error("Failed to revoke session on logout", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to logout",
+		})
+		return
+	}
+
+	clearAuthCookies(ctx)
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Logout successfully",
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+	"shortly-api-service/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Per-item outcomes of a bulk shorten request
+const (
+	bulkStatusCreated  = "created"
+	bulkStatusConflict = "conflict"
+	bulkStatusInvalid  = "invalid"
+	bulkStatusError    = "error"
+)
+
+// Different passwords one bulk request may set, each costs a bcrypt hash
+const maxBulkPasswords = 10
+
+func (s *Server) CreateBulkUrls(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	idStr := strconv.Itoa(id)
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	limit := config.AppConfig.BULK_SHORTEN_LIMIT
+
+	items, err := bindBulkUrls(ctx, limit)
+
+	if err != nil {
+		utils.Log.Error("Failed to bind bulk request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	if len(items) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "No URLs provided",
+		})
+		return
+	}
+
+	verifiedDomains, err := s.userVerifiedDomains(ctx.Request.Context(), idStr)
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch domains", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	results := make([]dto.BulkUrlResultDTO, len(items))
+	domainIDs := make([]uint, len(items))
+	pending := make([]int, 0, len(items))
+
+	seenUrls := make(map[string]bool)
+	seenKeys := make(map[string]bool)
+	passwords := make(map[string]bool)
+
+	for i, item := range items {
+
+		results[i] = dto.BulkUrlResultDTO{Index: i, OriginalURL: item.OriginalURL}
+
+		if validationErrors := validators.ValidateCreateUrlData(item); len(validationErrors) > 0 {
+			results[i].Status = bulkStatusInvalid
+			results[i].ValidationError = validationErrors
+			continue
+		}
+
+		if host := normalizeHost(item.Domain); host != "" {
+			domainID, ok := verifiedDomains[host]
+
+			if !ok {
+				results[i].Status = bulkStatusInvalid
+				results[i].Error = "Domain not found or not verified"
+				continue
+			}
+
+			domainIDs[i] = domainID
+		}
+
+		urlKey := scopedKey(domainIDs[i], item.OriginalURL)
+		shortKey := scopedKey(domainIDs[i], item.ShortKey)
+
+		if seenUrls[urlKey] {
+			results[i].Status = bulkStatusConflict
+			results[i].Error = "Duplicate URL in request"
+			continue
+		}
+
+		if item.ShortKey != "" && seenKeys[shortKey] {
+			results[i].Status = bulkStatusConflict
+			results[i].Error = "Duplicate short key in request"
+			continue
+		}
+
+		if item.Password != "" && !passwords[item.Password] && len(passwords) >= maxBulkPasswords {
+			results[i].Status = bulkStatusInvalid
+			results[i].Error = fmt.Sprintf("At most %d different passwords per request", maxBulkPasswords)
+			continue
+		}
+
+		if item.Password != "" {
+			passwords[item.Password] = true
+		}
+
+		seenUrls[urlKey] = true
+		if item.ShortKey != "" {
+			seenKeys[shortKey] = true
+		}
+
+		pending = append(pending, i)
+	}
+
+	pending, err = s.filterBulkConflicts(ctx.Request.Context(), scope, items, domainIDs, pending, results)
+
+	if err != nil {
+		utils.Log.Error("Failed to check existing URLs", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	missingKeys := 0
+
+	for _, i := range pending {
+		if items[i].ShortKey == "" {
+			missingKeys++
+		}
+	}
+
+	keys, err := s.Keys.Take(ctx.Request.Context(), missingKeys)
+
+	if err != nil {
+		utils.Log.Error("Failed to get keys from key source", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to generate short keys",
+		})
+		return
+	}
+
+	newUrls := make([]models.Url, 0, len(pending))
+	inserted := make([]int, 0, len(pending))
+
+	// Links sharing a password share its hash
+	hashes := make(map[string]string)
+
+	for _, i := range pending {
+
+		item := items[i]
+
+		if item.ShortKey == "" {
+			item.ShortKey, keys = keys[0], keys[1:]
+		}
+
+		var passwordHash *string
+
+		if item.Password != "" {
+			hash, ok := hashes[item.Password]
+
+			if !ok {
+				var err error
+
+				if hash, err = utils.HashPassword(item.Password); err != nil {
+					utils.Log.Error("Error hashing password", "error", err)
+					results[i].Status = bulkStatusError
+					results[i].Error = "Error hashing password"
+					continue
+				}
+
+				hashes[item.Password] = hash
+			}
+
+			passwordHash = &hash
+		}
+
+		newUrl := models.Url{
+			OriginalURL: item.OriginalURL,
+			ShortKey:    item.ShortKey,
+			DomainID:    domainIDs[i],
+			Title:       item.Title,
+			Password:    passwordHash,
+			ExpiresAt:   item.ExpiresAt,
+			MaxClicks:   item.MaxClicks,
+		}
+
+		scope.Assign(&newUrl)
+
+		newUrls = append(newUrls, newUrl)
+		inserted = append(inserted, i)
+	}
+
+	stored, err := s.Urls.CreateMany(ctx.Request.Context(), newUrls)
+
+	if err != nil {
+		utils.Log.Error("Failed to create bulk URLs", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create URLs",
+		})
+		return
+	}
+
+	// A key taken between the conflict check and the insert only fails its own item
+	createdUrls := make([]models.Url, 0, len(newUrls))
+
+	for n, i := range inserted {
+
+		if !stored[n] {
+			results[i].Status = bulkStatusConflict
+			results[i].Error = "This short key already exists."
+			continue
+		}
+
+		results[i].Status = bulkStatusCreated
+		results[i].ID = newUrls[n].ID
+		results[i].ShortKey = newUrls[n].ShortKey
+
+		createdUrls = append(createdUrls, newUrls[n])
+	}
+
+	s.Events.EmitUrlEvent(webhooks.EventUrlCreated, createdUrls...)
+
+	utils.Log.Info("Bulk URLs created", "created", len(createdUrls), "requested", len(items), "userID", idStr)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": dto.BulkUrlResponseDTO{
+			Created: len(createdUrls),
+			Failed:  len(items) - len(createdUrls),
+			Results: results,
+		},
+		"message": "Bulk shorten completed",
+	})
+}
+
+// filterBulkConflicts drops pending items whose URL was already shortened in
+// the scope on that domain or whose custom short key is taken, marking them
+// as conflicts.
+func (s *Server) filterBulkConflicts(ctx context.Context, scope authz.Scope, items []validators.CreateUrlValidator, domainIDs []uint, pending []int, results []dto.BulkUrlResultDTO) ([]int, error) {
+
+	if len(pending) == 0 {
+		return pending, nil
+	}
+
+	originalUrls := make([]string, 0, len(pending))
+	customKeys := make([]repository.DomainShortKey, 0)
+
+	for _, i := range pending {
+		originalUrls = append(originalUrls, items[i].OriginalURL)
+		if items[i].ShortKey != "" {
+			customKeys = append(customKeys, repository.DomainShortKey{DomainID: domainIDs[i], ShortKey: items[i].ShortKey})
+		}
+	}
+
+	existingUrls, err := s.Urls.ListShortened(ctx, scope, originalUrls)
+
+	if err != nil {
+		return nil, err
+	}
+
+	shortened := make(map[string]bool, len(existingUrls))
+
+	for _, url := range existingUrls {
+		shortened[scopedKey(url.DomainID, url.OriginalURL)] = true
+	}
+
+	existingKeys, err := s.Urls.TakenShortKeys(ctx, customKeys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]bool, len(existingKeys))
+
+	for _, key := range existingKeys {
+		taken[scopedKey(key.DomainID, key.ShortKey)] = true
+	}
+
+	remaining := pending[:0]
+
+	for _, i := range pending {
+		switch {
+		case shortened[scopedKey(domainIDs[i], items[i].OriginalURL)]:
+			results[i].Status = bulkStatusConflict
+			results[i].Error = "This URL has already been shortened."
+		case items[i].ShortKey != "" && taken[scopedKey(domainIDs[i], items[i].ShortKey)]:
+			results[i].Status = bulkStatusConflict
+			results[i].Error = "This short key already exists."
+		default:
+			remaining = append(remaining, i)
+		}
+	}
+
+	return remaining, nil
+}
+
+func scopedKey(domainID uint, value string) string {
+	return strconv.FormatUint(uint64(domainID), 10) + "|" + value
+}
+
+// bindBulkUrls reads the request body as a JSON array, a text/csv body or a
+// multipart CSV upload in the "file" field.
+func bindBulkUrls(ctx *gin.Context, limit int) ([]validators.CreateUrlValidator, error) {
+
+	switch ctx.ContentType() {
+
+	case "multipart/form-data":
+		file, err := ctx.FormFile("file")
+
+		if err != nil {
+			return nil, errors.New("missing CSV file in \"file\" field")
+		}
+
+		f, err := file.Open()
+
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return parseBulkCsv(f, limit)
+
+	case "text/csv":
+		return parseBulkCsv(ctx.Request.Body, limit)
+
+	default:
+		return parseBulkJson(ctx.Request.Body, limit)
+	}
+}
+
+// parseBulkJson decodes a JSON array one element at a time, so a body over
+// the limit is rejected without reading the rest of it.
+func parseBulkJson(r io.Reader, limit int) ([]validators.CreateUrlValidator, error) {
+
+	errMalformed := errors.New("expected a JSON array of URLs")
+
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errMalformed
+	}
+
+	items := make([]validators.CreateUrlValidator, 0)
+
+	for decoder.More() {
+
+		if len(items) == limit {
+			return nil, fmt.Errorf("a bulk request can contain at most %d URLs", limit)
+		}
+
+		var item validators.CreateUrlValidator
+
+		if err := decoder.Decode(&item); err != nil {
+			return nil, errMalformed
+		}
+
+		items = append(items, item)
+	}
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim(']') {
+		return nil, errMalformed
+	}
+
+	return items, nil
+}
+
+// parseBulkCsv reads a CSV with a header row. Only original_url is required;
+// short_key, title, password, expires_at (RFC 3339) and max_clicks are optional.
+func parseBulkCsv(r io.Reader, limit int) ([]validators.CreateUrlValidator, error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, errors.New("CSV file is empty or malformed")
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["original_url"]; !ok {
+		return nil, errors.New("CSV header must contain an original_url column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	items := make([]validators.CreateUrlValidator, 0)
+
+	for line := 2; ; line++ {
+
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: %w", line, err)
+		}
+
+		if len(items) == limit {
+			return nil, fmt.Errorf("a bulk request can contain at most %d URLs", limit)
+		}
+
+		item := validators.CreateUrlValidator{
+			OriginalURL: field(record, "original_url"),
+			ShortKey:    field(record, "short_key"),
+			Title:       field(record, "title"),
+			Password:    field(record, "password"),
+		}
+
+		if value := field(record, "expires_at"); value != "" {
+			expiresAt, err := time.Parse(time.RFC3339, value)
+
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: expires_at must be an RFC 3339 timestamp", line)
+			}
+
+			item.ExpiresAt = &expiresAt
+		}
+
+		if value := field(record, "max_clicks"); value != "" {
+			maxClicks, err := strconv.Atoi(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: max_clicks must be an integer", line)
+			}
+
+			item.MaxClicks = &maxClicks
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// racingUrls stores a link with a taken short key right before the bulk
+// insert, as a concurrent request passing the same conflict check would.
+type racingUrls struct {
+	*repository.MemoryUrlRepository
+	shortKey string
+}
+
+func (r racingUrls) CreateMany(ctx context.Context, urls []models.Url) ([]bool, error) {
+
+	racer := models.Url{OriginalURL: "https://example.com/racer", ShortKey: r.shortKey}
+
+	if err := r.MemoryUrlRepository.Create(ctx, &racer); err != nil {
+		return nil, err
+	}
+
+	return r.MemoryUrlRepository.CreateMany(ctx, urls)
+}
+
+func (ts *testServer) bulk(t *testing.T, userID int, body string) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/url/shorten/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(testUserHeader, strconv.Itoa(userID))
+
+	rec := httptest.NewRecorder()
+	ts.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decodeBulk(t *testing.T, rec *httptest.ResponseRecorder) dto.BulkUrlResponseDTO {
+
+	t.Helper()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Data dto.BulkUrlResponseDTO `json:"data"`
+	}
+
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode bulk response: %v", err)
+	}
+
+	return response.Data
+}
+
+func TestCreateBulkUrlsTakenShortKey(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	ts.createUrl(t, 2, gin.H{"original_url": "https://example.com/other", "short_key": "taken"})
+
+	result := decodeBulk(t, ts.bulk(t, 1, `[
+		{"original_url": "https://example.com/a"},
+		{"original_url": "https://example.com/b", "short_key": "taken"},
+		{"original_url": "https://example.com/c", "short_key": "free"}
+	]`))
+
+	if result.Created != 2 || result.Failed != 1 {
+		t.Fatalf("created %d, failed %d, want 2 and 1", result.Created, result.Failed)
+	}
+
+	if status := result.Results[1].Status; status != bulkStatusConflict {
+		t.Fatalf("taken short key: status %q, want %q", status, bulkStatusConflict)
+	}
+
+	for _, i := range []int{0, 2} {
+		if status := result.Results[i].Status; status != bulkStatusCreated {
+			t.Fatalf("item %d: status %q, want %q", i, status, bulkStatusCreated)
+		}
+	}
+}
+
+func TestCreateBulkUrlsInsertConflict(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	ts.Urls = racingUrls{MemoryUrlRepository: ts.urls, shortKey: "raced"}
+
+	result := decodeBulk(t, ts.bulk(t, 1, `[
+		{"original_url": "https://example.com/a", "short_key": "raced"},
+		{"original_url": "https://example.com/b", "short_key": "mine"}
+	]`))
+
+	if result.Created != 1 || result.Failed != 1 {
+		t.Fatalf("created %d, failed %d, want 1 and 1", result.Created, result.Failed)
+	}
+
+	if status := result.Results[0].Status; status != bulkStatusConflict {
+		t.Fatalf("raced short key: status %q, want %q", status, bulkStatusConflict)
+	}
+
+	if status := result.Results[1].Status; status != bulkStatusCreated {
+		t.Fatalf("other item: status %q, want %q", status, bulkStatusCreated)
+	}
+
+	// Only the stored link is announced
+	if len(ts.events.events) != 1 {
+		t.Fatalf("emitted %d events, want 1", len(ts.events.events))
+	}
+}
+
+func TestCreateBulkUrlsLimit(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	config.AppConfig.BULK_SHORTEN_LIMIT = 2
+
+	var body bytes.Buffer
+
+	body.WriteString("[")
+
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		body.WriteString(`{"original_url": "https://example.com/` + strconv.Itoa(i) + `"}`)
+	}
+
+	// The body never closes, the limit is reached before the decoder needs it to
+	body.WriteString(",")
+
+	if rec := ts.bulk(t, 1, body.String()); rec.Code != http.StatusBadRequest {
+		t.Fatalf("over the limit: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := ts.bulk(t, 1, `{"original_url": "https://example.com/a"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("not an array: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	result := decodeBulk(t, ts.bulk(t, 1, `[{"original_url": "https://example.com/a"}, {"original_url": "https://example.com/b"}]`))
+
+	if result.Created != 2 {
+		t.Fatalf("at the limit: created %d, want 2", result.Created)
+	}
+}
+
+func TestCreateBulkUrlsPasswords(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	items := make([]gin.H, 0, maxBulkPasswords+2)
+
+	// One password more than allowed, then the first one again
+	for i := 0; i <= maxBulkPasswords; i++ {
+		items = append(items, gin.H{"original_url": "https://example.com/" + strconv.Itoa(i), "password": "secret-" + strconv.Itoa(i)})
+	}
+
+	items = append(items, gin.H{"original_url": "https://example.com/again", "password": "secret-0"})
+
+	body, _ := json.Marshal(items)
+
+	result := decodeBulk(t, ts.bulk(t, 1, string(body)))
+
+	if result.Created != maxBulkPasswords+1 || result.Failed != 1 {
+		t.Fatalf("created %d and failed %d, want %d and 1", result.Created, result.Failed, maxBulkPasswords+1)
+	}
+
+	if over := result.Results[maxBulkPasswords]; over.Status != bulkStatusInvalid {
+		t.Fatalf("item over the password limit = %+v, want invalid", over)
+	}
+
+	first, err := ts.urls.FindByShortKey(context.Background(), 0, result.Results[0].ShortKey)
+
+	if err != nil {
+		t.Fatalf("first link: %v", err)
+	}
+
+	again, err := ts.urls.FindByShortKey(context.Background(), 0, result.Results[maxBulkPasswords+1].ShortKey)
+
+	if err != nil {
+		t.Fatalf("repeated password link: %v", err)
+	}
+
+	// The repeated password is hashed once
+	if first.Password == nil || again.Password == nil || *first.Password != *again.Password {
+		t.Fatalf("hashes %v and %v, want one shared hash", first.Password, again.Password)
+	}
+
+	if !utils.VerifyPassword("secret-0", *again.Password) {
+		t.Fatal("shared hash doesn't verify the password")
+	}
+}
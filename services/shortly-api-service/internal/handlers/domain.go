@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	domainChallengePrefix = "_shortly-challenge."
+	domainChallengeValue  = "shortly-verification="
+)
+
+var errDomainNotVerified = errors.New("domain not found or not verified")
+
+func (s *Server) CreateDomain(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	idStr := strconv.Itoa(id)
+
+	var data validators.CreateDomainValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Host = normalizeHost(data.Host)
+
+	validationErrors := validators.ValidateCreateDomainData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	// Unverified claims of other users don't block the host, whoever proves
+	// control over its DNS first gets it
+	if _, err := s.Domains.FindVerified(ctx.Request.Context(), data.Host); err == nil {
+		utils.Log.Warn("Domain already registered", "host", data.Host)
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "This domain is already registered",
+		})
+		return
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if _, err := s.Domains.FindClaim(ctx.Request.Context(), idStr, data.Host); err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "You have already registered this domain",
+		})
+		return
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(16)
+
+	if err != nil {
+		utils.Log.Error("Could not generate verification token", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	domain := models.Domain{
+		Host:              data.Host,
+		UserID:            idStr,
+		VerificationToken: token,
+	}
+
+	if err := s.Domains.Create(ctx.Request.Context(), &domain); err != nil {
+		utils.Log.Error("Failed to create domain", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create domain",
+		})
+		return
+	}
+
+	utils.Log.Info("Domain registered", "host", domain.Host, "userID", idStr)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    toDomainDTO(domain),
+		"message": "Domain registered, add the TXT record and verify it",
+	})
+}
+
+func (s *Server) GetDomains(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	domains, err := s.Domains.ListForUser(ctx.Request.Context(), strconv.Itoa(id))
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch domains", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to retrieve domains",
+		})
+		return
+	}
+
+	response := make([]dto.DomainResponseDTO, 0, len(domains))
+
+	for _, domain := range domains {
+		response = append(response, toDomainDTO(domain))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Domains retrieved successfully",
+	})
+}
+
+func (s *Server) VerifyDomain(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	domain, ok := s.findUserDomain(ctx, strconv.Itoa(id))
+
+	if !ok {
+		return
+	}
+
+	if domain.VerifiedAt == nil {
+
+		lookupCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		records, err := lib.DomainResolver.LookupTXT(lookupCtx, domainChallengePrefix+domain.Host)
+
+		if err != nil || !hasChallenge(records, domain.VerificationToken) {
+			utils.Log.Warn("Domain verification failed", "host", domain.Host, "error", err)
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error":   "Verification TXT record not found",
+			})
+			return
+		}
+
+		err = s.Domains.MarkVerified(ctx.Request.Context(), &domain, time.Now())
+
+		if errors.Is(err, repository.ErrDuplicate) {
+			utils.Log.Warn("Domain already verified by another account", "host", domain.Host, "domain_id", domain.ID)
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "This domain is already registered",
+			})
+			return
+		}
+
+		if err != nil {
+			utils.Log.Error("Failed to verify domain", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to verify domain",
+			})
+			return
+		}
+
+		s.invalidateDomainCache(ctx.Request.Context(), domain.Host)
+
+		utils.Log.Info("Domain verified", "host", domain.Host)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    toDomainDTO(domain),
+		"message": "Domain verified successfully",
+	})
+}
+
+func (s *Server) DeleteDomain(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	domain, ok := s.findUserDomain(ctx, strconv.Itoa(id))
+
+	if !ok {
+		return
+	}
+
+	urlCount, err := s.Urls.CountOnDomain(ctx.Request.Context(), domain.ID)
+
+	if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if urlCount > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Delete the URLs on this domain first",
+		})
+		return
+	}
+
+	// Hard delete so the host can be registered again
+	if err := s.Domains.Delete(ctx.Request.Context(), &domain); err != nil {
+		utils.Log.Error("Failed to delete domain", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete domain",
+		})
+		return
+	}
+
+	s.invalidateDomainCache(ctx.Request.Context(), domain.Host)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Domain deleted successfully",
+	})
+}
+
+// findUserDomain loads the :domainId domain of the user, answering 404 when
+// there is none.
+func (s *Server) findUserDomain(ctx *gin.Context, userID string) (models.Domain, bool) {
+
+	domainID, err := strconv.ParseUint(ctx.Param("domainId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Domain not found",
+		})
+		return models.Domain{}, false
+	}
+
+	domain, err := s.Domains.FindForUser(ctx.Request.Context(), userID, uint(domainID))
+
+	if errors.Is(err, repository.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Domain not found",
+		})
+		return domain, false
+	} else if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return domain, false
+	}
+
+	return domain, true
+}
+
+func toDomainDTO(domain models.Domain) dto.DomainResponseDTO {
+	return dto.DomainResponseDTO{
+		ID:         domain.ID,
+		Host:       domain.Host,
+		Verified:   domain.VerifiedAt != nil,
+		VerifiedAt: domain.VerifiedAt,
+		TXTRecord:  domainChallengePrefix + domain.Host,
+		TXTValue:   domainChallengeValue + domain.VerificationToken,
+		CreatedAt:  domain.CreatedAt,
+	}
+}
+
+func hasChallenge(records []string, token string) bool {
+	for _, record := range records {
+		if strings.TrimSpace(record) == domainChallengeValue+token {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.TrimSpace(strings.ToLower(host)), ".")
+}
+
+// requestHost returns the lowercased Host header without its port.
+func requestHost(ctx *gin.Context) string {
+
+	host := ctx.Request.Host
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
+
+// How long host lookups are cached. Verified hosts are dropped from the
+// cache when verified or deleted, other hosts only expire: a miss is kept
+// briefly so a host verified on another instance, or whose invalidation
+// failed, isn't served as the shared domain for long.
+const (
+	domainHitTTL  = time.Hour
+	domainMissTTL = 30 * time.Second
+)
+
+// hostDomainID maps a request host to the verified domain serving it.
+// Hosts that are not custom domains resolve to 0, the shared domain.
+func (s *Server) hostDomainID(ctx context.Context, host string) (uint, error) {
+
+	if domainID, ok := s.Cache.GetDomainID(ctx, host); ok {
+		return domainID, nil
+	}
+
+	var domainID uint
+	ttl := domainMissTTL
+
+	domain, err := s.Domains.FindVerified(ctx, host)
+
+	if err == nil {
+		domainID = domain.ID
+		ttl = domainHitTTL
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return 0, err
+	}
+
+	if err := s.Cache.SetDomainID(ctx, host, domainID, ttl); err != nil {
+		utils.Log.Error("Failed to cache domain", "error", err)
+	}
+
+	return domainID, nil
+}
+
+// userDomainID resolves a host owned and verified by the user. An empty host
+// is the shared domain.
+func (s *Server) userDomainID(ctx context.Context, userID string, host string) (uint, error) {
+
+	host = normalizeHost(host)
+
+	if host == "" {
+		return 0, nil
+	}
+
+	domain, err := s.Domains.FindVerifiedForUser(ctx, userID, host)
+
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, errDomainNotVerified
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return domain.ID, nil
+}
+
+// userVerifiedDomains maps the hosts of the user's verified domains to their IDs.
+func (s *Server) userVerifiedDomains(ctx context.Context, userID string) (map[string]uint, error) {
+
+	domains, err := s.Domains.ListVerifiedForUser(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]uint, len(domains))
+
+	for _, domain := range domains {
+		ids[domain.Host] = domain.ID
+	}
+
+	return ids, nil
+}
+
+// urlDomainHosts maps the domain IDs used by urls to their hosts. Workspace
+// links may sit on domains of other members, so this goes by ID, not owner.
+func (s *Server) urlDomainHosts(ctx context.Context, urls []models.Url) (map[uint]string, error) {
+
+	ids := make([]uint, 0)
+
+	for _, url := range urls {
+		if url.DomainID != 0 {
+			ids = append(ids, url.DomainID)
+		}
+	}
+
+	return s.Domains.Hosts(ctx, ids)
+}
+
+// invalidateDomainCache drops the cached host lookup before the response,
+// like invalidateUrlCache.
+func (s *Server) invalidateDomainCache(ctx context.Context, host string) {
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheTimeout)
+	defer cancel()
+
+	if err := s.Cache.DeleteDomainID(ctx, host); err != nil {
+		utils.Log.Error("Failed to delete domain from cache", "error", err)
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// txtRecords answers TXT lookups from memory instead of DNS.
+type txtRecords struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func (r *txtRecords) LookupTXT(ctx context.Context, name string) ([]string, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.records[name], nil
+}
+
+func (r *txtRecords) publish(domain dto.DomainResponseDTO) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[domain.TXTRecord] = append(r.records[domain.TXTRecord], domain.TXTValue)
+}
+
+func useTXTRecords(t *testing.T) *txtRecords {
+
+	t.Helper()
+
+	records := &txtRecords{records: make(map[string][]string)}
+
+	previous := lib.DomainResolver
+	lib.DomainResolver = records
+	t.Cleanup(func() { lib.DomainResolver = previous })
+
+	return records
+}
+
+func (ts *testServer) createDomain(t *testing.T, userID int, host string) (dto.DomainResponseDTO, int) {
+
+	t.Helper()
+
+	rec := ts.do(t, http.MethodPost, "/domains/", userID, gin.H{"host": host})
+
+	var response struct {
+		Data dto.DomainResponseDTO `json:"data"`
+	}
+
+	if rec.Code == http.StatusCreated {
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode domain: %v", err)
+		}
+	}
+
+	return response.Data, rec.Code
+}
+
+func (ts *testServer) verifyDomain(t *testing.T, userID int, domain dto.DomainResponseDTO) int {
+
+	t.Helper()
+
+	return ts.do(t, http.MethodPost, "/domains/"+strconv.FormatUint(uint64(domain.ID), 10)+"/verify", userID, nil).Code
+}
+
+// An unverified claim must not lock the real owner out of their host.
+func TestDomainClaimCannotBeSquatted(t *testing.T) {
+
+	ts := newTestServer(t)
+	dns := useTXTRecords(t)
+
+	squatter, code := ts.createDomain(t, 2, "links.example.com")
+
+	if code != http.StatusCreated {
+		t.Fatalf("squatter claim: status %d", code)
+	}
+
+	owner, code := ts.createDomain(t, 1, "links.example.com")
+
+	if code != http.StatusCreated {
+		t.Fatalf("owner claim next to an unverified one: status %d, want %d", code, http.StatusCreated)
+	}
+
+	if _, code := ts.createDomain(t, 1, "links.example.com"); code != http.StatusConflict {
+		t.Fatalf("second claim of the same user: status %d, want %d", code, http.StatusConflict)
+	}
+
+	// Only the owner controls the DNS zone
+	dns.publish(owner)
+
+	if code := ts.verifyDomain(t, 2, squatter); code != http.StatusUnprocessableEntity {
+		t.Fatalf("squatter verify without the record: status %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+
+	if code := ts.verifyDomain(t, 1, owner); code != http.StatusOK {
+		t.Fatalf("owner verify: status %d, want %d", code, http.StatusOK)
+	}
+
+	// Verifying took the host over, the squatter's claim is gone
+	if code := ts.verifyDomain(t, 2, squatter); code != http.StatusNotFound {
+		t.Fatalf("squatter verify after takeover: status %d, want %d", code, http.StatusNotFound)
+	}
+
+	if _, code := ts.createDomain(t, 3, "links.example.com"); code != http.StatusConflict {
+		t.Fatalf("claim of a verified host: status %d, want %d", code, http.StatusConflict)
+	}
+}
+
+// A claim whose TXT check passes after another account verified the host
+// loses, as when two verifications race.
+func TestDomainVerifyConflict(t *testing.T) {
+
+	ts := newTestServer(t)
+	dns := useTXTRecords(t)
+
+	claim, _ := ts.createDomain(t, 2, "go.example.org")
+	dns.publish(claim)
+
+	verifiedAt := time.Now()
+
+	// The winner of the race, stored next to the claim
+	ts.Domains.(*repository.MemoryDomainRepository).Add(models.Domain{
+		Model:             gorm.Model{ID: claim.ID + 1},
+		Host:              "go.example.org",
+		UserID:            "1",
+		VerificationToken: "winner",
+		VerifiedAt:        &verifiedAt,
+	})
+
+	if code := ts.verifyDomain(t, 2, claim); code != http.StatusConflict {
+		t.Fatalf("verify after another account: status %d, want %d", code, http.StatusConflict)
+	}
+
+	verified, err := ts.Domains.FindVerified(context.Background(), "go.example.org")
+
+	if err != nil || verified.UserID != "1" {
+		t.Fatalf("verified domain = %+v, %v, want the one of user 1", verified, err)
+	}
+}
+
+// domainTTLs records the TTL each host lookup is cached for.
+type domainTTLs struct {
+	*repository.MemoryLinkCache
+	mu   sync.Mutex
+	ttls map[string]time.Duration
+}
+
+func (c *domainTTLs) SetDomainID(ctx context.Context, host string, domainID uint, ttl time.Duration) error {
+
+	c.mu.Lock()
+	c.ttls[host] = ttl
+	c.mu.Unlock()
+
+	return c.MemoryLinkCache.SetDomainID(ctx, host, domainID, ttl)
+}
+
+// A host looked up before its verification is only cached briefly, and the
+// verification drops it at once.
+func TestHostLookupMissIsNotCachedForLong(t *testing.T) {
+
+	ts := newTestServer(t)
+	dns := useTXTRecords(t)
+
+	cache := &domainTTLs{MemoryLinkCache: repository.NewMemoryLinkCache(), ttls: make(map[string]time.Duration)}
+	ts.Cache = cache
+
+	ctx := context.Background()
+
+	claim, _ := ts.createDomain(t, 1, "links.example.com")
+
+	if id, err := ts.hostDomainID(ctx, "links.example.com"); err != nil || id != 0 {
+		t.Fatalf("unverified host resolved to %d, %v, want the shared domain", id, err)
+	}
+
+	if ttl := cache.ttls["links.example.com"]; ttl != domainMissTTL {
+		t.Fatalf("miss cached for %s, want %s", ttl, domainMissTTL)
+	}
+
+	dns.publish(claim)
+
+	if code := ts.verifyDomain(t, 1, claim); code != http.StatusOK {
+		t.Fatalf("verify: status %d", code)
+	}
+
+	if id, err := ts.hostDomainID(ctx, "links.example.com"); err != nil || id != claim.ID {
+		t.Fatalf("verified host resolved to %d, %v, want %d", id, err, claim.ID)
+	}
+
+	if ttl := cache.ttls["links.example.com"]; ttl != domainHitTTL {
+		t.Fatalf("verified host cached for %s, want %s", ttl, domainHitTTL)
+	}
+}
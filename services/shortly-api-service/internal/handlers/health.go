@@ -1,14 +1,151 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/clicks"
+	"shortly-api-service/internal/clients"
+	"shortly-api-service/internal/database"
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/keys"
+	"shortly-api-service/internal/redis"
+	"shortly-api-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func HealthCheck(ctx *gin.Context) {
+// Dependency states reported by ReadinessCheck
+const (
+	dependencyUp   = "up"
+	dependencyDown = "down"
+)
+
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown fails every later readiness check, so load balancers stop
+// routing to the instance while it drains.
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// readinessChecks are run in parallel, each with HEALTH_CHECK_TIMEOUT.
+var readinessChecks = map[string]func(ctx context.Context) error{
+	"postgres": func(ctx context.Context) error {
+
+		sqlDB, err := database.DB.DB()
+
+		if err != nil {
+			return err
+		}
+
+		return sqlDB.PingContext(ctx)
+	},
+	"redis_cache": func(ctx context.Context) error {
+		return redis.RedisClient.Ping(ctx).Err()
+	},
+	"redis_rate_limit": func(ctx context.Context) error {
+		return redis.RateLimitClient.Ping(ctx).Err()
+	},
+	"kgs": clients.CheckKGS,
+}
+
+// requiredForReadiness tells whether a failing check takes the instance out
+// of rotation. KGS is only required while links can't be created without it.
+func requiredForReadiness(name string) bool {
+
+	if name != "kgs" {
+		return true
+	}
+
+	return config.AppConfig.KEY_SOURCE == keys.SourceKGS && !config.AppConfig.KEY_SOURCE_FALLBACK
+}
+
+// LivenessCheck only tells that the process is able to answer, it never
+// touches a dependency so an outage doesn't get the service restarted.
+func LivenessCheck(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Server is up and running",
 	})
 }
+
+// ReadinessCheck pings every dependency and answers 503 when a required one
+// is down, so the instance is taken out of rotation until it recovers.
+func ReadinessCheck(ctx *gin.Context) {
+
+	if shuttingDown.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "Server is shutting down",
+		})
+		return
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		ready  = true
+		checks = make(map[string]dto.DependencyStatusDTO, len(readinessChecks))
+	)
+
+	for name, check := range readinessChecks {
+		wg.Add(1)
+
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), config.AppConfig.HEALTH_CHECK_TIMEOUT)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+
+			result := dto.DependencyStatusDTO{
+				Status:    dependencyUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			if err != nil {
+				result.Status = dependencyDown
+				result.Error = err.Error()
+				utils.Log.Warn("Readiness check failed", "dependency", name, "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			checks[name] = result
+			ready = ready && (err == nil || !requiredForReadiness(name))
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"data":    checks,
+			"error":   "One or more dependencies are unavailable",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    checks,
+		"message": "Server is ready",
+	})
+}
+
+func ClickPipelineStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    clicks.Queue.Stats(),
+		"message": "Click pipeline stats retrieved successfully",
+	})
+}
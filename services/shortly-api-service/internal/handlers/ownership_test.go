@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Links of other users must look like they don't exist: every scoped route
+// answers 404 and leaves the record alone.
+func TestOtherUsersUrlIsNotFound(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/private", "title": "Owner title"})
+
+	urlID := strconv.FormatUint(uint64(url.ID), 10)
+
+	ts.analytics.Record(models.Analytics{
+		UrlID:     urlID,
+		ClickedAt: time.Now(),
+		IPAddress: "203.0.113.7",
+		Country:   "DE",
+	})
+
+	requests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"details", http.MethodGet, "/url/" + url.ShortKey, nil},
+		{"qr", http.MethodGet, "/url/" + url.ShortKey + "/qr", nil},
+		{"update", http.MethodPatch, "/url/" + url.ShortKey, gin.H{"short_url": "stolen", "title": "Hijacked", "max_clicks": 1}},
+		{"delete", http.MethodDelete, "/url/" + url.ShortKey, nil},
+		{"analytics", http.MethodGet, "/analytics/" + urlID, nil},
+		{"timeseries", http.MethodGet, "/analytics/" + urlID + "/timeseries", nil},
+		{"breakdown", http.MethodGet, "/analytics/" + urlID + "/breakdown/country", nil},
+	}
+
+	for _, request := range requests {
+		t.Run(request.name, func(t *testing.T) {
+
+			rec := ts.do(t, request.method, request.path, 2, request.body)
+
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("status %d, want %d, body %s", rec.Code, http.StatusNotFound, rec.Body.String())
+			}
+
+			stored, err := ts.urls.FindByShortKey(context.Background(), 0, url.ShortKey)
+
+			if err != nil {
+				t.Fatalf("url no longer stored: %v", err)
+			}
+
+			if !reflect.DeepEqual(stored, url) {
+				t.Fatalf("url changed:\n got %+v\nwant %+v", stored, url)
+			}
+		})
+	}
+
+	if _, err := ts.urls.FindByShortKey(context.Background(), 0, "stolen"); err == nil {
+		t.Fatal("update by another user renamed the link")
+	}
+
+	// The owner still reaches everything
+	for _, request := range requests {
+		if request.method != http.MethodGet {
+			continue
+		}
+		if rec := ts.do(t, request.method, request.path, 1, nil); rec.Code != http.StatusOK {
+			t.Fatalf("owner %s: status %d, body %s", request.name, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -6,16 +6,15 @@ import (
 	"strings"
 	"time"
 
-	"shortly-api-service/internal/database"
 	"shortly-api-service/internal/dto"
-	"shortly-api-service/internal/models"
-	"shortly-api-service/internal/redis"
 	"shortly-api-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetUserProfile(ctx *gin.Context) {
+const profileCacheTTL = 30 * time.Minute
+
+func (s *Server) GetUserProfile(ctx *gin.Context) {
 
 	emailInterface, exists := ctx.Get("email")
 
@@ -39,13 +38,9 @@ func GetUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "user:profile:" + email
-
-	cached, err := redis.RedisClient.Get(ctx.Request.Context(), cacheKey).Result()
-
-	if err == nil && cached != "" {
+	if cached, ok := s.Profiles.GetProfile(ctx.Request.Context(), email); ok {
 		var cachedDTO dto.UserDTO
-		if err := json.Unmarshal([]byte(cached), &cachedDTO); err == nil {
+		if err := json.Unmarshal(cached, &cachedDTO); err == nil {
 			utils.Log.Info("User profile served from Redis")
 			ctx.JSON(http.StatusOK, gin.H{
 				"success": true,
@@ -56,9 +51,9 @@ func GetUserProfile(ctx *gin.Context) {
 		}
 	}
 
-	var user models.User
+	user, err := s.Users.FindByEmail(ctx.Request.Context(), email)
 
-	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err != nil {
 		utils.Log.Error("No user found", "error", err)
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -85,11 +80,7 @@ func GetUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	if err := redis.RedisClient.Set(
-		ctx.Request.Context(),
-		cacheKey,
-		jsonBytes,
-		30*time.Minute).Err(); err != nil {
+	if err := s.Profiles.SetProfile(ctx.Request.Context(), email, jsonBytes, profileCacheTTL); err != nil {
 		utils.Log.Error("Failed to cache user profile", "error", err)
 	}
 
@@ -101,7 +92,7 @@ func GetUserProfile(ctx *gin.Context) {
 
 }
 
-func UpdateUserProfile(ctx *gin.Context) {
+func (s *Server) UpdateUserProfile(ctx *gin.Context) {
 
 	emailInterface, exists := ctx.Get("email")
 
@@ -136,9 +127,9 @@ func UpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	var user models.User
+	user, err := s.Users.FindByEmail(ctx.Request.Context(), email)
 
-	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err != nil {
 		utils.Log.Error("User not found during update", "error", err)
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -157,7 +148,7 @@ func UpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Save(&user).Error; err != nil {
+	if err := s.Users.Save(ctx.Request.Context(), &user); err != nil {
 		utils.Log.Error("Failed to update user profile", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -166,8 +157,6 @@ func UpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "user:profile:" + email
-
 	userDTO := dto.UserDTO{
 		ID:        user.ID,
 		Email:     user.Email,
@@ -186,11 +175,7 @@ func UpdateUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	if err := redis.RedisClient.Set(
-		ctx.Request.Context(),
-		cacheKey,
-		jsonBytes,
-		30*time.Minute).Err(); err != nil {
+	if err := s.Profiles.SetProfile(ctx.Request.Context(), email, jsonBytes, profileCacheTTL); err != nil {
 		utils.Log.Error("Failed to update Redis cache", "error", err)
 	}
 
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	qrCacheTTL      = 24 * time.Hour
+	qrDefaultSize   = 256
+	qrMinSize       = 64
+	qrMaxSize       = 2048
+	qrDefaultLevel  = "M"
+	qrDefaultMargin = 4
+	qrMaxMargin     = 16
+)
+
+func (s *Server) GetUrlQRCode(ctx *gin.Context) {
+
+	shortKey := ctx.Param("shortKey")
+
+	if shortKey == "" {
+		utils.Log.Error("Short key is missing from path")
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Short key is required",
+		})
+		return
+	}
+
+	opts, ok := qrOptions(ctx)
+
+	if !ok {
+		return
+	}
+
+	domainID, ok := s.queryDomainID(ctx)
+
+	if !ok {
+		return
+	}
+
+	shortUrl, ok := s.findScopedUrl(ctx, repository.UrlFilter{DomainID: domainID, ShortKey: shortKey})
+
+	if !ok {
+		return
+	}
+
+	variant := fmt.Sprintf("%s:%d:%s:%d", opts.Format, opts.Size, opts.Level, opts.Margin)
+
+	contentType := "image/png"
+
+	if opts.Format == lib.QRFormatSVG {
+		contentType = "image/svg+xml"
+	}
+
+	if cached, ok := s.Cache.GetQRCode(ctx.Request.Context(), domainID, shortKey, variant); ok {
+		utils.Log.Info("QR code served from cache", "shortKey", shortKey)
+		ctx.Data(http.StatusOK, contentType, cached)
+		return
+	}
+
+	link, err := s.shortLink(ctx.Request.Context(), shortUrl)
+
+	if err != nil {
+		utils.Log.Error("Failed to build short link", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	image, contentType, err := lib.RenderQR(link, opts)
+
+	if err != nil {
+		utils.Log.Error("Failed to render QR code", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to generate QR code",
+		})
+		return
+	}
+
+	s.Tasks.Go("cache qr code", func(ctx context.Context) error {
+		return s.Cache.SetQRCode(ctx, domainID, shortKey, variant, image, qrCacheTTL)
+	})
+
+	ctx.Data(http.StatusOK, contentType, image)
+}
+
+// qrOptions reads format, size, level and margin from the query string,
+// answering the request itself when one is invalid.
+func qrOptions(ctx *gin.Context) (lib.QROptions, bool) {
+
+	opts := lib.QROptions{
+		Format: strings.ToLower(ctx.DefaultQuery("format", lib.QRFormatPNG)),
+		Level:  strings.ToUpper(ctx.DefaultQuery("level", qrDefaultLevel)),
+		Size:   qrDefaultSize,
+		Margin: qrDefaultMargin,
+	}
+
+	if opts.Format != lib.QRFormatPNG && opts.Format != lib.QRFormatSVG {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "format must be either png or svg",
+		})
+		return opts, false
+	}
+
+	if !lib.IsValidQRLevel(opts.Level) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "level must be one of L, M, Q or H",
+		})
+		return opts, false
+	}
+
+	if value := ctx.Query("size"); value != "" {
+		size, err := strconv.Atoi(value)
+
+		if err != nil || size < qrMinSize || size > qrMaxSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("size must be between %d and %d pixels", qrMinSize, qrMaxSize),
+			})
+			return opts, false
+		}
+
+		opts.Size = size
+	}
+
+	if value := ctx.Query("margin"); value != "" {
+		margin, err := strconv.Atoi(value)
+
+		if err != nil || margin < 0 || margin > qrMaxMargin {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("margin must be between 0 and %d modules", qrMaxMargin),
+			})
+			return opts, false
+		}
+
+		opts.Margin = margin
+	}
+
+	return opts, true
+}
+
+// shortLink builds the public URL of a short link, on its custom domain
+// when it has one.
+func (s *Server) shortLink(ctx context.Context, shortUrl models.Url) (string, error) {
+
+	base, err := url.Parse(config.AppConfig.SHORT_URL_BASE)
+
+	if err != nil {
+		return "", err
+	}
+
+	if shortUrl.DomainID != 0 {
+		hosts, err := s.urlDomainHosts(ctx, []models.Url{shortUrl})
+
+		if err != nil {
+			return "", err
+		}
+
+		if host, ok := hosts[shortUrl.DomainID]; ok {
+			base.Host = host
+		}
+	}
+
+	return strings.TrimRight(base.String(), "/") + "/" + url.PathEscape(shortUrl.ShortKey), nil
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRedirectRulesPerUrl = 20
+
+func (s *Server) GetRedirectRules(ctx *gin.Context) {
+
+	url, ok := s.ruleUrl(ctx)
+
+	if !ok {
+		return
+	}
+
+	rules, err := s.Rules.List(ctx.Request.Context(), url.ID)
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch redirect rules", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch redirect rules",
+		})
+		return
+	}
+
+	response := make([]dto.RedirectRuleDTO, 0, len(rules))
+
+	for _, rule := range rules {
+		response = append(response, toRedirectRuleDTO(rule))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Redirect rules retrieved successfully",
+	})
+}
+
+func (s *Server) CreateRedirectRule(ctx *gin.Context) {
+
+	url, ok := s.ruleUrl(ctx)
+
+	if !ok {
+		return
+	}
+
+	var data validators.CreateRedirectRuleValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Destination = strings.TrimSpace(data.Destination)
+
+	validationErrors := validators.ValidateCreateRedirectRuleData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	existing, err := s.Rules.List(ctx.Request.Context(), url.ID)
+
+	if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if len(existing) >= maxRedirectRulesPerUrl {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Redirect rule limit reached, delete an unused rule first",
+		})
+		return
+	}
+
+	rule := models.RedirectRule{
+		UrlID:       url.ID,
+		Destination: data.Destination,
+		Devices:     joinRuleList(data.Devices, strings.ToLower),
+		OS:          joinRuleList(data.OS, strings.ToLower),
+		Countries:   joinRuleList(data.Countries, strings.TrimSpace),
+		Languages:   joinRuleList(data.Languages, strings.ToLower),
+		StartsAt:    data.StartsAt,
+		EndsAt:      data.EndsAt,
+	}
+
+	// New rules go last unless placed explicitly
+	if data.Position != nil {
+		rule.Position = *data.Position
+	} else if len(existing) > 0 {
+		rule.Position = existing[len(existing)-1].Position + 1
+	}
+
+	if err := checkRedirectRule(rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := s.Rules.Create(ctx.Request.Context(), &rule); err != nil {
+		utils.Log.Error("Failed to create redirect rule", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create redirect rule",
+		})
+		return
+	}
+
+	s.invalidateUrlCache(ctx.Request.Context(), url.DomainID, url.ShortKey)
+
+	utils.Log.Info("Redirect rule created", "rule_id", rule.ID, "urlID", url.ID, "position", rule.Position)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    toRedirectRuleDTO(rule),
+		"message": "Redirect rule created successfully",
+	})
+}
+
+func (s *Server) UpdateRedirectRule(ctx *gin.Context) {
+
+	url, rule, ok := s.findRedirectRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	var data validators.UpdateRedirectRuleValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Destination = strings.TrimSpace(data.Destination)
+
+	validationErrors := validators.ValidateUpdateRedirectRuleData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	// An empty list clears the condition, a missing one keeps it
+	if data.Destination != "" {
+		rule.Destination = data.Destination
+	}
+	if data.Position != nil {
+		rule.Position = *data.Position
+	}
+	if data.Devices != nil {
+		rule.Devices = joinRuleList(data.Devices, strings.ToLower)
+	}
+	if data.OS != nil {
+		rule.OS = joinRuleList(data.OS, strings.ToLower)
+	}
+	if data.Countries != nil {
+		rule.Countries = joinRuleList(data.Countries, strings.TrimSpace)
+	}
+	if data.Languages != nil {
+		rule.Languages = joinRuleList(data.Languages, strings.ToLower)
+	}
+	if data.StartsAt != nil {
+		rule.StartsAt = data.StartsAt
+	}
+	if data.EndsAt != nil {
+		rule.EndsAt = data.EndsAt
+	}
+
+	if err := checkRedirectRule(rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := s.Rules.Update(ctx.Request.Context(), &rule); err != nil {
+		utils.Log.Error("Failed to update redirect rule", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update redirect rule",
+		})
+		return
+	}
+
+	s.invalidateUrlCache(ctx.Request.Context(), url.DomainID, url.ShortKey)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    toRedirectRuleDTO(rule),
+		"message": "Redirect rule updated successfully",
+	})
+}
+
+func (s *Server) DeleteRedirectRule(ctx *gin.Context) {
+
+	url, rule, ok := s.findRedirectRule(ctx)
+
+	if !ok {
+		return
+	}
+
+	if err := s.Rules.Delete(ctx.Request.Context(), &rule); err != nil {
+		utils.Log.Error("Failed to delete redirect rule", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete redirect rule",
+		})
+		return
+	}
+
+	s.invalidateUrlCache(ctx.Request.Context(), url.DomainID, url.ShortKey)
+
+	utils.Log.Info("Redirect rule deleted", "rule_id", rule.ID, "urlID", url.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Redirect rule deleted successfully",
+	})
+}
+
+// ruleUrl finds the link of /url/:shortKey/rules in the caller's scope.
+func (s *Server) ruleUrl(ctx *gin.Context) (models.Url, bool) {
+
+	domainID, ok := s.queryDomainID(ctx)
+
+	if !ok {
+		return models.Url{}, false
+	}
+
+	return s.findScopedUrl(ctx, repository.UrlFilter{DomainID: domainID, ShortKey: ctx.Param("shortKey")})
+}
+
+func (s *Server) findRedirectRule(ctx *gin.Context) (models.Url, models.RedirectRule, bool) {
+
+	url, ok := s.ruleUrl(ctx)
+
+	if !ok {
+		return url, models.RedirectRule{}, false
+	}
+
+	ruleID, err := strconv.ParseUint(ctx.Param("ruleId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Redirect rule not found",
+		})
+		return url, models.RedirectRule{}, false
+	}
+
+	rule, err := s.Rules.Find(ctx.Request.Context(), url.ID, uint(ruleID))
+
+	if errors.Is(err, repository.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Redirect rule not found",
+		})
+		return url, rule, false
+	} else if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return url, rule, false
+	}
+
+	return url, rule, true
+}
+
+// checkRedirectRule rejects rules that would catch every visitor, hiding the
+// original URL for good, and empty time windows.
+func checkRedirectRule(rule models.RedirectRule) error {
+
+	if rule.Devices == "" && rule.OS == "" && rule.Countries == "" && rule.Languages == "" && rule.StartsAt == nil && rule.EndsAt == nil {
+		return errors.New("A redirect rule needs at least one condition")
+	}
+
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+func joinRuleList(values []string, normalize func(string) string) string {
+
+	normalized := make([]string, 0, len(values))
+
+	for _, value := range values {
+		normalized = append(normalized, normalize(value))
+	}
+
+	return strings.Join(normalized, ",")
+}
+
+func toRedirectRuleDTO(rule models.RedirectRule) dto.RedirectRuleDTO {
+	return dto.RedirectRuleDTO{
+		ID:          rule.ID,
+		Position:    rule.Position,
+		Destination: rule.Destination,
+		Devices:     rule.DeviceList(),
+		OS:          rule.OSList(),
+		Countries:   rule.CountryList(),
+		Languages:   rule.LanguageList(),
+		StartsAt:    rule.StartsAt,
+		EndsAt:      rule.EndsAt,
+		CreatedAt:   rule.CreatedAt,
+		UpdatedAt:   rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"time"
+
+	"shortly-api-service/internal/background"
+	"shortly-api-service/internal/clicks"
+	"shortly-api-service/internal/keys"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+)
+
+// ClickRecorder takes the clicks of served redirects, *clicks.Pipeline in
+// production.
+type ClickRecorder interface {
+	Enqueue(event clicks.Event) bool
+}
+
+// EventEmitter queues webhook events, *webhooks.Emitter in production.
+type EventEmitter interface {
+	EmitUrlEvent(eventType string, urls ...models.Url)
+	EmitTest(hook models.Webhook) (models.WebhookDelivery, error)
+}
+
+// Server holds the storage, caches and key source every handler except the
+// health checks works against, so they can run on the in-memory repositories
+// as well as on Postgres and Redis.
+type Server struct {
+	Urls       repository.UrlRepository
+	Users      repository.UserRepository
+	Sessions   repository.SessionRepository
+	ApiKeys    repository.ApiKeyRepository
+	Analytics  repository.AnalyticsRepository
+	Domains    repository.DomainRepository
+	Rules      repository.RedirectRuleRepository
+	Workspaces repository.WorkspaceRepository
+	Webhooks   repository.WebhookRepository
+	Cache      repository.LinkCache
+	Profiles   repository.ProfileCache
+	Denylist   repository.TokenDenylist
+	Keys       keys.KeySource
+	Clicks     ClickRecorder
+	Events     EventEmitter
+	Tasks      *background.Tasks
+}
+
+// Cache calls made while answering a request wait at most this long
+const cacheTimeout = 2 * time.Second
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/background"
+	"shortly-api-service/internal/clicks"
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/keys"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testUserHeader picks the signed-in user of a test request, standing in for
+// the JWT and Authorize middlewares.
+const testUserHeader = "X-Test-User"
+
+type recordedClicks struct {
+	mu     sync.Mutex
+	events []clicks.Event
+}
+
+func (r *recordedClicks) Enqueue(event clicks.Event) bool {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+
+	return true
+}
+
+func (r *recordedClicks) count() int {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.events)
+}
+
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordedEvents) EmitUrlEvent(eventType string, urls ...models.Url) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for range urls {
+		r.events = append(r.events, eventType)
+	}
+}
+
+func (r *recordedEvents) EmitTest(hook models.Webhook) (models.WebhookDelivery, error) {
+	return models.WebhookDelivery{WebhookID: hook.ID, Status: models.DeliveryPending}, nil
+}
+
+type testServer struct {
+	*Server
+	router    *gin.Engine
+	urls      *repository.MemoryUrlRepository
+	analytics *repository.MemoryAnalyticsRepository
+	clicks    *recordedClicks
+	events    *recordedEvents
+}
+
+func newTestServer(t *testing.T) *testServer {
+
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	config.AppConfig.SHORT_URL_BASE = "http://localhost:8080"
+	config.AppConfig.BULK_SHORTEN_LIMIT = 1000
+
+	urls := repository.NewMemoryUrlRepository()
+	users := repository.NewMemoryUserRepository()
+
+	ts := &testServer{
+		urls:      urls,
+		analytics: repository.NewMemoryAnalyticsRepository(),
+		clicks:    &recordedClicks{},
+		events:    &recordedEvents{},
+	}
+
+	ts.Server = &Server{
+		Urls:       urls,
+		Users:      users,
+		Sessions:   repository.NewMemorySessionRepository(),
+		ApiKeys:    repository.NewMemoryApiKeyRepository(),
+		Analytics:  ts.analytics,
+		Domains:    repository.NewMemoryDomainRepository(),
+		Rules:      repository.NewMemoryRedirectRuleRepository(),
+		Workspaces: repository.NewMemoryWorkspaceRepository(users),
+		Webhooks:   repository.NewMemoryWebhookRepository(urls),
+		Cache:      repository.NewMemoryLinkCache(),
+		Profiles:   repository.NewMemoryProfileCache(),
+		Denylist:   repository.NewMemoryTokenDenylist(),
+		Keys:       keys.NewLocal(8, urls),
+		Clicks:     ts.clicks,
+		Events:     ts.events,
+		Tasks:      background.New(time.Second),
+	}
+
+	router := gin.New()
+
+	router.GET("/url/redirect/:shortKey", ts.RedirectToOriginalUrl)
+
+	url := router.Group("/url", signedIn)
+	{
+		url.POST("/shorten", ts.CreateUrl)
+		url.POST("/shorten/bulk", ts.CreateBulkUrls)
+		url.GET("/:shortKey", ts.GetUrlDetails)
+		url.GET("/:shortKey/qr", ts.GetUrlQRCode)
+		url.PATCH("/:shortKey", ts.UpdateUrl)
+		url.DELETE("/:shortKey", ts.DeleteUrl)
+	}
+
+	domains := router.Group("/domains", signedIn)
+	{
+		domains.POST("/", ts.CreateDomain)
+		domains.POST("/:domainId/verify", ts.VerifyDomain)
+	}
+
+	analytics := router.Group("/analytics", signedIn)
+	{
+		analytics.GET("/:urlId", ts.GetAnalytics)
+		analytics.GET("/:urlId/timeseries", ts.GetAnalyticsTimeseries)
+		analytics.GET("/:urlId/breakdown/:dimension", ts.GetAnalyticsBreakdown)
+	}
+
+	ts.router = router
+
+	return ts
+}
+
+// signedIn sets what the auth middlewares would for the user of the request.
+func signedIn(ctx *gin.Context) {
+
+	userID, err := strconv.Atoi(ctx.GetHeader(testUserHeader))
+
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	ctx.Set("id", userID)
+	authz.Set(ctx, authz.Scope{UserID: uint(userID), Role: models.RoleOwner})
+}
+
+func (ts *testServer) do(t *testing.T, method string, path string, userID int, body interface{}) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	var payload bytes.Buffer
+
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.RemoteAddr = "127.0.0.1:40000"
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if userID != 0 {
+		req.Header.Set(testUserHeader, strconv.Itoa(userID))
+	}
+
+	rec := httptest.NewRecorder()
+	ts.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// createUrl shortens a URL for userID through the handler and returns
+// the stored link.
+func (ts *testServer) createUrl(t *testing.T, userID int, body gin.H) models.Url {
+
+	t.Helper()
+
+	rec := ts.do(t, http.MethodPost, "/url/shorten", userID, body)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create url: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Data struct {
+			ShortKey string `json:"short_url"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+
+	// Let the cache write of the create land before the test goes on
+	if err := ts.Tasks.Wait(context.Background()); err != nil {
+		t.Fatalf("wait for background tasks: %v", err)
+	}
+
+	url, err := ts.urls.FindByShortKey(context.Background(), 0, response.Data.ShortKey)
+
+	if err != nil {
+		t.Fatalf("created url %q not stored: %v", response.Data.ShortKey, err)
+	}
+
+	return url
+}
+
+func TestCreateUrl(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/a", "title": "Example"})
+
+	if url.OriginalURL != "https://example.com/a" || url.Title != "Example" {
+		t.Fatalf("stored url = %+v", url)
+	}
+
+	if url.UserID == nil || *url.UserID != "1" {
+		t.Fatalf("url owner = %v, want 1", url.UserID)
+	}
+
+	if len(url.ShortKey) != 8 {
+		t.Fatalf("generated short key %q, want 8 characters", url.ShortKey)
+	}
+
+	if len(ts.events.events) != 1 {
+		t.Fatalf("emitted %d events, want 1", len(ts.events.events))
+	}
+
+	if _, ok := ts.Cache.GetUrl(context.Background(), 0, url.ShortKey); !ok {
+		t.Fatal("created url not cached once background tasks finished")
+	}
+
+	// The same user shortening the same URL again is a conflict
+	if rec := ts.do(t, http.MethodPost, "/url/shorten", 1, gin.H{"original_url": "https://example.com/a"}); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate create: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateUrlCustomShortKey(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/a", "short_key": "my-link"})
+
+	if url.ShortKey != "my-link" {
+		t.Fatalf("short key = %q, want my-link", url.ShortKey)
+	}
+
+	// Short keys are unique per domain, whoever asks
+	rec := ts.do(t, http.MethodPost, "/url/shorten", 2, gin.H{"original_url": "https://example.com/b", "short_key": "my-link"})
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("taken short key: status %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateUrlValidation(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	rec := ts.do(t, http.MethodPost, "/url/shorten", 1, gin.H{"original_url": "not a url"})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRedirectToOriginalUrl(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/target"})
+
+	rec := ts.do(t, http.MethodGet, "/url/redirect/"+url.ShortKey, 0, nil)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusFound)
+	}
+
+	if location := rec.Header().Get("Location"); location != "https://example.com/target" {
+		t.Fatalf("Location = %q", location)
+	}
+
+	if ts.clicks.count() != 1 {
+		t.Fatalf("recorded %d clicks, want 1", ts.clicks.count())
+	}
+
+	if rec := ts.do(t, http.MethodGet, "/url/redirect/missing", 0, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown key: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestRedirectClickLimit(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/once", "max_clicks": 1})
+
+	if rec := ts.do(t, http.MethodGet, "/url/redirect/"+url.ShortKey, 0, nil); rec.Code != http.StatusFound {
+		t.Fatalf("first click: status %d, want %d", rec.Code, http.StatusFound)
+	}
+
+	if rec := ts.do(t, http.MethodGet, "/url/redirect/"+url.ShortKey, 0, nil); rec.Code != http.StatusGone {
+		t.Fatalf("second click: status %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestUpdateUrl(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/a"})
+
+	rec := ts.do(t, http.MethodPatch, "/url/"+url.ShortKey, 1, gin.H{"short_url": "renamed", "title": "New title"})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	if _, err := ts.urls.FindByShortKey(context.Background(), 0, url.ShortKey); err == nil {
+		t.Fatalf("old short key %q still resolves", url.ShortKey)
+	}
+
+	// The old key is dropped from the cache before the response
+	if _, ok := ts.Cache.GetUrl(context.Background(), 0, url.ShortKey); ok {
+		t.Fatalf("old short key %q still cached", url.ShortKey)
+	}
+
+	updated, err := ts.urls.FindByShortKey(context.Background(), 0, "renamed")
+
+	if err != nil {
+		t.Fatalf("renamed url not found: %v", err)
+	}
+
+	if updated.ID != url.ID || updated.Title != "New title" {
+		t.Fatalf("updated url = %+v", updated)
+	}
+}
+
+func TestUpdateUrlClearsLimits(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/a", "expires_at": expiresAt, "max_clicks": 5})
+
+	// Only the title changes, the response shows the whole stored link
+	rec := ts.do(t, http.MethodPatch, "/url/"+url.ShortKey, 1, gin.H{"title": "Kept limits"})
+
+	var response struct {
+		Data dto.UpdateUrlResponseDTO `json:"data"`
+	}
+
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	if response.Data.ShortKey != url.ShortKey || response.Data.ExpiresAt == nil || response.Data.MaxClicks == nil || *response.Data.MaxClicks != 5 {
+		t.Fatalf("response = %+v, want the stored key and limits", response.Data)
+	}
+
+	if rec := ts.do(t, http.MethodPatch, "/url/"+url.ShortKey, 1, gin.H{"max_clicks": 3, "clear_max_clicks": true}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("set and clear at once: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = ts.do(t, http.MethodPatch, "/url/"+url.ShortKey, 1, gin.H{"clear_expires_at": true, "clear_max_clicks": true})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("clear: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	stored, err := ts.urls.FindByShortKey(context.Background(), 0, url.ShortKey)
+
+	if err != nil || stored.ExpiresAt != nil || stored.MaxClicks != nil || stored.Title != "Kept limits" {
+		t.Fatalf("stored url = %+v, %v, want the limits cleared", stored, err)
+	}
+}
+
+func TestDeleteUrl(t *testing.T) {
+
+	ts := newTestServer(t)
+
+	url := ts.createUrl(t, 1, gin.H{"original_url": "https://example.com/a"})
+
+	if rec := ts.do(t, http.MethodDelete, "/url/"+url.ShortKey, 1, nil); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	if _, err := ts.urls.FindByShortKey(context.Background(), 0, url.ShortKey); err == nil {
+		t.Fatalf("deleted url %q still stored", url.ShortKey)
+	}
+
+	if rec := ts.do(t, http.MethodGet, "/url/"+url.ShortKey, 1, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("details after delete: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	refreshCookieName = "refresh_token"
+	// The refresh token is only ever sent to the auth endpoints
+	refreshCookiePath = "/api/v1/auth"
+)
+
+var errSessionRotated = errors.New("session was rotated concurrently")
+
+type sessionTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+func (s *Server) RefreshToken(ctx *gin.Context) {
+
+	var data validators.RefreshTokenValidator
+
+	// The body is optional, browsers send the refresh_token cookie instead
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&data); err != nil {
+			utils.Log.Error("Failed to bind request body", "error", err)
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid request format",
+			})
+			return
+		}
+	}
+
+	refreshToken := data.RefreshToken
+
+	if refreshToken == "" {
+		refreshToken, _ = ctx.Cookie(refreshCookieName)
+	}
+
+	if refreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: No refresh token provided",
+		})
+		return
+	}
+
+	hash := utils.HashToken(refreshToken)
+
+	session, err := s.Sessions.FindByRefreshHash(ctx.Request.Context(), hash)
+
+	if err != nil {
+
+		if !errors.Is(err, repository.ErrNotFound) {
+			utils.Log.Error("Failed to load session", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal server error",
+			})
+			return
+		}
+
+		// A refresh token that was already rotated away is being replayed, so
+		// it has leaked: kill the whole session.
+		if reused, err := s.Sessions.FindByPreviousRefreshHash(ctx.Request.Context(), hash); err == nil {
+			utils.Log.Warn("Refresh token reuse detected, revoking session", "session_id", reused.ID, "user_id", reused.UserID, "ip", ctx.ClientIP())
+			if err := s.revokeSessions(ctx, []models.Session{reused}); err != nil {
+				utils.Log.Error("Failed to revoke reused session", "error", err)
+			}
+		}
+
+		clearAuthCookies(ctx)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Invalid refresh token",
+		})
+		return
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		clearAuthCookies(ctx)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Session has expired",
+		})
+		return
+	}
+
+	user, err := s.Users.FindByID(ctx.Request.Context(), session.UserID)
+
+	if err != nil {
+		utils.Log.Error("User of session not found", "user_id", session.UserID, "error", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Invalid refresh token",
+		})
+		return
+	}
+
+	tokens, err := s.rotateSession(ctx, session, user)
+
+	if err == errSessionRotated {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Invalid refresh token",
+		})
+		return
+	}
+
+	if err != nil {
+		utils.Log.Error("Failed to rotate session", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Could not generate token",
+		})
+		return
+	}
+
+	// The access token issued before this refresh stops working right away
+	if err := s.Denylist.Add(ctx.Request.Context(), session.AccessTokenID, session.AccessExpiresAt); err != nil {
+		utils.Log.Error("Failed to denylist previous access token", "error", err)
+	}
+
+	setAuthCookies(ctx, tokens)
+
+	utils.Log.Info("Session refreshed", "user_id", user.ID, "session_id", session.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(config.AppConfig.ACCESS_TOKEN_TTL.Seconds()),
+		"message":       "Token refreshed successfully",
+	})
+}
+
+func (s *Server) LogoutAll(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	sessions, err := s.Sessions.ListActive(ctx.Request.Context(), uint(id))
+
+	if err != nil {
+		utils.Log.Error("Failed to load sessions", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke sessions",
+		})
+		return
+	}
+
+	if err := s.revokeSessions(ctx, sessions); err != nil {
+		utils.Log.Error("Failed to revoke sessions", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke sessions",
+		})
+		return
+	}
+
+	clearAuthCookies(ctx)
+
+	utils.Log.Info("All sessions revoked", "user_id", id, "count", len(sessions))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"revoked_sessions": len(sessions),
+		},
+		"message": "Logged out of all sessions",
+	})
+}
+
+// startSession records a new session for user and issues its first token pair.
+func (s *Server) startSession(ctx *gin.Context, user models.User) (sessionTokens, error) {
+
+	var tokens sessionTokens
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+
+	if err != nil {
+		return tokens, err
+	}
+
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        truncateString(ctx.Request.UserAgent(), 255),
+		IPAddress:        ctx.ClientIP(),
+		ExpiresAt:        time.Now().Add(config.AppConfig.REFRESH_TOKEN_TTL),
+	}
+
+	err = s.Sessions.Create(ctx.Request.Context(), &session, func(session *models.Session) error {
+
+		accessToken, jti, expiresAt, err := utils.GenerateToken(user.ID, user.Email, session.ID)
+
+		if err != nil {
+			return err
+		}
+
+		session.AccessTokenID = jti
+		session.AccessExpiresAt = expiresAt
+
+		tokens = sessionTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresAt: expiresAt}
+		return nil
+	})
+
+	return tokens, err
+}
+
+// rotateSession swaps the session's refresh token for a new one. The update
+// is conditional on the old hash so two concurrent refreshes can't both win.
+func (s *Server) rotateSession(ctx *gin.Context, session models.Session, user models.User) (sessionTokens, error) {
+
+	var tokens sessionTokens
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+
+	if err != nil {
+		return tokens, err
+	}
+
+	accessToken, jti, expiresAt, err := utils.GenerateToken(user.ID, user.Email, session.ID)
+
+	if err != nil {
+		return tokens, err
+	}
+
+	previousHash := session.RefreshTokenHash
+
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	session.AccessTokenID = jti
+	session.AccessExpiresAt = expiresAt
+	session.UserAgent = truncateString(ctx.Request.UserAgent(), 255)
+	session.IPAddress = ctx.ClientIP()
+
+	rotated, err := s.Sessions.Rotate(ctx.Request.Context(), session, previousHash)
+
+	if err != nil {
+		return tokens, err
+	}
+
+	if !rotated {
+		return tokens, errSessionRotated
+	}
+
+	return sessionTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresAt: expiresAt}, nil
+}
+
+// revokeSessions marks the sessions revoked and denylists their current
+// access tokens, so they stop working immediately.
+func (s *Server) revokeSessions(ctx *gin.Context, sessions []models.Session) error {
+
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(sessions))
+
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+
+	if err := s.Sessions.Revoke(ctx.Request.Context(), ids); err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := s.Denylist.Add(ctx.Request.Context(), session.AccessTokenID, session.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setAuthCookies(ctx *gin.Context, tokens sessionTokens) {
+	ctx.SetCookie("token", tokens.AccessToken, int(config.AppConfig.ACCESS_TOKEN_TTL.Seconds()), "/", "", true, true)
+	ctx.SetCookie(refreshCookieName, tokens.RefreshToken, int(config.AppConfig.REFRESH_TOKEN_TTL.Seconds()), refreshCookiePath, "", true, true)
+}
+
+func clearAuthCookies(ctx *gin.Context) {
+	ctx.SetCookie("token", "", -1, "/", "", true, true)
+	ctx.SetCookie(refreshCookieName, "", -1, refreshCookiePath, "", true, true)
+}
+
+func truncateString(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long a visitor can reopen a protected link without re-entering the password
+const unlockCookieTTL = 30 * time.Minute
+
+var unlockPage = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>Protected link</title>
+</head>
+<body style="font-family: sans-serif; max-width: 360px; margin: 80px auto;">
+	<h2>This link is password protected</h2>
+	{{if .Error}}<p style="color: #c00;">{{.Error}}</p>{{end}}
+	<form method="POST">
+		<input type="password" name="password" placeholder="Password" autofocus required>
+		<button type="submit">Unlock</button>
+	</form>
+</body>
+</html>`))
+
+func (s *Server) UnlockUrl(ctx *gin.Context) {
+
+	shortKey := ctx.Param("shortKey")
+
+	if shortKey == "" {
+		utils.Log.Error("Short key is missing from path")
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Short key is required",
+		})
+		return
+	}
+
+	var data validators.UnlockUrlValidator
+
+	if err := ctx.ShouldBind(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		renderUnlockPrompt(ctx, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+
+	validationErrors := validators.ValidateUnlockUrlData(data)
+
+	if len(validationErrors) > 0 {
+		renderUnlockPrompt(ctx, http.StatusBadRequest, "Password is required")
+		return
+	}
+
+	domainID, err := s.hostDomainID(ctx.Request.Context(), requestHost(ctx))
+
+	if err != nil {
+		utils.Log.Error("Failed to resolve domain", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	url, _, err := s.findUrlByShortKey(ctx.Request.Context(), domainID, shortKey)
+
+	if err != nil {
+		utils.Log.Error("Short Key not found in database", "error", err)
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "URL not found",
+		})
+		return
+	}
+
+	if url.Password != nil {
+		if !utils.VerifyPassword(data.Password, *url.Password) {
+			utils.Log.Warn("Invalid password for protected link", "shortKey", shortKey, "ip", ctx.ClientIP())
+			renderUnlockPrompt(ctx, http.StatusUnauthorized, "Password is not valid")
+			return
+		}
+
+		token, err := utils.GenerateUnlockToken(url.ID, url.DomainID, *url.Password, unlockCookieTTL)
+
+		if err != nil {
+			utils.Log.Error("Could not generate unlock token", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Could not generate token",
+			})
+			return
+		}
+
+		ctx.SetCookie(unlockCookieName(url), token, int(unlockCookieTTL.Seconds()), "/", "", true, true)
+	}
+
+	// Send the visitor back through the regular redirect so expiry, click
+	// limits and analytics are applied exactly once.
+	ctx.Redirect(http.StatusSeeOther, ctx.Request.URL.Path)
+}
+
+// unlockCookieName is per link, short keys repeat across domains.
+func unlockCookieName(url models.Url) string {
+	return "unlock_" + strconv.FormatUint(uint64(url.ID), 10)
+}
+
+func isUrlUnlocked(ctx *gin.Context, url models.Url) bool {
+
+	token, err := ctx.Cookie(unlockCookieName(url))
+
+	if err != nil || token == "" || url.Password == nil {
+		return false
+	}
+
+	return utils.VerifyUnlockToken(token, url.ID, url.DomainID, *url.Password)
+}
+
+// renderUnlockPrompt answers browsers with the password form and every other
+// client with a JSON error.
+func renderUnlockPrompt(ctx *gin.Context, status int, message string) {
+
+	if !strings.Contains(ctx.GetHeader("Accept"), "text/html") {
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	pageError := ""
+
+	if status != http.StatusUnauthorized || ctx.Request.Method == http.MethodPost {
+		pageError = message
+	}
+
+	var page bytes.Buffer
+
+	if err := unlockPage.Execute(&page, gin.H{"Error": pageError}); err != nil {
+		utils.Log.Error("Failed to render unlock page", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	ctx.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
@@ -2,27 +2,26 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
-	"shortly-proto/gen/key"
-
-	"shortly-api-service/internal/clients"
-	"shortly-api-service/internal/database"
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/clicks"
 	"shortly-api-service/internal/dto"
-	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/metrics"
 	"shortly-api-service/internal/models"
-	"shortly-api-service/internal/redis"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/rules"
 	"shortly-api-service/internal/utils"
 	"shortly-api-service/internal/validators"
+	"shortly-api-service/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func CreateUrl(ctx *gin.Context) {
+func (s *Server) CreateUrl(ctx *gin.Context) {
 
 	idInterface, exists := ctx.Get("id")
 
@@ -48,6 +47,12 @@ func CreateUrl(ctx *gin.Context) {
 
 	idStr := strconv.Itoa(id)
 
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
 	var data validators.CreateUrlValidator
 
 	if err := ctx.ShouldBindJSON(&data); err != nil {
@@ -69,9 +74,24 @@ func CreateUrl(ctx *gin.Context) {
 		return
 	}
 
-	var existing models.Url
+	domainID, err := s.userDomainID(ctx.Request.Context(), idStr, data.Domain)
 
-	if err := database.DB.Where("original_url = ?  AND user_id = ? ", data.OriginalURL, idStr).First(&existing).Error; err == nil {
+	if errors.Is(err, errDomainNotVerified) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Domain not found or not verified",
+		})
+		return
+	} else if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if _, err := s.Urls.FindInScope(ctx.Request.Context(), scope, repository.UrlFilter{DomainID: domainID, OriginalURL: data.OriginalURL}); err == nil {
 		utils.Log.Error("Url is already shortened")
 		ctx.JSON(http.StatusConflict, gin.H{
 			"success": false,
@@ -81,10 +101,10 @@ func CreateUrl(ctx *gin.Context) {
 	}
 
 	if data.ShortKey == "" {
-		key, err := clients.KGSClient.GetKey(context.Background(), &key.Empty{})
+		key, err := s.Keys.Next(ctx.Request.Context())
 
 		if err != nil {
-			utils.Log.Error("Failed to get key from KGS service", "error", err)
+			utils.Log.Error("Failed to get key from key source", "error", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
 				"error":   "Failed to generate short key",
@@ -92,12 +112,10 @@ func CreateUrl(ctx *gin.Context) {
 			return
 		}
 
-		data.ShortKey = key.Key
+		data.ShortKey = key
 	}
 
-	var existingKey models.Url
-
-	if err := database.DB.Where("short_key = ?", data.ShortKey).First(&existingKey).Error; err == nil {
+	if _, err := s.Urls.FindByShortKey(ctx.Request.Context(), domainID, data.ShortKey); err == nil {
 		utils.Log.Error("ShortKey already exists")
 		ctx.JSON(http.StatusConflict, gin.H{
 			"success": false,
@@ -106,14 +124,36 @@ func CreateUrl(ctx *gin.Context) {
 		return
 	}
 
+	var passwordHash *string
+
+	if data.Password != "" {
+		hash, err := utils.HashPassword(data.Password)
+
+		if err != nil {
+			utils.Log.Error("Error hashing password", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Error hashing password",
+			})
+			return
+		}
+
+		passwordHash = &hash
+	}
+
 	newUrl := models.Url{
 		OriginalURL: data.OriginalURL,
 		ShortKey:    data.ShortKey,
+		DomainID:    domainID,
 		Title:       data.Title,
-		UserID:      &idStr,
+		Password:    passwordHash,
+		ExpiresAt:   data.ExpiresAt,
+		MaxClicks:   data.MaxClicks,
 	}
 
-	if err := database.DB.Create(&newUrl).Error; err != nil {
+	scope.Assign(&newUrl)
+
+	if err := s.Urls.Create(ctx.Request.Context(), &newUrl); err != nil {
 		utils.Log.Error("Failed to create URL", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -122,27 +162,13 @@ func CreateUrl(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "url:" + data.ShortKey
-
-	jsonByte, err := json.Marshal(newUrl)
-
-	if err != nil {
-		utils.Log.Error("Failed to marshal url data")
-	}
-
-	go func() {
-		err := redis.RedisClient.Set(
-			ctx.Request.Context(),
-			cacheKey,
-			jsonByte,
-			24*time.Hour).Err()
+	s.Events.EmitUrlEvent(webhooks.EventUrlCreated, newUrl)
 
-		if err != nil {
-			utils.Log.Error("Failed to cache generated url data", "error", err)
-		}
-	}()
+	s.Tasks.Go("cache created url", func(ctx context.Context) error {
+		return s.Cache.SetUrl(ctx, newUrl, urlCacheTTL(newUrl))
+	})
 
-	utils.Log.Info("URL successfully created", "shortKey", data.ShortKey, "userID", idStr)
+	utils.Log.Info("URL successfully created", "shortKey", data.ShortKey, "userID", idStr, "workspaceID", newUrl.WorkspaceID)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -150,45 +176,41 @@ func CreateUrl(ctx *gin.Context) {
 			ID:          newUrl.ID,
 			OriginalURL: newUrl.OriginalURL,
 			ShortKey:    newUrl.ShortKey,
+			Domain:      normalizeHost(data.Domain),
+			WorkspaceID: newUrl.WorkspaceID,
 			Title:       newUrl.Title,
+			ExpiresAt:   newUrl.ExpiresAt,
+			MaxClicks:   newUrl.MaxClicks,
+			Protected:   newUrl.Password != nil,
 		},
 		"message": "URL successfully created",
 	})
 
 }
 
-func GetAllUrls(ctx *gin.Context) {
+func (s *Server) GetAllUrls(ctx *gin.Context) {
 
-	idInterface, exists := ctx.Get("id")
+	scope, ok := requestScope(ctx)
 
-	if !exists {
-		utils.Log.Error("User ID not found in context")
-		ctx.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Unauthorized: User ID missing",
-		})
+	if !ok {
 		return
 	}
 
-	id, ok := idInterface.(int)
+	urls, err := s.Urls.ListInScope(ctx.Request.Context(), scope)
 
-	if !ok {
-		utils.Log.Error("Failed to assert user ID type")
+	if err != nil {
+		utils.Log.Error("Failed to fetch URLs", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Internal server error",
+			"error":   "Failed to retrieve URLs",
 		})
 		return
 	}
 
-	userID := strconv.Itoa(id)
-
-	var urls []models.Url
+	domainHosts, err := s.urlDomainHosts(ctx.Request.Context(), urls)
 
-	if err := database.DB.
-		Where("user_id = ?", userID).
-		Find(&urls).Error; err != nil {
-		utils.Log.Error("Failed to fetch URLs", "error", err)
+	if err != nil {
+		utils.Log.Error("Failed to fetch domains", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to retrieve URLs",
@@ -203,8 +225,13 @@ func GetAllUrls(ctx *gin.Context) {
 			ID:          url.ID,
 			OriginalURL: url.OriginalURL,
 			ShortKey:    url.ShortKey,
+			Domain:      domainHosts[url.DomainID],
+			WorkspaceID: url.WorkspaceID,
 			Title:       url.Title,
 			Clicks:      url.Clicks,
+			ExpiresAt:   url.ExpiresAt,
+			MaxClicks:   url.MaxClicks,
+			Protected:   url.Password != nil,
 			CreatedAt:   url.CreatedAt,
 		})
 	}
@@ -216,7 +243,7 @@ func GetAllUrls(ctx *gin.Context) {
 	})
 }
 
-func GetUrlDetails(ctx *gin.Context) {
+func (s *Server) GetUrlDetails(ctx *gin.Context) {
 
 	shortKey := ctx.Param("shortKey")
 
@@ -229,14 +256,15 @@ func GetUrlDetails(ctx *gin.Context) {
 		return
 	}
 
-	var url models.Url
+	domainID, ok := s.queryDomainID(ctx)
 
-	if err := database.DB.Where("short_key = ?", shortKey).First(&url).Error; err != nil {
-		utils.Log.Error("Failed to find URL", "error", err)
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "URL not found",
-		})
+	if !ok {
+		return
+	}
+
+	url, ok := s.findScopedUrl(ctx, repository.UrlFilter{DomainID: domainID, ShortKey: shortKey})
+
+	if !ok {
 		return
 	}
 
@@ -246,15 +274,19 @@ func GetUrlDetails(ctx *gin.Context) {
 			ID:          url.ID,
 			OriginalURL: url.OriginalURL,
 			ShortKey:    url.ShortKey,
+			Domain:      ctx.Query("domain"),
 			Title:       url.Title,
 			Clicks:      url.Clicks,
+			ExpiresAt:   url.ExpiresAt,
+			MaxClicks:   url.MaxClicks,
+			Protected:   url.Password != nil,
 			CreatedAt:   url.CreatedAt,
 		},
 		"message": "URL details retrieved successfully",
 	})
 }
 
-func RedirectToOriginalUrl(ctx *gin.Context) {
+func (s *Server) RedirectToOriginalUrl(ctx *gin.Context) {
 
 	shortKey := ctx.Param("shortKey")
 
@@ -267,26 +299,26 @@ func RedirectToOriginalUrl(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "url:" + shortKey
+	domainID, err := s.hostDomainID(ctx.Request.Context(), requestHost(ctx))
 
-	data, err := redis.RedisClient.Get(ctx.Request.Context(), cacheKey).Result()
-
-	if err == nil && data != "" {
-		var cachedDTO models.Url
-		if err := json.Unmarshal([]byte(data), &cachedDTO); err == nil {
-			utils.Log.Info("URL served from Redis cache")
+	if err != nil {
+		utils.Log.Error("Failed to resolve domain", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
 
-			go incrementClickCount(cachedDTO.ID)
-			go storeAnalytics(ctx, cachedDTO.ID)
+	url, fromCache, err := s.findUrlByShortKey(ctx.Request.Context(), domainID, shortKey)
 
-			ctx.Redirect(http.StatusFound, cachedDTO.OriginalURL)
-			return
-		}
+	if fromCache {
+		metrics.RedirectCache.WithLabelValues(metrics.CacheHit).Inc()
+	} else {
+		metrics.RedirectCache.WithLabelValues(metrics.CacheMiss).Inc()
 	}
 
-	var url models.Url
-
-	if err := database.DB.Where("short_key = ?", shortKey).First(&url).Error; err != nil {
+	if err != nil {
 		utils.Log.Error("Short Key not found in database", "error", err)
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -295,56 +327,213 @@ func RedirectToOriginalUrl(ctx *gin.Context) {
 		return
 	}
 
-	go func() {
-		jsonData, err := json.Marshal(url)
-		if err == nil {
-			err = redis.RedisClient.Set(ctx.Request.Context(), cacheKey, jsonData, 24*time.Hour).Err()
-			if err != nil {
-				utils.Log.Error("Failed to cache URL in Redis", "error", err)
-			}
+	if isUrlExpired(url) {
+		utils.Log.Warn("Short link has expired", "shortKey", shortKey)
+		s.invalidateUrlCache(ctx.Request.Context(), domainID, shortKey)
+		ctx.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   "This link has expired",
+		})
+		return
+	}
+
+	if url.Password != nil && !isUrlUnlocked(ctx, url) {
+		renderUnlockPrompt(ctx, http.StatusUnauthorized, "This link is password protected")
+		return
+	}
+
+	if url.MaxClicks != nil {
+		// Links with a click budget are counted synchronously so the limit
+		// can't be overshot by concurrent redirects.
+		allowed, err := s.Urls.ConsumeClick(ctx.Request.Context(), url.ID)
+
+		if err != nil {
+			utils.Log.Error("Failed to update click count", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal server error",
+			})
+			return
+		}
+
+		if !allowed {
+			utils.Log.Warn("Short link reached its click limit", "shortKey", shortKey)
+			s.invalidateUrlCache(ctx.Request.Context(), domainID, shortKey)
+			ctx.JSON(http.StatusGone, gin.H{
+				"success": false,
+				"error":   "This link has reached its click limit",
+			})
+			return
 		}
-	}()
+	}
+
+	if !fromCache {
+		s.Tasks.Go("cache url", func(ctx context.Context) error {
+			return s.Cache.SetUrl(ctx, url, urlCacheTTL(url))
+		})
+	}
+
+	// Click count and analytics are written in batches by the click pipeline
+	s.Clicks.Enqueue(clicks.Event{
+		UrlID:     url.ID,
+		ClickedAt: time.Now(),
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+		Referrer:  ctx.GetHeader("Referer"),
+		Counted:   url.MaxClicks != nil,
+	})
+
+	destination := url.OriginalURL
+
+	if len(url.RedirectRules) > 0 {
+
+		// The answer depends on who asks, shared caches must not reuse it
+		ctx.Header("Vary", "User-Agent, Accept-Language")
 
-	// Async operations
-	go incrementClickCount(url.ID)
-	go storeAnalytics(ctx, url.ID)
+		visitor := rules.NewVisitor(ctx.ClientIP(), ctx.GetHeader("User-Agent"), ctx.GetHeader("Accept-Language"))
 
-	ctx.Redirect(http.StatusFound, url.OriginalURL)
+		if rule, ok := rules.Match(url.RedirectRules, visitor); ok {
+			destination = rule.Destination
+		}
+	}
+
+	ctx.Redirect(http.StatusFound, destination)
+}
+
+// requestScope returns the workspace scope resolved by the Authorize
+// middleware for this route.
+func requestScope(ctx *gin.Context) (authz.Scope, bool) {
+
+	scope, ok := authz.FromContext(ctx)
+
+	if !ok {
+		utils.Log.Error("Authorization scope missing from context", "path", ctx.FullPath())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return scope, false
+	}
+
+	return scope, true
+}
+
+// findScopedUrl is the lookup every URL and analytics management handler
+// goes through. Links outside the caller's scope answer 404 like missing
+// ones, so their existence isn't revealed.
+func (s *Server) findScopedUrl(ctx *gin.Context, filter repository.UrlFilter) (models.Url, bool) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return models.Url{}, false
+	}
+
+	url, err := s.Urls.FindInScope(ctx.Request.Context(), scope, filter)
+
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.Log.Warn("URL not found in caller scope", "userID", scope.UserID, "workspaceID", scope.WorkspaceID, "path", ctx.Request.URL.Path)
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "URL not found",
+		})
+		return url, false
+	} else if err != nil {
+		utils.Log.Error("Failed to find URL", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return url, false
+	}
+
+	return url, true
 }
 
-func incrementClickCount(urlId uint) {
-	err := database.DB.Model(&models.Url{}).Where("id = ?", urlId).
-		UpdateColumn("clicks", gorm.Expr("clicks + ?", 1)).Error
+// findUrlByShortKey looks the short key up in the link cache first and falls
+// back to the URL repository, reporting whether the result came from the cache.
+func (s *Server) findUrlByShortKey(ctx context.Context, domainID uint, shortKey string) (models.Url, bool, error) {
+
+	if url, ok := s.Cache.GetUrl(ctx, domainID, shortKey); ok {
+		utils.Log.Info("URL served from cache")
+		return url, true, nil
+	}
+
+	url, err := s.Urls.FindByShortKey(ctx, domainID, shortKey)
+
 	if err != nil {
-		utils.Log.Error("Failed to update click count", "error", err)
+		return url, false, err
 	}
+
+	// Rules are cached with the link, so redirects don't query them
+	url.RedirectRules, err = s.Rules.List(ctx, url.ID)
+
+	return url, false, err
 }
 
-func storeAnalytics(ctx *gin.Context, urlID uint) {
-	ip := ctx.ClientIP()
-	userAgent := ctx.GetHeader("User-Agent")
+// queryDomainID resolves the optional ?domain= parameter of the URL
+// management endpoints, answering the request itself when it is invalid.
+func (s *Server) queryDomainID(ctx *gin.Context) (uint, bool) {
 
-	country := lib.GetCountryFromIP(ip)
-	device, browser, os := lib.ParseUserAgent(userAgent)
+	domainID, err := s.userDomainID(ctx.Request.Context(), strconv.Itoa(ctx.GetInt("id")), ctx.Query("domain"))
 
-	analytics := models.Analytics{
-		UrlID:     strconv.FormatUint(uint64(urlID), 10),
-		ClickedAt: time.Now(),
-		IPAddress: ip,
-		UserAgent: userAgent,
-		Referrer:  ctx.GetHeader("Referer"),
-		Country:   country,
-		Device:    device,
-		Browser:   browser,
-		OS:        os,
+	if errors.Is(err, errDomainNotVerified) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "URL not found",
+		})
+		return 0, false
+	} else if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return 0, false
+	}
+
+	return domainID, true
+}
+
+// urlCacheTTL returns how long a URL may stay in Redis: one day, capped at
+// the link's remaining lifetime so expired links are never served from cache.
+func urlCacheTTL(url models.Url) time.Duration {
+	ttl := 24 * time.Hour
+
+	if url.ExpiresAt != nil {
+		if remaining := time.Until(*url.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
 	}
 
-	if err := database.DB.Create(&analytics).Error; err != nil {
-		utils.Log.Error("Failed to store analytics", "error", err)
+	// go-redis treats a zero TTL as "never expire"
+	if ttl <= 0 {
+		ttl = time.Millisecond
 	}
+
+	return ttl
+}
+
+func isUrlExpired(url models.Url) bool {
+	return url.ExpiresAt != nil && !time.Now().Before(*url.ExpiresAt)
 }
 
-func UpdateUrl(ctx *gin.Context) {
+// invalidateUrlCache drops the cached link with its rendered QR codes. It
+// runs before the response so the next request can't read the old link, and
+// isn't cut short when the client goes away.
+func (s *Server) invalidateUrlCache(ctx context.Context, domainID uint, shortKey string) {
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheTimeout)
+	defer cancel()
+
+	if err := s.Cache.DeleteUrl(ctx, domainID, shortKey); err != nil {
+		utils.Log.Error("Failed to delete from cache", "error", err)
+	} else {
+		utils.Log.Info("Deleted URL from cache", "shortKey", shortKey, "domainID", domainID)
+	}
+}
+
+func (s *Server) UpdateUrl(ctx *gin.Context) {
 
 	shortKey := ctx.Param("shortKey")
 
@@ -357,14 +546,15 @@ func UpdateUrl(ctx *gin.Context) {
 		return
 	}
 
-	var url models.Url
+	domainID, ok := s.queryDomainID(ctx)
 
-	if err := database.DB.Where("short_key = ?", shortKey).First(&url).Error; err != nil {
-		utils.Log.Error("URL not found", "error", err)
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "URL not found",
-		})
+	if !ok {
+		return
+	}
+
+	url, ok := s.findScopedUrl(ctx, repository.UrlFilter{DomainID: domainID, ShortKey: shortKey})
+
+	if !ok {
 		return
 	}
 
@@ -390,8 +580,7 @@ func UpdateUrl(ctx *gin.Context) {
 	}
 
 	if updateData.ShortKey != "" && updateData.ShortKey != shortKey {
-		var existing models.Url
-		if err := database.DB.Where("short_key = ?", updateData.ShortKey).First(&existing).Error; err == nil {
+		if _, err := s.Urls.FindByShortKey(ctx.Request.Context(), domainID, updateData.ShortKey); err == nil {
 			utils.Log.Error("Short key already exists", "short_key", updateData.ShortKey)
 			ctx.JSON(http.StatusConflict, gin.H{
 				"success": false,
@@ -401,16 +590,40 @@ func UpdateUrl(ctx *gin.Context) {
 		}
 	}
 
-	updateFields := map[string]interface{}{}
-
 	if updateData.ShortKey != "" {
-		updateFields["ShortKey"] = updateData.ShortKey
+		url.ShortKey = updateData.ShortKey
 	}
 	if updateData.Title != "" {
-		updateFields["Title"] = updateData.Title
+		url.Title = updateData.Title
+	}
+	if updateData.ExpiresAt != nil {
+		url.ExpiresAt = updateData.ExpiresAt
+	}
+	if updateData.MaxClicks != nil {
+		url.MaxClicks = updateData.MaxClicks
+	}
+	if updateData.ClearExpiresAt {
+		url.ExpiresAt = nil
+	}
+	if updateData.ClearMaxClicks {
+		url.MaxClicks = nil
+	}
+	if updateData.Password != "" {
+		hash, err := utils.HashPassword(updateData.Password)
+
+		if err != nil {
+			utils.Log.Error("Error hashing password", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Error hashing password",
+			})
+			return
+		}
+
+		url.Password = &hash
 	}
 
-	if err := database.DB.Model(&url).Select("ShortKey", "Title").Updates(updateFields).Error; err != nil {
+	if err := s.Urls.Update(ctx.Request.Context(), &url); err != nil {
 		utils.Log.Error("Failed to update URL", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -419,34 +632,28 @@ func UpdateUrl(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "url:" + shortKey
+	s.Events.EmitUrlEvent(webhooks.EventUrlUpdated, url)
 
-	go func() {
-		_, err := redis.RedisClient.Del(context.Background(), cacheKey).Result()
-
-		if err != nil {
-			utils.Log.Error("Failed to delete from cache", "error", err)
-		} else {
-			utils.Log.Info("Deleted URL from Redis cache", "cacheKey", cacheKey)
-		}
-
-	}()
+	s.invalidateUrlCache(ctx.Request.Context(), domainID, shortKey)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": dto.UpdateUrlResponseDTO{
 			ID:          url.ID,
 			OriginalURL: url.OriginalURL,
-			ShortKey:    updateData.ShortKey,
-			Title:       updateData.Title,
+			ShortKey:    url.ShortKey,
+			Title:       url.Title,
 			Clicks:      url.Clicks,
+			ExpiresAt:   url.ExpiresAt,
+			MaxClicks:   url.MaxClicks,
+			Protected:   url.Password != nil,
 			UpdatedAt:   url.UpdatedAt,
 		},
 		"message": "URL updated successfully",
 	})
 }
 
-func DeleteUrl(ctx *gin.Context) {
+func (s *Server) DeleteUrl(ctx *gin.Context) {
 
 	shortKey := ctx.Param("shortKey")
 
@@ -459,18 +666,19 @@ func DeleteUrl(ctx *gin.Context) {
 		return
 	}
 
-	var url models.Url
+	domainID, ok := s.queryDomainID(ctx)
 
-	if err := database.DB.Where("short_key = ?", shortKey).First(&url).Error; err != nil {
-		utils.Log.Error("URL not found", "error", err)
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "URL not found",
-		})
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Delete(&url).Error; err != nil {
+	url, ok := s.findScopedUrl(ctx, repository.UrlFilter{DomainID: domainID, ShortKey: shortKey})
+
+	if !ok {
+		return
+	}
+
+	if err := s.Urls.Delete(ctx.Request.Context(), &url); err != nil {
 		utils.Log.Error("Failed to delete URL", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -479,17 +687,9 @@ func DeleteUrl(ctx *gin.Context) {
 		return
 	}
 
-	cacheKey := "url:" + shortKey
+	s.Events.EmitUrlEvent(webhooks.EventUrlDeleted, url)
 
-	go func() {
-		_, err := redis.RedisClient.Del(context.Background(), cacheKey).Result()
-
-		if err != nil {
-			utils.Log.Error("Failed to delete from cache", "error", err)
-		} else {
-			utils.Log.Info("Deleted URL from Redis cache", "cacheKey", cacheKey)
-		}
-	}()
+	s.invalidateUrlCache(ctx.Request.Context(), domainID, shortKey)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxWebhooksPerScope = 20
+
+func (s *Server) CreateWebhook(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	var data validators.CreateWebhookValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.URL = strings.TrimSpace(data.URL)
+
+	validationErrors := validators.ValidateCreateWebhookData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	count, err := s.Webhooks.CountInScope(ctx.Request.Context(), scope)
+
+	if err != nil {
+		utils.Log.Error("Database error", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if count >= maxWebhooksPerScope {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Webhook limit reached, delete an unused webhook first",
+		})
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+
+	if err != nil {
+		utils.Log.Error("Could not generate webhook secret", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	hook := models.Webhook{
+		UserID:      scope.UserID,
+		WorkspaceID: scope.WorkspaceID,
+		URL:         data.URL,
+		Secret:      secret,
+		Events:      strings.Join(data.Events, ","),
+		Active:      true,
+	}
+
+	if err := s.Webhooks.Create(ctx.Request.Context(), &hook); err != nil {
+		utils.Log.Error("Failed to create webhook", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create webhook",
+		})
+		return
+	}
+
+	utils.Log.Info("Webhook created", "webhook_id", hook.ID, "userID", scope.UserID, "workspaceID", scope.WorkspaceID, "events", hook.Events)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": dto.CreateWebhookDTO{
+			WebhookDTO: toWebhookDTO(hook),
+			Secret:     secret,
+		},
+		"message": "Webhook created, copy the signing secret now as it won't be shown again",
+	})
+}
+
+func (s *Server) GetWebhooks(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	hooks, err := s.Webhooks.ListInScope(ctx.Request.Context(), scope)
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch webhooks", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch webhooks",
+		})
+		return
+	}
+
+	response := make([]dto.WebhookDTO, 0, len(hooks))
+
+	for _, hook := range hooks {
+		response = append(response, toWebhookDTO(hook))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Webhooks retrieved successfully",
+	})
+}
+
+func (s *Server) DeleteWebhook(ctx *gin.Context) {
+
+	hook, ok := s.findScopedWebhook(ctx)
+
+	if !ok {
+		return
+	}
+
+	// Pending deliveries of a deleted webhook are failed by the dispatcher
+	if err := s.Webhooks.Delete(ctx.Request.Context(), &hook); err != nil {
+		utils.Log.Error("Failed to delete webhook", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete webhook",
+		})
+		return
+	}
+
+	utils.Log.Info("Webhook deleted", "webhook_id", hook.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+func (s *Server) GetWebhookDeliveries(ctx *gin.Context) {
+
+	hook, ok := s.findScopedWebhook(ctx)
+
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	deliveries, total, err := s.Webhooks.Deliveries(ctx.Request.Context(), hook.ID, ctx.Query("status"), (page-1)*limit, limit)
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch webhook deliveries", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch webhook deliveries",
+		})
+		return
+	}
+
+	response := make([]dto.WebhookDeliveryDTO, 0, len(deliveries))
+
+	for _, delivery := range deliveries {
+		response = append(response, toWebhookDeliveryDTO(delivery))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"pagination": dto.PaginationDTO{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		},
+		"message": "Webhook deliveries retrieved successfully",
+	})
+}
+
+// TestWebhook queues a webhook.test event so the receiver can check its
+// signature verification. The outcome shows up in the delivery log.
+func (s *Server) TestWebhook(ctx *gin.Context) {
+
+	hook, ok := s.findScopedWebhook(ctx)
+
+	if !ok {
+		return
+	}
+
+	delivery, err := s.Events.EmitTest(hook)
+
+	if err != nil {
+		utils.Log.Error("Failed to queue test delivery", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to queue test delivery",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    toWebhookDeliveryDTO(delivery),
+		"message": "Test event queued",
+	})
+}
+
+func (s *Server) findScopedWebhook(ctx *gin.Context) (models.Webhook, bool) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return models.Webhook{}, false
+	}
+
+	hookID, err := strconv.ParseUint(ctx.Param("webhookId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Webhook not found",
+		})
+		return models.Webhook{}, false
+	}
+
+	hook, err := s.Webhooks.FindInScope(ctx.Request.Context(), scope, uint(hookID))
+
+	if errors.Is(err, repository.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Webhook not found",
+		})
+		return hook, false
+	}
+
+	if err != nil {
+		utils.Log.Error("Failed to load webhook", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return hook, false
+	}
+
+	return hook, true
+}
+
+func toWebhookDTO(hook models.Webhook) dto.WebhookDTO {
+	return dto.WebhookDTO{
+		ID:          hook.ID,
+		URL:         hook.URL,
+		Events:      hook.EventList(),
+		Active:      hook.Active,
+		WorkspaceID: hook.WorkspaceID,
+		CreatedAt:   hook.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryDTO(delivery models.WebhookDelivery) dto.WebhookDeliveryDTO {
+
+	deliveryDTO := dto.WebhookDeliveryDTO{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+
+	if delivery.Status == models.DeliveryPending {
+		deliveryDTO.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	return deliveryDTO
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+	"shortly-api-service/internal/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) CreateWorkspace(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	var data validators.CreateWorkspaceValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+
+	validationErrors := validators.ValidateCreateWorkspaceData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	workspace := models.Workspace{Name: data.Name}
+
+	if err := s.Workspaces.Create(ctx.Request.Context(), &workspace, uint(id)); err != nil {
+		utils.Log.Error("Failed to create workspace", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create workspace",
+		})
+		return
+	}
+
+	utils.Log.Info("Workspace created", "workspaceID", workspace.ID, "userID", id)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": dto.WorkspaceDTO{
+			ID:        workspace.ID,
+			Name:      workspace.Name,
+			Role:      models.RoleOwner,
+			CreatedAt: workspace.CreatedAt,
+		},
+		"message": "Workspace created successfully",
+	})
+}
+
+func (s *Server) GetWorkspaces(ctx *gin.Context) {
+
+	idInterface, exists := ctx.Get("id")
+
+	if !exists {
+		utils.Log.Error("Id not found in context")
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized: Id is missing from context",
+		})
+		return
+	}
+
+	id, ok := idInterface.(int)
+
+	if !ok {
+		utils.Log.Error("Failed to assert id type from context")
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal server error",
+		})
+		return
+	}
+
+	memberships, err := s.Workspaces.ListForUser(ctx.Request.Context(), uint(id))
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch workspaces", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch workspaces",
+		})
+		return
+	}
+
+	response := make([]dto.WorkspaceDTO, 0, len(memberships))
+
+	for _, membership := range memberships {
+		response = append(response, dto.WorkspaceDTO{
+			ID:        membership.ID,
+			Name:      membership.Name,
+			Role:      membership.Role,
+			CreatedAt: membership.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Workspaces retrieved successfully",
+	})
+}
+
+func (s *Server) GetWorkspaceMembers(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	members, err := s.Workspaces.Members(ctx.Request.Context(), *scope.WorkspaceID)
+
+	if err != nil {
+		utils.Log.Error("Failed to fetch workspace members", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch workspace members",
+		})
+		return
+	}
+
+	response := make([]dto.WorkspaceMemberDTO, 0, len(members))
+
+	for _, member := range members {
+		response = append(response, toWorkspaceMemberDTO(member))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+		"message": "Workspace members retrieved successfully",
+	})
+}
+
+func (s *Server) AddWorkspaceMember(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	var data validators.AddWorkspaceMemberValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	data.Email = strings.TrimSpace(strings.ToLower(data.Email))
+
+	validationErrors := validators.ValidateAddWorkspaceMemberData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	user, err := s.Users.FindByEmail(ctx.Request.Context(), data.Email)
+
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "User not found",
+		})
+		return
+	}
+
+	if _, err := s.Workspaces.MemberRole(ctx.Request.Context(), *scope.WorkspaceID, user.ID); err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "User is already a member of this workspace",
+		})
+		return
+	}
+
+	member := models.WorkspaceMember{
+		WorkspaceID: *scope.WorkspaceID,
+		UserID:      user.ID,
+		User:        &user,
+		Role:        data.Role,
+	}
+
+	if err := s.Workspaces.AddMember(ctx.Request.Context(), &member); err != nil {
+		utils.Log.Error("Failed to add workspace member", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to add workspace member",
+		})
+		return
+	}
+
+	utils.Log.Info("Workspace member added", "workspaceID", *scope.WorkspaceID, "memberID", user.ID, "role", data.Role, "by", scope.UserID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    toWorkspaceMemberDTO(member),
+		"message": "Member added successfully",
+	})
+}
+
+func (s *Server) UpdateWorkspaceMember(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid userId in path",
+		})
+		return
+	}
+
+	var data validators.UpdateWorkspaceMemberValidator
+
+	if err := ctx.ShouldBindJSON(&data); err != nil {
+		utils.Log.Error("Failed to bind request body", "error", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request format",
+		})
+		return
+	}
+
+	validationErrors := validators.ValidateUpdateWorkspaceMemberData(data)
+
+	if len(validationErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success":          false,
+			"validation_error": validationErrors,
+		})
+		return
+	}
+
+	member, err := s.Workspaces.UpdateRole(ctx.Request.Context(), *scope.WorkspaceID, uint(memberID), data.Role)
+
+	if !handleMembershipError(ctx, err, "Failed to update workspace member") {
+		return
+	}
+
+	utils.Log.Info("Workspace member role changed", "workspaceID", *scope.WorkspaceID, "memberID", memberID, "role", data.Role, "by", scope.UserID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    toWorkspaceMemberDTO(member),
+		"message": "Member updated successfully",
+	})
+}
+
+func (s *Server) RemoveWorkspaceMember(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid userId in path",
+		})
+		return
+	}
+
+	s.removeWorkspaceMember(ctx, *scope.WorkspaceID, uint(memberID))
+}
+
+// LeaveWorkspace removes the caller from the workspace. The links they
+// created stay in the workspace.
+func (s *Server) LeaveWorkspace(ctx *gin.Context) {
+
+	scope, ok := requestScope(ctx)
+
+	if !ok {
+		return
+	}
+
+	s.removeWorkspaceMember(ctx, *scope.WorkspaceID, scope.UserID)
+}
+
+func (s *Server) removeWorkspaceMember(ctx *gin.Context, workspaceID uint, memberID uint) {
+
+	err := s.Workspaces.RemoveMember(ctx.Request.Context(), workspaceID, memberID)
+
+	if !handleMembershipError(ctx, err, "Failed to remove workspace member") {
+		return
+	}
+
+	utils.Log.Info("Workspace member removed", "workspaceID", workspaceID, "memberID", memberID, "by", ctx.GetInt("id"))
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member removed successfully",
+	})
+}
+
+// handleMembershipError answers the request for a failed membership change
+// and reports whether the handler may continue.
+func handleMembershipError(ctx *gin.Context, err error, message string) bool {
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, repository.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Member not found",
+		})
+	case errors.Is(err, repository.ErrLastOwner):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "A workspace must keep at least one owner",
+		})
+	default:
+		utils.Log.Error(message, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	return false
+}
+
+func toWorkspaceMemberDTO(member models.WorkspaceMember) dto.WorkspaceMemberDTO {
+
+	memberDTO := dto.WorkspaceMemberDTO{
+		UserID:   member.UserID,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}
+
+	if member.User != nil {
+		memberDTO.Email = member.User.Email
+		memberDTO.Username = member.User.Username
+	}
+
+	return memberDTO
+}
@@ -0,0 +1,56 @@
+package keys
+
+import (
+	"context"
+	"time"
+
+	"shortly-api-service/internal/metrics"
+	"shortly-api-service/internal/utils"
+)
+
+// Fallback asks primary first, bounded by timeout, and serves the request
+// from secondary when primary fails. Used to keep link creation working
+// while KGS is down or slow.
+type Fallback struct {
+	primary   KeySource
+	secondary KeySource
+	timeout   time.Duration
+}
+
+func NewFallback(primary KeySource, secondary KeySource, timeout time.Duration) *Fallback {
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+		timeout:   timeout,
+	}
+}
+
+func (f *Fallback) Next(ctx context.Context) (string, error) {
+	return next(ctx, f)
+}
+
+func (f *Fallback) Take(ctx context.Context, n int) ([]string, error) {
+
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	primaryCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	keys, err := f.primary.Take(primaryCtx, n)
+
+	if err == nil {
+		return keys, nil
+	}
+
+	// The caller is gone, nothing left to serve
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	utils.Log.Warn("Key source failed, generating keys locally", "count", n, "error", err)
+	metrics.KeySourceFallbacks.Inc()
+
+	return f.secondary.Take(ctx, n)
+}
@@ -0,0 +1,100 @@
+package keys
+
+import (
+	"context"
+	"fmt"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/clients"
+
+	"gorm.io/gorm"
+)
+
+// Key sources selectable with KEY_SOURCE
+const (
+	SourceKGS      = "kgs"
+	SourceLocal    = "local"
+	SourceSequence = "sequence"
+)
+
+// Alphabet of the keys generated inside the API, the same as KGS's default pool
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// KeySource hands out short keys no link uses yet.
+type KeySource interface {
+	Next(ctx context.Context) (string, error)
+	Take(ctx context.Context, n int) ([]string, error)
+}
+
+// KeyChecker reports which keys are already used by a link on any domain.
+// repository.UrlRepository implements it.
+type KeyChecker interface {
+	ExistingShortKeys(ctx context.Context, shortKeys []string) ([]string, error)
+}
+
+// NewFromConfig builds the source selected by KEY_SOURCE. The KGS source
+// falls back to local generation unless KEY_SOURCE_FALLBACK is off.
+func NewFromConfig(db *gorm.DB, checker KeyChecker) (KeySource, error) {
+
+	length := config.AppConfig.LOCAL_KEY_LENGTH
+
+	if length < 4 || length > 50 {
+		return nil, fmt.Errorf("LOCAL_KEY_LENGTH must be between 4 and 50, got %d", length)
+	}
+
+	switch config.AppConfig.KEY_SOURCE {
+	case SourceKGS:
+		if !config.AppConfig.KEY_SOURCE_FALLBACK {
+			return clients.KeyPool, nil
+		}
+		return NewFallback(clients.KeyPool, NewLocal(length, checker), config.AppConfig.KGS_KEY_TIMEOUT), nil
+	case SourceLocal:
+		return NewLocal(length, checker), nil
+	case SourceSequence:
+		return NewSequence(db, length, checker), nil
+	default:
+		return nil, fmt.Errorf("unknown KEY_SOURCE %q (use kgs, local or sequence)", config.AppConfig.KEY_SOURCE)
+	}
+}
+
+// next takes a single key from source.
+func next(ctx context.Context, source KeySource) (string, error) {
+
+	keys, err := source.Take(ctx, 1)
+
+	if err != nil {
+		return "", err
+	}
+
+	return keys[0], nil
+}
+
+// unused drops the candidates checker reports as taken.
+func unused(ctx context.Context, checker KeyChecker, candidates []string) ([]string, error) {
+
+	existing, err := checker.ExistingShortKeys(ctx, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return candidates, nil
+	}
+
+	taken := make(map[string]bool, len(existing))
+
+	for _, key := range existing {
+		taken[key] = true
+	}
+
+	free := candidates[:0]
+
+	for _, key := range candidates {
+		if !taken[key] {
+			free = append(free, key)
+		}
+	}
+
+	return free, nil
+}
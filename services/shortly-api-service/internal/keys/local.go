@@ -0,0 +1,106 @@
+package keys
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// Rounds of fresh candidates drawn before giving up on colliding keys
+const maxLocalAttempts = 5
+
+// Local draws random keys with crypto/rand, dropping and redrawing the ones
+// a link already uses. Its length should differ from the KGS pools so the
+// keys it issues can never be handed out by KGS later.
+type Local struct {
+	length  int
+	checker KeyChecker
+}
+
+func NewLocal(length int, checker KeyChecker) *Local {
+	return &Local{
+		length:  length,
+		checker: checker,
+	}
+}
+
+func (l *Local) Next(ctx context.Context) (string, error) {
+	return next(ctx, l)
+}
+
+func (l *Local) Take(ctx context.Context, n int) ([]string, error) {
+
+	keys := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+
+	for attempt := 0; attempt < maxLocalAttempts && len(keys) < n; attempt++ {
+
+		candidates := make([]string, 0, n-len(keys))
+
+		for len(candidates) < n-len(keys) {
+
+			key, err := randomKey(l.length)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			candidates = append(candidates, key)
+		}
+
+		free, err := unused(ctx, l.checker, candidates)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, free...)
+	}
+
+	if len(keys) < n {
+		return nil, fmt.Errorf("could not find %d unused keys of length %d", n, l.length)
+	}
+
+	return keys, nil
+}
+
+// randomKey draws every character uniformly from the alphabet. Bytes at or
+// above the largest multiple of the alphabet size are rejected so there is
+// no modulo bias.
+func randomKey(length int) (string, error) {
+
+	base := len(alphabet)
+	bound := 256 - 256%base
+
+	var result strings.Builder
+	result.Grow(length)
+
+	buf := make([]byte, length)
+
+	for result.Len() < length {
+
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			if int(b) >= bound {
+				continue
+			}
+
+			result.WriteByte(alphabet[int(b)%base])
+
+			if result.Len() == length {
+				break
+			}
+		}
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,107 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"gorm.io/gorm"
+)
+
+// SequenceName is the PostgreSQL sequence the migration creates for Sequence.
+const SequenceName = "short_key_seq"
+
+// Odd and not a multiple of 31, so multiplying by it permutes [0, 62^length)
+var sequenceMultiplier = big.NewInt(25214903917)
+
+// Sequence turns numbers from a PostgreSQL sequence into keys. Every number
+// maps to a distinct key, so instances never hand out the same one, and
+// consecutive numbers are scattered over the key space so links aren't
+// trivially enumerable. Keys taken by custom short keys are skipped.
+type Sequence struct {
+	db      *gorm.DB
+	length  int
+	space   *big.Int
+	checker KeyChecker
+}
+
+func NewSequence(db *gorm.DB, length int, checker KeyChecker) *Sequence {
+	return &Sequence{
+		db:      db,
+		length:  length,
+		space:   new(big.Int).Exp(big.NewInt(int64(len(alphabet))), big.NewInt(int64(length)), nil),
+		checker: checker,
+	}
+}
+
+func (s *Sequence) Next(ctx context.Context) (string, error) {
+	return next(ctx, s)
+}
+
+func (s *Sequence) Take(ctx context.Context, n int) ([]string, error) {
+
+	keys := make([]string, 0, n)
+
+	for attempt := 0; attempt < maxLocalAttempts && len(keys) < n; attempt++ {
+
+		var numbers []int64
+
+		if err := s.db.WithContext(ctx).
+			Raw("SELECT nextval(?) FROM generate_series(1, ?)", SequenceName, n-len(keys)).
+			Scan(&numbers).Error; err != nil {
+			return nil, err
+		}
+
+		candidates := make([]string, 0, len(numbers))
+
+		for _, number := range numbers {
+
+			key, err := s.encode(number)
+
+			if err != nil {
+				return nil, err
+			}
+
+			candidates = append(candidates, key)
+		}
+
+		free, err := unused(ctx, s.checker, candidates)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, free...)
+	}
+
+	if len(keys) < n {
+		return nil, fmt.Errorf("could not find %d unused keys in sequence %s", n, SequenceName)
+	}
+
+	return keys, nil
+}
+
+// encode scrambles number within the key space and writes it in the
+// alphabet, left padded to the key length.
+func (s *Sequence) encode(number int64) (string, error) {
+
+	n := big.NewInt(number)
+
+	if n.Sign() < 0 || n.Cmp(s.space) >= 0 {
+		return "", errors.New("key space exhausted for this length")
+	}
+
+	n.Mul(n, sequenceMultiplier).Mod(n, s.space)
+
+	base := big.NewInt(int64(len(alphabet)))
+	digit := new(big.Int)
+	key := make([]byte, s.length)
+
+	for i := s.length - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		key[i] = alphabet[digit.Int64()]
+	}
+
+	return string(key), nil
+}
@@ -0,0 +1,14 @@
+package lib
+
+import (
+	"context"
+	"net"
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it, tests can
+// swap in a fake.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+var DomainResolver TXTResolver = net.DefaultResolver
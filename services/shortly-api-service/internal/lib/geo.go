@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/redis"
+	"shortly-api-service/internal/utils"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type GeoInfo struct {
+	Country     string `json:"country"`
+	CountryCode string `json:"country_code,omitempty"`
+	Region      string `json:"region"`
+	City        string `json:"city"`
+	ASN         uint   `json:"asn"`
+	ASOrg       string `json:"as_org"`
+}
+
+// GeoLocator resolves an IP address to its location. An empty Country means
+// the locator doesn't know the address.
+type GeoLocator interface {
+	Lookup(ip net.IP) (GeoInfo, error)
+}
+
+var Geo GeoLocator
+
+// Countries is the local city database alone, nil without one. Redirects
+// read it to match country rules and never wait on the network.
+var Countries GeoLocator
+
+// InitGeoLocator builds the locator chain from config: the local MMDB files
+// when configured, then the ipapi.co HTTP lookup when GEOIP_HTTP_FALLBACK
+// opts in. With neither, every address is Unknown and no visitor IP leaves
+// the network.
+func InitGeoLocator() error {
+
+	var chain []GeoLocator
+
+	Countries = nil
+
+	if config.AppConfig.GEOIP_CITY_DB != "" || config.AppConfig.GEOIP_ASN_DB != "" {
+
+		locator, err := NewMMDBLocator(config.AppConfig.GEOIP_CITY_DB, config.AppConfig.GEOIP_ASN_DB)
+
+		if err != nil {
+			return err
+		}
+
+		chain = append(chain, locator)
+
+		if config.AppConfig.GEOIP_CITY_DB != "" {
+			Countries = locator
+		}
+
+		utils.Log.Info("✅ Loaded GeoIP database", "city_db", config.AppConfig.GEOIP_CITY_DB, "asn_db", config.AppConfig.GEOIP_ASN_DB)
+	}
+
+	if config.AppConfig.GEOIP_HTTP_FALLBACK {
+		chain = append(chain, NewHTTPLocator(2*time.Second))
+	}
+
+	if len(chain) == 0 {
+		utils.Log.Warn("No GeoIP database configured, visitor locations are recorded as Unknown")
+		Geo = unknownLocator{}
+		return nil
+	}
+
+	Geo = chainLocator(chain)
+
+	return nil
+}
+
+// LookupGeo resolves ip through the configured locators, caching the result
+// in Redis for a day.
+func LookupGeo(ip string) GeoInfo {
+
+	parsed := net.ParseIP(ip)
+
+	if parsed == nil {
+		return GeoInfo{Country: "Unknown"}
+	}
+
+	if parsed.IsLoopback() {
+		return GeoInfo{Country: "Localhost"}
+	}
+
+	if parsed.IsPrivate() || parsed.IsUnspecified() || parsed.IsLinkLocalUnicast() {
+		return GeoInfo{Country: "Unknown"}
+	}
+
+	cacheKey := "ip-geo:" + ip
+
+	if cached, err := redis.RedisClient.Get(context.Background(), cacheKey).Result(); err == nil && cached != "" {
+		var info GeoInfo
+		if err := json.Unmarshal([]byte(cached), &info); err == nil {
+			return info
+		}
+	}
+
+	info, err := Geo.Lookup(parsed)
+
+	if err != nil || info.Country == "" {
+		return GeoInfo{Country: "Unknown"}
+	}
+
+	if jsonBytes, err := json.Marshal(info); err == nil {
+		_ = redis.RedisClient.Set(context.Background(), cacheKey, jsonBytes, 24*time.Hour).Err()
+	}
+
+	return info
+}
+
+// unknownLocator knows no address, used when no source is configured.
+type unknownLocator struct{}
+
+func (unknownLocator) Lookup(ip net.IP) (GeoInfo, error) {
+	return GeoInfo{}, nil
+}
+
+// LookupCountry resolves the country of ip from the local database, giving up
+// after timeout. It reports false when there is no database, the address is
+// unknown or the lookup took too long.
+func LookupCountry(ip string, timeout time.Duration) (GeoInfo, bool) {
+
+	parsed := net.ParseIP(ip)
+	locator := Countries
+
+	if parsed == nil || locator == nil {
+		return GeoInfo{}, false
+	}
+
+	result := make(chan GeoInfo, 1)
+
+	go func() {
+
+		info, err := locator.Lookup(parsed)
+
+		if err != nil {
+			info = GeoInfo{}
+		}
+
+		result <- info
+	}()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	select {
+	case info := <-result:
+		return info, info.CountryCode != "" || info.Country != ""
+	case <-deadline.C:
+		return GeoInfo{}, false
+	}
+}
+
+// chainLocator returns the first answer that knows the country.
+type chainLocator []GeoLocator
+
+func (c chainLocator) Lookup(ip net.IP) (GeoInfo, error) {
+
+	var lastErr error
+
+	for _, locator := range c {
+		info, err := locator.Lookup(ip)
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if info.Country != "" {
+			return info, nil
+		}
+	}
+
+	return GeoInfo{}, lastErr
+}
+
+// MMDBLocator reads MaxMind-format databases (GeoLite2/GeoIP2 City or
+// Country, and ASN) from local files.
+type MMDBLocator struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+type mmdbCityRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+type mmdbASNRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// NewMMDBLocator opens the given files, either path may be empty.
+func NewMMDBLocator(cityPath string, asnPath string) (*MMDBLocator, error) {
+
+	locator := &MMDBLocator{}
+
+	if cityPath != "" {
+		reader, err := maxminddb.Open(cityPath)
+
+		if err != nil {
+			return nil, fmt.Errorf("❌ Failed to open GeoIP city database: %w", err)
+		}
+
+		locator.city = reader
+	}
+
+	if asnPath != "" {
+		reader, err := maxminddb.Open(asnPath)
+
+		if err != nil {
+			locator.Close()
+			return nil, fmt.Errorf("❌ Failed to open GeoIP ASN database: %w", err)
+		}
+
+		locator.asn = reader
+	}
+
+	return locator, nil
+}
+
+func (m *MMDBLocator) Lookup(ip net.IP) (GeoInfo, error) {
+
+	var info GeoInfo
+
+	if m.city != nil {
+		var record mmdbCityRecord
+
+		if err := m.city.Lookup(ip, &record); err != nil {
+			return info, err
+		}
+
+		info.Country = record.Country.Names["en"]
+		info.CountryCode = record.Country.ISOCode
+		info.City = record.City.Names["en"]
+
+		if len(record.Subdivisions) > 0 {
+			info.Region = record.Subdivisions[0].Names["en"]
+		}
+	}
+
+	if m.asn != nil {
+		var record mmdbASNRecord
+
+		if err := m.asn.Lookup(ip, &record); err != nil {
+			return info, err
+		}
+
+		info.ASN = record.Number
+		info.ASOrg = record.Organization
+	}
+
+	return info, nil
+}
+
+func (m *MMDBLocator) Close() {
+	if m.city != nil {
+		m.city.Close()
+	}
+	if m.asn != nil {
+		m.asn.Close()
+	}
+}
+
+// HTTPLocator queries ipapi.co. It sends visitor IPs to a third party and is
+// only used when no local database is configured or as an explicit fallback.
+type HTTPLocator struct {
+	client http.Client
+}
+
+type ipApiResponse struct {
+	CountryName string `json:"country_name"`
+	CountryCode string `json:"country_code"`
+	Region      string `json:"region"`
+	City        string `json:"city"`
+	ASN         string `json:"asn"`
+	Org         string `json:"org"`
+	Error       bool   `json:"error"`
+}
+
+func NewHTTPLocator(timeout time.Duration) *HTTPLocator {
+	return &HTTPLocator{client: http.Client{Timeout: timeout}}
+}
+
+func (h *HTTPLocator) Lookup(ip net.IP) (GeoInfo, error) {
+
+	resp, err := h.client.Get(fmt.Sprintf("https://ipapi.co/%s/json/", ip.String()))
+
+	if err != nil {
+		return GeoInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	var result ipApiResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return GeoInfo{}, err
+	}
+
+	if result.Error {
+		return GeoInfo{}, errors.New("ipapi lookup failed")
+	}
+
+	asn, _ := strconv.ParseUint(strings.TrimPrefix(result.ASN, "AS"), 10, 32)
+
+	return GeoInfo{
+		Country:     result.CountryName,
+		CountryCode: result.CountryCode,
+		Region:      result.Region,
+		City:        result.City,
+		ASN:         uint(asn),
+		ASOrg:       result.Org,
+	}, nil
+}
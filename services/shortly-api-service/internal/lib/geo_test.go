@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/utils"
+)
+
+func TestInitGeoLocatorSources(t *testing.T) {
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+
+	config.AppConfig.GEOIP_CITY_DB = ""
+	config.AppConfig.GEOIP_ASN_DB = ""
+
+	// Without a database or the opt-in, no address is sent anywhere
+	config.AppConfig.GEOIP_HTTP_FALLBACK = false
+
+	if err := InitGeoLocator(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	if _, ok := Geo.(unknownLocator); !ok {
+		t.Fatalf("locator %T, want unknownLocator", Geo)
+	}
+
+	config.AppConfig.GEOIP_HTTP_FALLBACK = true
+
+	if err := InitGeoLocator(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	chain, ok := Geo.(chainLocator)
+
+	if !ok || len(chain) != 1 {
+		t.Fatalf("locator %#v, want a chain of the HTTP locator", Geo)
+	}
+
+	if _, ok := chain[0].(*HTTPLocator); !ok {
+		t.Fatalf("chain holds %T, want *HTTPLocator", chain[0])
+	}
+}
+
+// slowLocator answers after delay.
+type slowLocator struct {
+	delay time.Duration
+	info  GeoInfo
+}
+
+func (s slowLocator) Lookup(ip net.IP) (GeoInfo, error) {
+	time.Sleep(s.delay)
+	return s.info, nil
+}
+
+func TestLookupCountry(t *testing.T) {
+
+	previous := Countries
+	t.Cleanup(func() { Countries = previous })
+
+	Countries = nil
+
+	if _, ok := LookupCountry("203.0.113.7", time.Second); ok {
+		t.Fatal("country known without a database")
+	}
+
+	Countries = slowLocator{info: GeoInfo{Country: "Germany", CountryCode: "DE"}}
+
+	if info, ok := LookupCountry("203.0.113.7", time.Second); !ok || info.CountryCode != "DE" {
+		t.Fatalf("got %+v, %v, want DE", info, ok)
+	}
+
+	if _, ok := LookupCountry("not an ip", time.Second); ok {
+		t.Fatal("country known for an invalid address")
+	}
+
+	Countries = slowLocator{delay: time.Second, info: GeoInfo{Country: "Germany", CountryCode: "DE"}}
+
+	start := time.Now()
+
+	if _, ok := LookupCountry("203.0.113.7", 10*time.Millisecond); ok {
+		t.Fatal("late answer used")
+	}
+
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Fatalf("waited %s past the deadline", waited)
+	}
+}
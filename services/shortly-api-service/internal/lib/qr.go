@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QROptions control how a QR code is rendered. Size is the width of the
+// image in pixels, Margin the quiet zone in modules.
+type QROptions struct {
+	Format string
+	Size   int
+	Level  string
+	Margin int
+}
+
+func IsValidQRLevel(level string) bool {
+	_, ok := qrLevels[level]
+	return ok
+}
+
+// RenderQR encodes content as a QR code and returns the image with its
+// content type.
+func RenderQR(content string, opts QROptions) ([]byte, string, error) {
+
+	level, ok := qrLevels[opts.Level]
+
+	if !ok {
+		return nil, "", fmt.Errorf("unknown error correction level %q", opts.Level)
+	}
+
+	code, err := qrcode.New(content, level)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The library's fixed border is replaced by the requested margin
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	switch opts.Format {
+	case QRFormatSVG:
+		return renderQRSvg(modules, opts), "image/svg+xml", nil
+	case QRFormatPNG:
+		data, err := renderQRPng(modules, opts)
+		return data, "image/png", err
+	default:
+		return nil, "", fmt.Errorf("unknown QR format %q", opts.Format)
+	}
+}
+
+func renderQRPng(modules [][]bool, opts QROptions) ([]byte, error) {
+
+	total := len(modules) + 2*opts.Margin
+	scale := opts.Size / total
+
+	// Never scale below one pixel per module, the code wouldn't scan
+	if scale < 1 {
+		scale = 1
+	}
+
+	// Modules are scaled by whole pixels, the rest is spread around the code
+	width := max(opts.Size, total*scale)
+	offset := (width-total*scale)/2 + opts.Margin*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func renderQRSvg(modules [][]bool, opts QROptions) []byte {
+
+	total := len(modules) + 2*opts.Margin
+
+	var path strings.Builder
+
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges"><rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		opts.Size, opts.Size, total, total, path.String(),
+	))
+}
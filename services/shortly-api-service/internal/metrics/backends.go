@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// InstrumentDB counts failed statements on every GORM callback chain. A
+// missing record is an answer, not a failure, so it isn't counted.
+func InstrumentDB(db *gorm.DB) error {
+
+	count := func(tx *gorm.DB) {
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			BackendErrors.WithLabelValues(BackendPostgres).Inc()
+		}
+	}
+
+	callbacks := db.Callback()
+
+	if err := callbacks.Create().After("gorm:create").Register("metrics:create", count); err != nil {
+		return err
+	}
+
+	if err := callbacks.Query().After("gorm:query").Register("metrics:query", count); err != nil {
+		return err
+	}
+
+	if err := callbacks.Update().After("gorm:update").Register("metrics:update", count); err != nil {
+		return err
+	}
+
+	if err := callbacks.Delete().After("gorm:delete").Register("metrics:delete", count); err != nil {
+		return err
+	}
+
+	if err := callbacks.Row().After("gorm:row").Register("metrics:row", count); err != nil {
+		return err
+	}
+
+	return callbacks.Raw().After("gorm:raw").Register("metrics:raw", count)
+}
+
+// InstrumentRedis counts failed commands of client. redis.Nil only means
+// the key doesn't exist and isn't counted.
+func InstrumentRedis(client *redis.Client) {
+	client.AddHook(redisHook{})
+}
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+
+		conn, err := next(ctx, network, addr)
+
+		if err != nil {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return conn, err
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+
+		err := next(ctx, cmd)
+
+		if err != nil && !errors.Is(err, redis.Nil) {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+
+		err := next(ctx, cmds)
+
+		if err != nil && !errors.Is(err, redis.Nil) {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return err
+	}
+}
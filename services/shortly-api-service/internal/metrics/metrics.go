@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortly_api"
+
+// Redirect cache lookup results
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Backends whose failures are counted in BackendErrors
+const (
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+)
+
+var (
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	RedirectCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirect_cache_lookups_total",
+		Help:      "Redirect lookups answered from the Redis cache (hit) or PostgreSQL (miss).",
+	}, []string{"result"})
+
+	BackendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_errors_total",
+		Help:      "Failed PostgreSQL queries and Redis commands.",
+	}, []string{"backend"})
+
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected with 429 by the rate limiter, by route.",
+	}, []string{"route"})
+
+	KGSCircuitState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kgs_circuit_state",
+		Help:      "State of the KGS circuit breaker: 0 closed, 1 open, 2 half-open.",
+	})
+
+	KeySourceFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "key_source_fallbacks_total",
+		Help:      "Short key requests served by local generation because KGS failed or timed out.",
+	})
+)
+
+// Middleware records the latency of every request under its route template,
+// e.g. /api/v1/url/redirect/:shortKey, so short keys don't become labels.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		RequestDuration.WithLabelValues(c.Request.Method, Route(c), strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Route returns the matched route template, or "unmatched" for 404s.
+func Route(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
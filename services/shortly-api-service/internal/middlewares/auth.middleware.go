@@ -2,30 +2,49 @@ package middlewares
 
 import (
 	"net/http"
-	"strings"
 
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/background"
+	"shortly-api-service/internal/repository"
 	"shortly-api-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		var token string
-		var err error
+// Auth holds what the authentication and authorization middlewares look
+// up, so they run on the in-memory repositories as well as on Postgres and
+// Redis.
+type Auth struct {
+	Users      repository.UserRepository
+	ApiKeys    repository.ApiKeyRepository
+	Denylist   repository.TokenDenylist
+	Workspaces authz.Members
+	Tasks      *background.Tasks
+}
 
-		token, err = ctx.Cookie("token")
-		if err != nil {
-			// If cookie is missing, check the Authorization header
-			authHeader := ctx.GetHeader("Authorization")
-			if authHeader != "" {
-				parts := strings.Split(authHeader, " ")
-				if len(parts) == 2 && parts[0] == "Bearer" {
-					token = parts[1]
-				}
+func NewAuth(users repository.UserRepository, apiKeys repository.ApiKeyRepository, denylist repository.TokenDenylist, workspaces authz.Members, tasks *background.Tasks) *Auth {
+	return &Auth{
+		Users:      users,
+		ApiKeys:    apiKeys,
+		Denylist:   denylist,
+		Workspaces: workspaces,
+		Tasks:      tasks,
+	}
+}
+
+func (a *Auth) AuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Scripts authenticate with a personal API key instead of a session
+		if key := ctx.GetHeader(ApiKeyHeader); key != "" {
+			if a.authenticateApiKey(ctx, key) {
+				ctx.Next()
 			}
+			return
 		}
 
+		// Token comes from the cookie or the Authorization header
+		token := utils.ExtractToken(ctx)
+
 		// If token is still empty, return unauthorized
 		if token == "" {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized: No token provided"})
@@ -56,9 +75,35 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Every access token carries a jti so it can be revoked before it expires
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized: Invalid token"})
+			ctx.Abort()
+			return
+		}
+
+		revoked, err := a.Denylist.Contains(ctx.Request.Context(), jti)
+		if err != nil {
+			utils.Log.Error("Failed to check token denylist", "error", err)
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "Unable to verify token"})
+			ctx.Abort()
+			return
+		}
+
+		if revoked {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized: Token has been revoked"})
+			ctx.Abort()
+			return
+		}
+
+		sessionID, _ := claims["sid"].(float64)
+
 		// Store in context for later use in routes
 		ctx.Set("id", int(userID)) // Convert float64 to int
 		ctx.Set("email", email)
+		ctx.Set("jti", jti)
+		ctx.Set("sid", uint(sessionID))
 
 		// Continue request processing
 		ctx.Next()
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize resolves the workspace the request acts in (the :workspaceId
+// path parameter or the X-Workspace-ID header) and checks that the caller's
+// role grants permission. Handlers read the result with authz.FromContext.
+func (a *Auth) Authorize(permission authz.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		id, ok := ctx.Get("id")
+		userID, isInt := id.(int)
+
+		if !ok || !isInt {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized: Id is missing from context"})
+			ctx.Abort()
+			return
+		}
+
+		workspace := ctx.Param("workspaceId")
+
+		if workspace == "" {
+			workspace = ctx.GetHeader(authz.WorkspaceHeader)
+		}
+
+		scope, err := authz.Resolve(ctx.Request.Context(), a.Workspaces, uint(userID), workspace)
+
+		switch {
+		case errors.Is(err, authz.ErrInvalidWorkspace):
+			ctx.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid workspace id"})
+			ctx.Abort()
+			return
+		case errors.Is(err, authz.ErrNotMember):
+			ctx.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Workspace not found"})
+			ctx.Abort()
+			return
+		case err != nil:
+			utils.Log.Error("Failed to resolve workspace", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			ctx.Abort()
+			return
+		}
+
+		if !scope.Can(permission) {
+			utils.Log.Warn("Permission denied", "userID", userID, "workspace", workspace, "role", scope.Role, "permission", permission)
+			ctx.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Forbidden: your role doesn't allow this action"})
+			ctx.Abort()
+			return
+		}
+
+		authz.Set(ctx, scope)
+		ctx.Next()
+	}
+}
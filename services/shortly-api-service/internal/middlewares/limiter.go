@@ -1,27 +1,19 @@
 package middlewares
 
 import (
-	"context"
-	"shortly-api-service/config"
+	"shortly-api-service/internal/metrics"
+	"shortly-api-service/internal/redis"
 	"shortly-api-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ulule/limiter/v3"
 	redisStore "github.com/ulule/limiter/v3/drivers/store/redis"
-
-	"github.com/redis/go-redis/v9"
 )
 
 func RateLimiter(rateString string) gin.HandlerFunc {
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     config.AppConfig.REDIS_ADDR,
-		Password: "",
-		DB:       2,
-	})
-
-	if _, err := rdb.Ping(context.Background()).Result(); err != nil {
-		panic("🔴 Failed to connect to Redis for rate limiting: " + err.Error())
+	if redis.RateLimitClient == nil {
+		panic("🔴 Redis must be connected before registering rate limited routes")
 	}
 
 	rate, err := limiter.NewRateFromFormatted(rateString)
@@ -30,7 +22,7 @@ func RateLimiter(rateString string) gin.HandlerFunc {
 		panic("🔴 Invalid rate limit format: " + err.Error())
 	}
 
-	store, err := redisStore.NewStoreWithOptions(rdb, limiter.StoreOptions{
+	store, err := redisStore.NewStoreWithOptions(redis.RateLimitClient, limiter.StoreOptions{
 		Prefix:   "rate_limit",
 		MaxRetry: 3,
 	})
@@ -54,6 +46,7 @@ func RateLimiter(rateString string) gin.HandlerFunc {
 
 		if ctx.Reached {
 			utils.Log.Warn("Rate limit exceeded", "ip", contextKey, "path", c.Request.URL.Path)
+			metrics.RateLimitRejections.WithLabelValues(metrics.Route(c)).Inc()
 			c.AbortWithStatusJSON(429, gin.H{"error": "Too Many Requests"})
 			return
 		}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"shortly-api-service/config"
 	"shortly-api-service/internal/database"
+	"shortly-api-service/internal/keys"
 	"shortly-api-service/internal/models"
 	"shortly-api-service/internal/utils"
 )
@@ -25,7 +26,15 @@ func RunMigration() {
 	err := database.DB.AutoMigrate(
 		&models.User{},
 		&models.Url{},
+		&models.RedirectRule{},
 		&models.Analytics{},
+		&models.Domain{},
+		&models.Session{},
+		&models.ApiKey{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 
 	if err != nil {
@@ -33,6 +42,29 @@ func RunMigration() {
 		os.Exit(1)
 	}
 
+	// Short keys are unique per domain now, drop the old global index
+	if database.DB.Migrator().HasIndex(&models.Url{}, "idx_urls_short_key") {
+		if err := database.DB.Migrator().DropIndex(&models.Url{}, "idx_urls_short_key"); err != nil {
+			utils.Log.Error("❌ Failed to drop global short key index", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Hosts are only unique among verified domains now, drop the old index
+	// that let the first unverified claim block everybody else
+	if database.DB.Migrator().HasIndex(&models.Domain{}, "idx_domains_host") {
+		if err := database.DB.Migrator().DropIndex(&models.Domain{}, "idx_domains_host"); err != nil {
+			utils.Log.Error("❌ Failed to drop global domain host index", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Numbers behind the keys of KEY_SOURCE=sequence
+	if err := database.DB.Exec("CREATE SEQUENCE IF NOT EXISTS " + keys.SequenceName).Error; err != nil {
+		utils.Log.Error("❌ Failed to create short key sequence", "error", err)
+		os.Exit(1)
+	}
+
 	utils.Log.Info("✅ Database migration completed successfully")
 
 }
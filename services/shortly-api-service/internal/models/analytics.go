@@ -8,14 +8,18 @@ import (
 type Analytics struct {
 	gorm.Model
 
-	UrlID     string    `gorm:"index;not null"`
+	UrlID     string    `gorm:"index;index:idx_analytics_url_clicked_at;not null"`
 	Url       Url       `gorm:"foreignKey:UrlID"`
-	ClickedAt time.Time `gorm:"autoCreateTime"`
+	ClickedAt time.Time `gorm:"autoCreateTime;index:idx_analytics_url_clicked_at"`
 	IPAddress string    `gorm:"not null"`
 	UserAgent string    `gorm:"not null"`
 	Referrer  string    `gorm:"size:255"`
 	Country   string    `gorm:"size:100"`
-	Device    string    `gorm:"size:50"`
-	Browser   string    `gorm:"size:50"`
-	OS        string    `gorm:"size:50"`
+	Region    string    `gorm:"size:100"`
+	City      string    `gorm:"size:100"`
+	ASN       uint
+	ASOrg     string `gorm:"size:255"`
+	Device    string `gorm:"size:50"`
+	Browser   string `gorm:"size:50"`
+	OS        string `gorm:"size:50"`
 }
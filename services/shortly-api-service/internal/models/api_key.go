@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeUrlsRead      = "urls:read"
+	ScopeUrlsWrite     = "urls:write"
+	ScopeAnalyticsRead = "analytics:read"
+	ScopeDomainsRead   = "domains:read"
+	ScopeDomainsWrite  = "domains:write"
+)
+
+// ApiKey is a personal access key for scripts and CI. Only the SHA-256 hash
+// of the key is stored; Prefix is kept so users can tell keys apart.
+type ApiKey struct {
+	gorm.Model
+
+	UserID     uint   `gorm:"index;not null"`
+	Name       string `gorm:"size:100;not null"`
+	Prefix     string `gorm:"size:16;not null"`
+	KeyHash    string `gorm:"size:64;uniqueIndex;not null"`
+	Scopes     string `gorm:"size:255;not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (k ApiKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
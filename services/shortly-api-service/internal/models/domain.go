@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Domain struct {
+	gorm.Model
+
+	// Anyone may claim a host, only one claim per host can be verified
+	Host              string `gorm:"size:255;not null;index:idx_domains_host_claims;uniqueIndex:idx_domains_verified_host,where:verified_at IS NOT NULL"`
+	UserID            string `gorm:"index;not null"`
+	VerificationToken string `gorm:"size:64;not null"`
+	VerifiedAt        *time.Time
+}
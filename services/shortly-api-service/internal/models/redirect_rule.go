@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RedirectRule sends visitors of a link matching every set condition to
+// Destination instead of the link's original URL. Rules of a link are tried
+// by ascending Position, the first match wins. List conditions are stored
+// comma separated and an empty one matches everyone.
+type RedirectRule struct {
+	gorm.Model
+
+	UrlID       uint   `gorm:"index;not null"`
+	Position    int    `gorm:"not null;default:0"`
+	Destination string `gorm:"size:2048;not null"`
+
+	Devices   string `gorm:"size:100"`
+	OS        string `gorm:"size:100"`
+	Countries string `gorm:"size:1000"`
+	Languages string `gorm:"size:500"`
+
+	// Active from StartsAt (inclusive) until EndsAt (exclusive)
+	StartsAt *time.Time
+	EndsAt   *time.Time
+}
+
+func (r RedirectRule) DeviceList() []string {
+	return splitList(r.Devices)
+}
+
+func (r RedirectRule) OSList() []string {
+	return splitList(r.OS)
+}
+
+func (r RedirectRule) CountryList() []string {
+	return splitList(r.Countries)
+}
+
+func (r RedirectRule) LanguageList() []string {
+	return splitList(r.Languages)
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
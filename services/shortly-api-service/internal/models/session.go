@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one signed-in device. The refresh token is rotated on every use
+// and only its SHA-256 hash is stored.
+type Session struct {
+	gorm.Model
+
+	UserID                   uint   `gorm:"index;not null"`
+	RefreshTokenHash         string `gorm:"size:64;uniqueIndex;not null"`
+	PreviousRefreshTokenHash string `gorm:"size:64;index"`
+	AccessTokenID            string `gorm:"size:64"`
+	AccessExpiresAt          time.Time
+	UserAgent                string    `gorm:"size:255"`
+	IPAddress                string    `gorm:"size:45"`
+	ExpiresAt                time.Time `gorm:"index;not null"`
+	RevokedAt                *time.Time
+}
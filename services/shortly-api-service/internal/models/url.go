@@ -1,17 +1,27 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type Url struct {
 	gorm.Model
 
-	OriginalURL string      `gorm:"not null"`
-	ShortKey    string      `gorm:"size:50;uniqueIndex;not null"`
-	Title       string      `gorm:"size:255"`
-	UserID      *string     `gorm:"index"`
-	User        *User       `gorm:"foreignKey:UserID"`
-	Clicks      int         `gorm:"default:0"`
+	OriginalURL string     `gorm:"not null"`
+	ShortKey    string     `gorm:"size:50;uniqueIndex:idx_urls_domain_short_key;not null"`
+	DomainID    uint       `gorm:"not null;default:0;uniqueIndex:idx_urls_domain_short_key"`
+	Title       string     `gorm:"size:255"`
+	UserID      *string    `gorm:"index"`
+	User        *User      `gorm:"foreignKey:UserID"`
+	WorkspaceID *uint      `gorm:"index"`
+	Clicks      int        `gorm:"default:0"`
+	ExpiresAt   *time.Time `gorm:"index"`
+	MaxClicks   *int
+	Password    *string
 	Analytics   []Analytics `gorm:"foreignKey:UrlID"`
+
+	// Loaded for redirects only, and cached with the link
+	RedirectRules []RedirectRule `gorm:"foreignKey:UrlID"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint that receives link events. Like links it belongs to
+// a user's personal space or to a workspace.
+type Webhook struct {
+	gorm.Model
+
+	UserID      uint   `gorm:"index;not null"`
+	WorkspaceID *uint  `gorm:"index"`
+	URL         string `gorm:"size:2048;not null"`
+	Secret      string `gorm:"size:64;not null"`
+	Events      string `gorm:"size:255;not null"`
+	Active      bool   `gorm:"not null;default:true"`
+}
+
+func (w Webhook) EventList() []string {
+	if w.Events == "" {
+		return []string{}
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// WebhookDelivery is both the durable delivery queue and the delivery log:
+// pending rows are picked up by the dispatcher once NextAttemptAt is due.
+type WebhookDelivery struct {
+	gorm.Model
+
+	WebhookID      uint      `gorm:"index;not null"`
+	Webhook        *Webhook  `gorm:"foreignKey:WebhookID"`
+	EventID        string    `gorm:"size:64;not null"`
+	EventType      string    `gorm:"size:50;not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"size:20;not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int
+	LastError      string `gorm:"size:500"`
+	DeliveredAt    *time.Time
+}
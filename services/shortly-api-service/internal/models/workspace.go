@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Workspace shares links between its members. Links created in a workspace
+// belong to it rather than to the member who created them.
+type Workspace struct {
+	gorm.Model
+
+	Name    string            `gorm:"size:100;not null"`
+	Members []WorkspaceMember `gorm:"foreignKey:WorkspaceID"`
+}
+
+type WorkspaceMember struct {
+	gorm.Model
+
+	WorkspaceID uint   `gorm:"not null;uniqueIndex:idx_workspace_members_workspace_user"`
+	UserID      uint   `gorm:"not null;index;uniqueIndex:idx_workspace_members_workspace_user"`
+	User        *User  `gorm:"foreignKey:UserID"`
+	Role        string `gorm:"size:20;not null"`
+}
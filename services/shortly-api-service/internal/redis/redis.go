@@ -4,12 +4,18 @@ import (
 	"context"
 
 	"shortly-api-service/config"
+	"shortly-api-service/internal/metrics"
 	"shortly-api-service/internal/utils"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var RedisClient *redis.Client
+// RedisClient holds the caches (DB 1), RateLimitClient the rate limit
+// counters (DB 2).
+var (
+	RedisClient     *redis.Client
+	RateLimitClient *redis.Client
+)
 
 func ConnectRedis() error {
 
@@ -19,6 +25,8 @@ func ConnectRedis() error {
 		DB:       1,
 	})
 
+	metrics.InstrumentRedis(RedisClient)
+
 	_, err := RedisClient.Ping(context.Background()).Result()
 
 	if err != nil {
@@ -26,8 +34,35 @@ func ConnectRedis() error {
 		return err
 	}
 
+	RateLimitClient = redis.NewClient(&redis.Options{
+		Addr:     config.AppConfig.REDIS_ADDR,
+		Password: "",
+		DB:       2,
+	})
+
+	metrics.InstrumentRedis(RateLimitClient)
+
+	if _, err := RateLimitClient.Ping(context.Background()).Result(); err != nil {
+		utils.Log.Error("❌ Redis rate limit connection failed", "error", err)
+		return err
+	}
+
 	utils.Log.Info("✅ Connected to Redis")
 
 	return nil
 
 }
+
+func CloseRedis() {
+	for _, client := range []*redis.Client{RedisClient, RateLimitClient} {
+		if client == nil {
+			continue
+		}
+
+		if err := client.Close(); err != nil {
+			utils.Log.Warn("Error closing Redis connection", "error", err)
+		}
+	}
+
+	utils.Log.Info("Redis connections closed")
+}
@@ -0,0 +1,1010 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+)
+
+// The in-memory implementations below keep everything in maps guarded by a
+// mutex. They back the handlers when no Postgres or Redis is available, as
+// in tests and local experiments, and mirror the Postgres semantics.
+
+type MemoryUrlRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	urls   map[uint]models.Url
+}
+
+func NewMemoryUrlRepository() *MemoryUrlRepository {
+	return &MemoryUrlRepository{urls: make(map[uint]models.Url)}
+}
+
+func (r *MemoryUrlRepository) Create(ctx context.Context, url *models.Url) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.urls {
+		if existing.DomainID == url.DomainID && existing.ShortKey == url.ShortKey {
+			return ErrDuplicate
+		}
+	}
+
+	r.nextID++
+
+	now := time.Now()
+
+	url.ID = r.nextID
+	url.CreatedAt = now
+	url.UpdatedAt = now
+
+	r.urls[url.ID] = *url
+
+	return nil
+}
+
+func (r *MemoryUrlRepository) FindByShortKey(ctx context.Context, domainID uint, shortKey string) (models.Url, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, url := range r.urls {
+		if url.DomainID == domainID && url.ShortKey == shortKey {
+			return url, nil
+		}
+	}
+
+	return models.Url{}, ErrNotFound
+}
+
+func (r *MemoryUrlRepository) FindInScope(ctx context.Context, scope authz.Scope, filter UrlFilter) (models.Url, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, url := range r.sorted() {
+
+		if !scope.Owns(url) {
+			continue
+		}
+
+		switch {
+		case filter.ID != 0:
+			if url.ID == filter.ID {
+				return url, nil
+			}
+		case filter.ShortKey != "":
+			if url.DomainID == filter.DomainID && url.ShortKey == filter.ShortKey {
+				return url, nil
+			}
+		default:
+			if url.DomainID == filter.DomainID && url.OriginalURL == filter.OriginalURL {
+				return url, nil
+			}
+		}
+	}
+
+	return models.Url{}, ErrNotFound
+}
+
+func (r *MemoryUrlRepository) ListInScope(ctx context.Context, scope authz.Scope) ([]models.Url, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	urls := make([]models.Url, 0)
+
+	for _, url := range r.sorted() {
+		if scope.Owns(url) {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls, nil
+}
+
+func (r *MemoryUrlRepository) Update(ctx context.Context, url *models.Url) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.urls[url.ID]
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, existing := range r.urls {
+		if existing.ID != url.ID && existing.DomainID == stored.DomainID && existing.ShortKey == url.ShortKey {
+			return ErrDuplicate
+		}
+	}
+
+	stored.ShortKey = url.ShortKey
+	stored.Title = url.Title
+	stored.ExpiresAt = url.ExpiresAt
+	stored.MaxClicks = url.MaxClicks
+	stored.Password = url.Password
+	stored.UpdatedAt = time.Now()
+
+	url.UpdatedAt = stored.UpdatedAt
+
+	r.urls[url.ID] = stored
+
+	return nil
+}
+
+func (r *MemoryUrlRepository) Delete(ctx context.Context, url *models.Url) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.urls, url.ID)
+
+	return nil
+}
+
+func (r *MemoryUrlRepository) ExistingShortKeys(ctx context.Context, shortKeys []string) ([]string, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used := make(map[string]bool, len(r.urls))
+
+	for _, url := range r.urls {
+		used[url.ShortKey] = true
+	}
+
+	existing := make([]string, 0)
+
+	for _, shortKey := range shortKeys {
+		if used[shortKey] {
+			existing = append(existing, shortKey)
+			used[shortKey] = false
+		}
+	}
+
+	return existing, nil
+}
+
+func (r *MemoryUrlRepository) ConsumeClick(ctx context.Context, id uint) (bool, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, ok := r.urls[id]
+
+	if !ok || url.MaxClicks == nil || url.Clicks >= *url.MaxClicks {
+		return false, nil
+	}
+
+	url.Clicks++
+	r.urls[id] = url
+
+	return true, nil
+}
+
+func (r *MemoryUrlRepository) CreateMany(ctx context.Context, urls []models.Url) ([]bool, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := make([]bool, len(urls))
+
+	now := time.Now()
+
+	for i := range urls {
+
+		taken := false
+
+		for _, existing := range r.urls {
+			if existing.DomainID == urls[i].DomainID && existing.ShortKey == urls[i].ShortKey {
+				taken = true
+				break
+			}
+		}
+
+		// Skipped like the Postgres ON CONFLICT DO NOTHING
+		if taken {
+			continue
+		}
+
+		r.nextID++
+
+		urls[i].ID = r.nextID
+		urls[i].CreatedAt = now
+		urls[i].UpdatedAt = now
+
+		r.urls[urls[i].ID] = urls[i]
+		created[i] = true
+	}
+
+	return created, nil
+}
+
+func (r *MemoryUrlRepository) ListShortened(ctx context.Context, scope authz.Scope, originalUrls []string) ([]models.Url, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	urls := make([]models.Url, 0)
+
+	for _, url := range r.sorted() {
+		if scope.Owns(url) && slices.Contains(originalUrls, url.OriginalURL) {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls, nil
+}
+
+func (r *MemoryUrlRepository) TakenShortKeys(ctx context.Context, keys []DomainShortKey) ([]DomainShortKey, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	taken := make([]DomainShortKey, 0)
+
+	for _, url := range r.sorted() {
+		key := DomainShortKey{DomainID: url.DomainID, ShortKey: url.ShortKey}
+		if slices.Contains(keys, key) {
+			taken = append(taken, key)
+		}
+	}
+
+	return taken, nil
+}
+
+func (r *MemoryUrlRepository) CountOnDomain(ctx context.Context, domainID uint) (int64, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+
+	for _, url := range r.urls {
+		if url.DomainID == domainID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// byID finds a link whoever owns it.
+func (r *MemoryUrlRepository) byID(id uint) (models.Url, bool) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	url, ok := r.urls[id]
+
+	return url, ok
+}
+
+// sorted returns the links in insertion order, like an unordered Postgres
+// scan usually does. The caller holds the lock.
+func (r *MemoryUrlRepository) sorted() []models.Url {
+
+	urls := make([]models.Url, 0, len(r.urls))
+
+	for _, url := range r.urls {
+		urls = append(urls, url)
+	}
+
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].ID < urls[j].ID
+	})
+
+	return urls
+}
+
+type MemoryUserRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	users  map[string]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]models.User)}
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+
+	return models.User{}, ErrNotFound
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[email]
+
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.Email]; ok {
+		return ErrDuplicate
+	}
+
+	r.nextID++
+
+	now := time.Now()
+
+	user.ID = r.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	r.users[user.Email] = *user
+
+	return nil
+}
+
+func (r *MemoryUserRepository) Save(ctx context.Context, user *models.User) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.UpdatedAt = time.Now()
+
+	r.users[user.Email] = *user
+
+	return nil
+}
+
+type MemoryAnalyticsRepository struct {
+	mu     sync.Mutex
+	events []models.Analytics
+}
+
+func NewMemoryAnalyticsRepository() *MemoryAnalyticsRepository {
+	return &MemoryAnalyticsRepository{}
+}
+
+// Record stores click events, standing in for the click pipeline.
+func (r *MemoryAnalyticsRepository) Record(events ...models.Analytics) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, events...)
+}
+
+// RecordClicks only keeps the events, click counts live in the link
+// repository.
+func (r *MemoryAnalyticsRepository) RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error {
+	r.Record(events...)
+	return nil
+}
+
+func (r *MemoryAnalyticsRepository) List(ctx context.Context, urlID uint, from, to time.Time, offset, limit int) ([]models.Analytics, int64, error) {
+
+	events := r.inRange(urlID, from, to)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ClickedAt.After(events[j].ClickedAt)
+	})
+
+	total := int64(len(events))
+
+	if offset >= len(events) {
+		return []models.Analytics{}, total, nil
+	}
+
+	events = events[offset:]
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, total, nil
+}
+
+func (r *MemoryAnalyticsRepository) Timeseries(ctx context.Context, urlID uint, from, to time.Time, interval string) ([]TimeBucket, error) {
+
+	var step time.Duration
+
+	switch interval {
+	case "day":
+		step = 24 * time.Hour
+	case "hour":
+		step = time.Hour
+	default:
+		return nil, errors.New("unknown analytics interval " + interval)
+	}
+
+	counts := make(map[time.Time]int64)
+
+	for _, event := range r.inRange(urlID, from, to) {
+		counts[event.ClickedAt.UTC().Truncate(step)]++
+	}
+
+	buckets := make([]TimeBucket, 0, len(counts))
+
+	for bucket, clicks := range counts {
+		buckets = append(buckets, TimeBucket{Bucket: bucket, Clicks: clicks})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Bucket.Before(buckets[j].Bucket)
+	})
+
+	return buckets, nil
+}
+
+func (r *MemoryAnalyticsRepository) Breakdown(ctx context.Context, urlID uint, from, to time.Time, dimension string, limit int) ([]BreakdownItem, int64, error) {
+
+	value, ok := analyticsDimensionValues[dimension]
+
+	if !ok {
+		return nil, 0, errors.New("unknown analytics dimension " + dimension)
+	}
+
+	events := r.inRange(urlID, from, to)
+	counts := make(map[string]int64)
+
+	for _, event := range events {
+		counts[value(event)]++
+	}
+
+	items := make([]BreakdownItem, 0, len(counts))
+
+	for value, clicks := range counts {
+		items = append(items, BreakdownItem{Value: value, Clicks: clicks})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Clicks != items[j].Clicks {
+			return items[i].Clicks > items[j].Clicks
+		}
+		return items[i].Value < items[j].Value
+	})
+
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, int64(len(events)), nil
+}
+
+func (r *MemoryAnalyticsRepository) inRange(urlID uint, from, to time.Time) []models.Analytics {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := strconv.FormatUint(uint64(urlID), 10)
+	events := make([]models.Analytics, 0)
+
+	for _, event := range r.events {
+		if event.UrlID == id && !event.ClickedAt.Before(from) && event.ClickedAt.Before(to) {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// analyticsDimensionValues mirrors analyticsDimensionColumns in Go.
+var analyticsDimensionValues = map[string]func(event models.Analytics) string{
+	"country": func(event models.Analytics) string { return orDefault(event.Country, "Unknown") },
+	"region":  func(event models.Analytics) string { return orDefault(event.Region, "Unknown") },
+	"city":    func(event models.Analytics) string { return orDefault(event.City, "Unknown") },
+	"device":  func(event models.Analytics) string { return orDefault(event.Device, "Unknown") },
+	"browser": func(event models.Analytics) string { return orDefault(event.Browser, "Unknown") },
+	"os":      func(event models.Analytics) string { return orDefault(event.OS, "Unknown") },
+	"referrer": func(event models.Analytics) string {
+
+		parsed, err := neturl.Parse(event.Referrer)
+
+		if err != nil || parsed.Scheme == "" {
+			return "Direct"
+		}
+
+		return orDefault(strings.ToLower(parsed.Hostname()), "Direct")
+	},
+}
+
+func orDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+type MemoryDomainRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	domains map[uint]models.Domain
+}
+
+func NewMemoryDomainRepository() *MemoryDomainRepository {
+	return &MemoryDomainRepository{domains: make(map[uint]models.Domain)}
+}
+
+// Add stores a domain as is, its ID must be set.
+func (r *MemoryDomainRepository) Add(domain models.Domain) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.domains[domain.ID] = domain
+	r.nextID = max(r.nextID, domain.ID)
+}
+
+func (r *MemoryDomainRepository) Create(ctx context.Context, domain *models.Domain) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+
+	now := time.Now()
+
+	domain.ID = r.nextID
+	domain.CreatedAt = now
+	domain.UpdatedAt = now
+
+	r.domains[domain.ID] = *domain
+
+	return nil
+}
+
+func (r *MemoryDomainRepository) FindClaim(ctx context.Context, userID string, host string) (models.Domain, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, domain := range r.domains {
+		if domain.Host == host && domain.UserID == userID {
+			return domain, nil
+		}
+	}
+
+	return models.Domain{}, ErrNotFound
+}
+
+func (r *MemoryDomainRepository) FindForUser(ctx context.Context, userID string, id uint) (models.Domain, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	domain, ok := r.domains[id]
+
+	if !ok || domain.UserID != userID {
+		return models.Domain{}, ErrNotFound
+	}
+
+	return domain, nil
+}
+
+func (r *MemoryDomainRepository) ListForUser(ctx context.Context, userID string) ([]models.Domain, error) {
+	return r.list(func(domain models.Domain) bool {
+		return domain.UserID == userID
+	}), nil
+}
+
+func (r *MemoryDomainRepository) ListVerifiedForUser(ctx context.Context, userID string) ([]models.Domain, error) {
+	return r.list(func(domain models.Domain) bool {
+		return domain.UserID == userID && domain.VerifiedAt != nil
+	}), nil
+}
+
+func (r *MemoryDomainRepository) MarkVerified(ctx context.Context, domain *models.Domain, verifiedAt time.Time) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.domains[domain.ID]
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	for id, other := range r.domains {
+
+		if id == stored.ID || other.Host != stored.Host {
+			continue
+		}
+
+		if other.VerifiedAt != nil {
+			return ErrDuplicate
+		}
+	}
+
+	for id, other := range r.domains {
+		if id != stored.ID && other.Host == stored.Host {
+			delete(r.domains, id)
+		}
+	}
+
+	stored.VerifiedAt = &verifiedAt
+	r.domains[domain.ID] = stored
+
+	domain.VerifiedAt = &verifiedAt
+
+	return nil
+}
+
+func (r *MemoryDomainRepository) Delete(ctx context.Context, domain *models.Domain) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.domains, domain.ID)
+
+	return nil
+}
+
+func (r *MemoryDomainRepository) FindVerified(ctx context.Context, host string) (models.Domain, error) {
+	return r.find(func(domain models.Domain) bool {
+		return domain.Host == host
+	})
+}
+
+func (r *MemoryDomainRepository) FindVerifiedForUser(ctx context.Context, userID string, host string) (models.Domain, error) {
+	return r.find(func(domain models.Domain) bool {
+		return domain.Host == host && domain.UserID == userID
+	})
+}
+
+func (r *MemoryDomainRepository) Hosts(ctx context.Context, ids []uint) (map[uint]string, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hosts := make(map[uint]string, len(ids))
+
+	for _, id := range ids {
+		if domain, ok := r.domains[id]; ok {
+			hosts[id] = domain.Host
+		}
+	}
+
+	return hosts, nil
+}
+
+// list returns the matching domains, newest first.
+func (r *MemoryDomainRepository) list(match func(domain models.Domain) bool) []models.Domain {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	domains := make([]models.Domain, 0)
+
+	for _, domain := range r.domains {
+		if match(domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].ID > domains[j].ID
+	})
+
+	return domains
+}
+
+func (r *MemoryDomainRepository) find(match func(domain models.Domain) bool) (models.Domain, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, domain := range r.domains {
+		if domain.VerifiedAt != nil && match(domain) {
+			return domain, nil
+		}
+	}
+
+	return models.Domain{}, ErrNotFound
+}
+
+type MemoryRedirectRuleRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	rules  map[uint]models.RedirectRule
+}
+
+func NewMemoryRedirectRuleRepository() *MemoryRedirectRuleRepository {
+	return &MemoryRedirectRuleRepository{rules: make(map[uint]models.RedirectRule)}
+}
+
+func (r *MemoryRedirectRuleRepository) List(ctx context.Context, urlID uint) ([]models.RedirectRule, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]models.RedirectRule, 0)
+
+	for _, rule := range r.rules {
+		if rule.UrlID == urlID {
+			rules = append(rules, rule)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Position != rules[j].Position {
+			return rules[i].Position < rules[j].Position
+		}
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules, nil
+}
+
+func (r *MemoryRedirectRuleRepository) Find(ctx context.Context, urlID uint, id uint) (models.RedirectRule, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+
+	if !ok || rule.UrlID != urlID {
+		return models.RedirectRule{}, ErrNotFound
+	}
+
+	return rule, nil
+}
+
+func (r *MemoryRedirectRuleRepository) Create(ctx context.Context, rule *models.RedirectRule) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+
+	now := time.Now()
+
+	rule.ID = r.nextID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	r.rules[rule.ID] = *rule
+
+	return nil
+}
+
+func (r *MemoryRedirectRuleRepository) Update(ctx context.Context, rule *models.RedirectRule) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.rules[rule.ID]
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.Position = rule.Position
+	stored.Destination = rule.Destination
+	stored.Devices = rule.Devices
+	stored.OS = rule.OS
+	stored.Countries = rule.Countries
+	stored.Languages = rule.Languages
+	stored.StartsAt = rule.StartsAt
+	stored.EndsAt = rule.EndsAt
+	stored.UpdatedAt = time.Now()
+
+	rule.UpdatedAt = stored.UpdatedAt
+
+	r.rules[rule.ID] = stored
+
+	return nil
+}
+
+func (r *MemoryRedirectRuleRepository) Delete(ctx context.Context, rule *models.RedirectRule) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.rules, rule.ID)
+
+	return nil
+}
+
+type memoryEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// memoryStore is a map with per-entry expiry, standing in for Redis.
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+func newMemoryStore() memoryStore {
+	return memoryStore{entries: make(map[string]memoryEntry)}
+}
+
+type MemoryLinkCache struct {
+	memoryStore
+}
+
+func NewMemoryLinkCache() *MemoryLinkCache {
+	return &MemoryLinkCache{memoryStore: newMemoryStore()}
+}
+
+func (c *MemoryLinkCache) GetUrl(ctx context.Context, domainID uint, shortKey string) (models.Url, bool) {
+
+	value, ok := c.get(UrlCacheKey(domainID, shortKey))
+
+	if !ok {
+		return models.Url{}, false
+	}
+
+	return value.(models.Url), true
+}
+
+func (c *MemoryLinkCache) SetUrl(ctx context.Context, url models.Url, ttl time.Duration) error {
+	c.set(UrlCacheKey(url.DomainID, url.ShortKey), url, ttl)
+	return nil
+}
+
+func (c *MemoryLinkCache) DeleteUrl(ctx context.Context, domainID uint, shortKey string) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, UrlCacheKey(domainID, shortKey))
+
+	prefix := QRCacheKey(domainID, shortKey) + "|"
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+
+	return nil
+}
+
+func (c *MemoryLinkCache) GetDomainID(ctx context.Context, host string) (uint, bool) {
+
+	value, ok := c.get(DomainCacheKey(host))
+
+	if !ok {
+		return 0, false
+	}
+
+	return value.(uint), true
+}
+
+func (c *MemoryLinkCache) SetDomainID(ctx context.Context, host string, domainID uint, ttl time.Duration) error {
+	c.set(DomainCacheKey(host), domainID, ttl)
+	return nil
+}
+
+func (c *MemoryLinkCache) DeleteDomainID(ctx context.Context, host string) error {
+	c.delete(DomainCacheKey(host))
+	return nil
+}
+
+func (c *MemoryLinkCache) GetQRCode(ctx context.Context, domainID uint, shortKey string, variant string) ([]byte, bool) {
+
+	value, ok := c.get(QRCacheKey(domainID, shortKey) + "|" + variant)
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.([]byte), true
+}
+
+func (c *MemoryLinkCache) SetQRCode(ctx context.Context, domainID uint, shortKey string, variant string, image []byte, ttl time.Duration) error {
+	c.set(QRCacheKey(domainID, shortKey)+"|"+variant, image, ttl)
+	return nil
+}
+
+type MemoryProfileCache struct {
+	memoryStore
+}
+
+func NewMemoryProfileCache() *MemoryProfileCache {
+	return &MemoryProfileCache{memoryStore: newMemoryStore()}
+}
+
+func (c *MemoryProfileCache) GetProfile(ctx context.Context, email string) ([]byte, bool) {
+
+	value, ok := c.get(ProfileCacheKey(email))
+
+	if !ok {
+		return nil, false
+	}
+
+	return value.([]byte), true
+}
+
+func (c *MemoryProfileCache) SetProfile(ctx context.Context, email string, profile []byte, ttl time.Duration) error {
+	c.set(ProfileCacheKey(email), profile, ttl)
+	return nil
+}
+
+type MemoryTokenDenylist struct {
+	memoryStore
+}
+
+func NewMemoryTokenDenylist() *MemoryTokenDenylist {
+	return &MemoryTokenDenylist{memoryStore: newMemoryStore()}
+}
+
+func (d *MemoryTokenDenylist) Add(ctx context.Context, jti string, expiresAt time.Time) error {
+
+	ttl := time.Until(expiresAt)
+
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+
+	d.set(TokenDenylistKey(jti), true, ttl)
+
+	return nil
+}
+
+func (d *MemoryTokenDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	_, ok := d.get(TokenDenylistKey(jti))
+	return ok, nil
+}
+
+func (c *memoryStore) get(key string) (interface{}, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *memoryStore) set(key string, value interface{}, ttl time.Duration) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *memoryStore) delete(key string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
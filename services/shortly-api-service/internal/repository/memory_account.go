@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/models"
+)
+
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	nextID   uint
+	sessions map[uint]models.Session
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[uint]models.Session)}
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, session *models.Session, issue func(session *models.Session) error) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.sessions {
+		if existing.RefreshTokenHash == session.RefreshTokenHash {
+			return ErrDuplicate
+		}
+	}
+
+	now := time.Now()
+
+	session.ID = r.nextID + 1
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	// Nothing is stored when issuing fails, like a rolled back transaction
+	if err := issue(session); err != nil {
+		return err
+	}
+
+	r.nextID++
+	r.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (r *MemorySessionRepository) FindByRefreshHash(ctx context.Context, hash string) (models.Session, error) {
+	return r.find(func(session models.Session) bool {
+		return session.RefreshTokenHash == hash
+	})
+}
+
+func (r *MemorySessionRepository) FindByPreviousRefreshHash(ctx context.Context, hash string) (models.Session, error) {
+	return r.find(func(session models.Session) bool {
+		return session.PreviousRefreshTokenHash == hash && session.RevokedAt == nil
+	})
+}
+
+func (r *MemorySessionRepository) FindActive(ctx context.Context, id uint) (models.Session, error) {
+	return r.find(func(session models.Session) bool {
+		return session.ID == id && session.RevokedAt == nil
+	})
+}
+
+func (r *MemorySessionRepository) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]models.Session, 0)
+
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) Rotate(ctx context.Context, session models.Session, previousHash string) (bool, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.sessions[session.ID]
+
+	if !ok || stored.RefreshTokenHash != previousHash || stored.RevokedAt != nil {
+		return false, nil
+	}
+
+	stored.RefreshTokenHash = session.RefreshTokenHash
+	stored.PreviousRefreshTokenHash = previousHash
+	stored.AccessTokenID = session.AccessTokenID
+	stored.AccessExpiresAt = session.AccessExpiresAt
+	stored.UserAgent = session.UserAgent
+	stored.IPAddress = session.IPAddress
+	stored.UpdatedAt = time.Now()
+
+	r.sessions[session.ID] = stored
+
+	return true, nil
+}
+
+func (r *MemorySessionRepository) Revoke(ctx context.Context, ids []uint) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for _, id := range ids {
+		if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			r.sessions[id] = session
+		}
+	}
+
+	return nil
+}
+
+func (r *MemorySessionRepository) find(match func(session models.Session) bool) (models.Session, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if match(session) {
+			return session, nil
+		}
+	}
+
+	return models.Session{}, ErrNotFound
+}
+
+type MemoryApiKeyRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	apiKeys map[uint]models.ApiKey
+}
+
+func NewMemoryApiKeyRepository() *MemoryApiKeyRepository {
+	return &MemoryApiKeyRepository{apiKeys: make(map[uint]models.ApiKey)}
+}
+
+func (r *MemoryApiKeyRepository) Create(ctx context.Context, apiKey *models.ApiKey) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.apiKeys {
+		if existing.KeyHash == apiKey.KeyHash {
+			return ErrDuplicate
+		}
+	}
+
+	r.nextID++
+
+	now := time.Now()
+
+	apiKey.ID = r.nextID
+	apiKey.CreatedAt = now
+	apiKey.UpdatedAt = now
+
+	r.apiKeys[apiKey.ID] = *apiKey
+
+	return nil
+}
+
+func (r *MemoryApiKeyRepository) FindByHash(ctx context.Context, hash string) (models.ApiKey, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, apiKey := range r.apiKeys {
+		if apiKey.KeyHash == hash {
+			return apiKey, nil
+		}
+	}
+
+	return models.ApiKey{}, ErrNotFound
+}
+
+func (r *MemoryApiKeyRepository) CountActive(ctx context.Context, userID uint) (int64, error) {
+
+	apiKeys, err := r.ListActive(ctx, userID)
+
+	return int64(len(apiKeys)), err
+}
+
+func (r *MemoryApiKeyRepository) ListActive(ctx context.Context, userID uint) ([]models.ApiKey, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKeys := make([]models.ApiKey, 0)
+
+	for _, apiKey := range r.apiKeys {
+		if apiKey.UserID == userID && apiKey.RevokedAt == nil {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].ID > apiKeys[j].ID
+	})
+
+	return apiKeys, nil
+}
+
+func (r *MemoryApiKeyRepository) Revoke(ctx context.Context, userID uint, id uint) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.apiKeys[id]
+
+	if !ok || apiKey.UserID != userID || apiKey.RevokedAt != nil {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+
+	r.apiKeys[id] = apiKey
+
+	return nil
+}
+
+func (r *MemoryApiKeyRepository) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if apiKey, ok := r.apiKeys[id]; ok {
+		apiKey.LastUsedAt = &usedAt
+		r.apiKeys[id] = apiKey
+	}
+
+	return nil
+}
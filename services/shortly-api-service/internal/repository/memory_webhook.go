@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+)
+
+// MemoryWebhookRepository reads links from urls to resolve click
+// subscriptions, the way the Postgres version joins them.
+type MemoryWebhookRepository struct {
+	mu             sync.Mutex
+	nextID         uint
+	nextDeliveryID uint
+	hooks          map[uint]models.Webhook
+	deliveries     []models.WebhookDelivery
+	urls           *MemoryUrlRepository
+}
+
+func NewMemoryWebhookRepository(urls *MemoryUrlRepository) *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		hooks: make(map[uint]models.Webhook),
+		urls:  urls,
+	}
+}
+
+func (r *MemoryWebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+
+	now := time.Now()
+
+	hook.ID = r.nextID
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+
+	r.hooks[hook.ID] = *hook
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) CountInScope(ctx context.Context, scope authz.Scope) (int64, error) {
+
+	hooks, err := r.ListInScope(ctx, scope)
+
+	return int64(len(hooks)), err
+}
+
+func (r *MemoryWebhookRepository) ListInScope(ctx context.Context, scope authz.Scope) ([]models.Webhook, error) {
+	return r.list(scope.OwnsWebhook), nil
+}
+
+func (r *MemoryWebhookRepository) FindInScope(ctx context.Context, scope authz.Scope, id uint) (models.Webhook, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hook, ok := r.hooks[id]
+
+	if !ok || !scope.OwnsWebhook(hook) {
+		return models.Webhook{}, ErrNotFound
+	}
+
+	return hook, nil
+}
+
+func (r *MemoryWebhookRepository) Delete(ctx context.Context, hook *models.Webhook) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.hooks, hook.ID)
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) Deliveries(ctx context.Context, webhookID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	// Newest first
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		delivery := r.deliveries[i]
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	total := int64(len(deliveries))
+
+	if offset >= len(deliveries) {
+		return []models.WebhookDelivery{}, total, nil
+	}
+
+	deliveries = deliveries[offset:]
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *MemoryWebhookRepository) Subscribers(ctx context.Context, eventType string, url models.Url) ([]models.Webhook, error) {
+	return r.list(func(hook models.Webhook) bool {
+		return subscribed(hook, eventType, url)
+	}), nil
+}
+
+func (r *MemoryWebhookRepository) ClickSubscriptions(ctx context.Context, eventType string, urlIDs []uint) ([]ClickSubscription, error) {
+
+	subscriptions := make([]ClickSubscription, 0)
+
+	for _, urlID := range urlIDs {
+
+		url, ok := r.urls.byID(urlID)
+
+		if !ok {
+			continue
+		}
+
+		hooks, _ := r.Subscribers(ctx, eventType, url)
+
+		for _, hook := range hooks {
+			subscriptions = append(subscriptions, ClickSubscription{UrlID: url.ID, ShortKey: url.ShortKey, WebhookID: hook.ID})
+		}
+	}
+
+	return subscriptions, nil
+}
+
+func (r *MemoryWebhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for i := range deliveries {
+		r.nextDeliveryID++
+
+		deliveries[i].ID = r.nextDeliveryID
+		deliveries[i].CreatedAt = now
+		deliveries[i].UpdatedAt = now
+
+		r.deliveries = append(r.deliveries, deliveries[i])
+	}
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	due := make([]int, 0)
+
+	for i, delivery := range r.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return r.deliveries[due[i]].NextAttemptAt.Before(r.deliveries[due[j]].NextAttemptAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]models.WebhookDelivery, 0, len(due))
+
+	for _, i := range due {
+
+		r.deliveries[i].NextAttemptAt = now.Add(lease)
+
+		delivery := r.deliveries[i]
+
+		if hook, ok := r.hooks[delivery.WebhookID]; ok {
+			delivery.Webhook = &hook
+		}
+
+		claimed = append(claimed, delivery)
+	}
+
+	return claimed, nil
+}
+
+func (r *MemoryWebhookRepository) FinishDelivery(ctx context.Context, id uint, outcome DeliveryOutcome) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deliveries {
+
+		delivery := &r.deliveries[i]
+
+		if delivery.ID != id {
+			continue
+		}
+
+		delivery.Attempts = outcome.Attempts
+		delivery.LastStatusCode = outcome.StatusCode
+		delivery.LastError = outcome.Error
+		delivery.UpdatedAt = time.Now()
+
+		if outcome.Status != "" {
+			delivery.Status = outcome.Status
+		}
+
+		if !outcome.NextAttemptAt.IsZero() {
+			delivery.NextAttemptAt = outcome.NextAttemptAt
+		}
+
+		if outcome.DeliveredAt != nil {
+			delivery.DeliveredAt = outcome.DeliveredAt
+		}
+
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// list returns the matching webhooks, newest first.
+func (r *MemoryWebhookRepository) list(match func(hook models.Webhook) bool) []models.Webhook {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hooks := make([]models.Webhook, 0)
+
+	for _, hook := range r.hooks {
+		if match(hook) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].ID > hooks[j].ID
+	})
+
+	return hooks
+}
+
+// subscribed mirrors the ownership rules of links: workspace webhooks get the
+// events of workspace links, personal webhooks those of their user's links.
+func subscribed(hook models.Webhook, eventType string, url models.Url) bool {
+
+	if !hook.Active || !slices.Contains(hook.EventList(), eventType) {
+		return false
+	}
+
+	if url.WorkspaceID != nil {
+		return hook.WorkspaceID != nil && *hook.WorkspaceID == *url.WorkspaceID
+	}
+
+	return hook.WorkspaceID == nil && url.UserID != nil && *url.UserID == strconv.FormatUint(uint64(hook.UserID), 10)
+}
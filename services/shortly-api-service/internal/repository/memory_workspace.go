@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+)
+
+// MemoryWorkspaceRepository reads member users from users, the way the
+// Postgres version preloads them.
+type MemoryWorkspaceRepository struct {
+	mu         sync.Mutex
+	nextID     uint
+	workspaces map[uint]models.Workspace
+	members    []models.WorkspaceMember
+	users      UserRepository
+}
+
+func NewMemoryWorkspaceRepository(users UserRepository) *MemoryWorkspaceRepository {
+	return &MemoryWorkspaceRepository{
+		workspaces: make(map[uint]models.Workspace),
+		users:      users,
+	}
+}
+
+func (r *MemoryWorkspaceRepository) Create(ctx context.Context, workspace *models.Workspace, ownerID uint) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+
+	now := time.Now()
+
+	workspace.ID = r.nextID
+	workspace.CreatedAt = now
+	workspace.UpdatedAt = now
+
+	r.workspaces[workspace.ID] = *workspace
+
+	r.members = append(r.members, models.WorkspaceMember{
+		Model:       workspace.Model,
+		WorkspaceID: workspace.ID,
+		UserID:      ownerID,
+		Role:        models.RoleOwner,
+	})
+
+	return nil
+}
+
+func (r *MemoryWorkspaceRepository) ListForUser(ctx context.Context, userID uint) ([]WorkspaceMembership, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memberships := make([]WorkspaceMembership, 0)
+
+	for _, member := range r.members {
+		if member.UserID == userID {
+			workspace := r.workspaces[member.WorkspaceID]
+			memberships = append(memberships, WorkspaceMembership{
+				ID:        workspace.ID,
+				Name:      workspace.Name,
+				Role:      member.Role,
+				CreatedAt: workspace.CreatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(memberships, func(i, j int) bool {
+		return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+	})
+
+	return memberships, nil
+}
+
+func (r *MemoryWorkspaceRepository) MemberRole(ctx context.Context, workspaceID uint, userID uint) (string, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.indexOf(workspaceID, userID); i >= 0 {
+		return r.members[i].Role, nil
+	}
+
+	return "", authz.ErrNotMember
+}
+
+func (r *MemoryWorkspaceRepository) Members(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error) {
+
+	r.mu.Lock()
+
+	members := make([]models.WorkspaceMember, 0)
+
+	for _, member := range r.members {
+		if member.WorkspaceID == workspaceID {
+			members = append(members, member)
+		}
+	}
+
+	r.mu.Unlock()
+
+	for i := range members {
+		if user, err := r.users.FindByID(ctx, members[i].UserID); err == nil {
+			members[i].User = &user
+		}
+	}
+
+	return members, nil
+}
+
+func (r *MemoryWorkspaceRepository) AddMember(ctx context.Context, member *models.WorkspaceMember) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(member.WorkspaceID, member.UserID) >= 0 {
+		return ErrDuplicate
+	}
+
+	now := time.Now()
+
+	member.CreatedAt = now
+	member.UpdatedAt = now
+
+	stored := *member
+	stored.User = nil
+
+	r.members = append(r.members, stored)
+
+	return nil
+}
+
+func (r *MemoryWorkspaceRepository) UpdateRole(ctx context.Context, workspaceID uint, userID uint, role string) (models.WorkspaceMember, error) {
+
+	r.mu.Lock()
+
+	i := r.indexOf(workspaceID, userID)
+
+	if i < 0 {
+		r.mu.Unlock()
+		return models.WorkspaceMember{}, ErrNotFound
+	}
+
+	if r.members[i].Role == models.RoleOwner && role != models.RoleOwner && r.owners(workspaceID) <= 1 {
+		r.mu.Unlock()
+		return models.WorkspaceMember{}, ErrLastOwner
+	}
+
+	r.members[i].Role = role
+	member := r.members[i]
+
+	r.mu.Unlock()
+
+	if user, err := r.users.FindByID(ctx, userID); err == nil {
+		member.User = &user
+	}
+
+	return member, nil
+}
+
+func (r *MemoryWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID uint, userID uint) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(workspaceID, userID)
+
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	if r.members[i].Role == models.RoleOwner && r.owners(workspaceID) <= 1 {
+		return ErrLastOwner
+	}
+
+	r.members = append(r.members[:i], r.members[i+1:]...)
+
+	return nil
+}
+
+// indexOf returns the position of the membership, -1 when there is none.
+// The caller holds the lock.
+func (r *MemoryWorkspaceRepository) indexOf(workspaceID uint, userID uint) int {
+	for i, member := range r.members {
+		if member.WorkspaceID == workspaceID && member.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// owners counts the owners of a workspace. The caller holds the lock.
+func (r *MemoryWorkspaceRepository) owners(workspaceID uint) int {
+
+	count := 0
+
+	for _, member := range r.members {
+		if member.WorkspaceID == workspaceID && member.Role == models.RoleOwner {
+			count++
+		}
+	}
+
+	return count
+}
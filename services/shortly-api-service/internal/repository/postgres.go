@@ -0,0 +1,507 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgreSQL error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// SQL expression each breakdown dimension groups by
+var analyticsDimensionColumns = map[string]string{
+	"country":  "COALESCE(NULLIF(country, ''), 'Unknown')",
+	"region":   "COALESCE(NULLIF(region, ''), 'Unknown')",
+	"city":     "COALESCE(NULLIF(city, ''), 'Unknown')",
+	"device":   "COALESCE(NULLIF(device, ''), 'Unknown')",
+	"browser":  "COALESCE(NULLIF(browser, ''), 'Unknown')",
+	"os":       "COALESCE(NULLIF(os, ''), 'Unknown')",
+	"referrer": "COALESCE(NULLIF(lower(substring(referrer from '^[a-zA-Z][a-zA-Z0-9+.-]*://([^/:?#]+)')), ''), 'Direct')",
+}
+
+type PostgresUrlRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresUrlRepository(db *gorm.DB) *PostgresUrlRepository {
+	return &PostgresUrlRepository{db: db}
+}
+
+func (r *PostgresUrlRepository) Create(ctx context.Context, url *models.Url) error {
+	return r.db.WithContext(ctx).Create(url).Error
+}
+
+func (r *PostgresUrlRepository) FindByShortKey(ctx context.Context, domainID uint, shortKey string) (models.Url, error) {
+
+	var url models.Url
+
+	err := r.db.WithContext(ctx).Where("short_key = ? AND domain_id = ?", shortKey, domainID).First(&url).Error
+
+	return url, notFound(err)
+}
+
+func (r *PostgresUrlRepository) FindInScope(ctx context.Context, scope authz.Scope, filter UrlFilter) (models.Url, error) {
+
+	var url models.Url
+
+	query := scope.Urls(r.db.WithContext(ctx))
+
+	switch {
+	case filter.ID != 0:
+		query = query.Where("id = ?", filter.ID)
+	case filter.ShortKey != "":
+		query = query.Where("short_key = ? AND domain_id = ?", filter.ShortKey, filter.DomainID)
+	default:
+		query = query.Where("original_url = ? AND domain_id = ?", filter.OriginalURL, filter.DomainID)
+	}
+
+	err := query.First(&url).Error
+
+	return url, notFound(err)
+}
+
+func (r *PostgresUrlRepository) ListInScope(ctx context.Context, scope authz.Scope) ([]models.Url, error) {
+
+	var urls []models.Url
+
+	err := scope.Urls(r.db.WithContext(ctx)).Find(&urls).Error
+
+	return urls, err
+}
+
+func (r *PostgresUrlRepository) Update(ctx context.Context, url *models.Url) error {
+	return r.db.WithContext(ctx).Model(url).Select("ShortKey", "Title", "ExpiresAt", "MaxClicks", "Password").Updates(url).Error
+}
+
+func (r *PostgresUrlRepository) Delete(ctx context.Context, url *models.Url) error {
+	return r.db.WithContext(ctx).Delete(url).Error
+}
+
+func (r *PostgresUrlRepository) ExistingShortKeys(ctx context.Context, shortKeys []string) ([]string, error) {
+
+	existing := make([]string, 0)
+
+	if len(shortKeys) == 0 {
+		return existing, nil
+	}
+
+	// Soft deleted links still hold their key in the unique index
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Url{}).
+		Where("short_key IN ?", shortKeys).
+		Distinct().
+		Pluck("short_key", &existing).Error
+
+	return existing, err
+}
+
+func (r *PostgresUrlRepository) ConsumeClick(ctx context.Context, id uint) (bool, error) {
+
+	result := r.db.WithContext(ctx).Model(&models.Url{}).
+		Where("id = ? AND clicks < max_clicks", id).
+		UpdateColumn("clicks", gorm.Expr("clicks + ?", 1))
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *PostgresUrlRepository) CreateMany(ctx context.Context, urls []models.Url) ([]bool, error) {
+
+	created := make([]bool, len(urls))
+
+	if len(urls) == 0 {
+		return created, nil
+	}
+
+	// One insert per link so a taken key only costs that link, which a
+	// multi-row insert can't report row by row
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		for i := range urls {
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&urls[i])
+
+			if result.Error != nil {
+				return result.Error
+			}
+
+			created[i] = result.RowsAffected > 0
+		}
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *PostgresUrlRepository) ListShortened(ctx context.Context, scope authz.Scope, originalUrls []string) ([]models.Url, error) {
+
+	urls := make([]models.Url, 0)
+
+	if len(originalUrls) == 0 {
+		return urls, nil
+	}
+
+	err := scope.Urls(r.db.WithContext(ctx).Select("original_url", "domain_id")).
+		Where("original_url IN ?", originalUrls).
+		Find(&urls).Error
+
+	return urls, err
+}
+
+func (r *PostgresUrlRepository) TakenShortKeys(ctx context.Context, keys []DomainShortKey) ([]DomainShortKey, error) {
+
+	taken := make([]DomainShortKey, 0)
+
+	if len(keys) == 0 {
+		return taken, nil
+	}
+
+	pairs := make([][]interface{}, 0, len(keys))
+
+	for _, key := range keys {
+		pairs = append(pairs, []interface{}{key.DomainID, key.ShortKey})
+	}
+
+	var urls []models.Url
+
+	if err := r.db.WithContext(ctx).Select("short_key", "domain_id").
+		Where("(domain_id, short_key) IN ?", pairs).
+		Find(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	for _, url := range urls {
+		taken = append(taken, DomainShortKey{DomainID: url.DomainID, ShortKey: url.ShortKey})
+	}
+
+	return taken, nil
+}
+
+func (r *PostgresUrlRepository) CountOnDomain(ctx context.Context, domainID uint) (int64, error) {
+
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&models.Url{}).Where("domain_id = ?", domainID).Count(&count).Error
+
+	return count, err
+}
+
+type PostgresUserRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresUserRepository(db *gorm.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+func (r *PostgresUserRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+
+	var user models.User
+
+	err := r.db.WithContext(ctx).First(&user, id).Error
+
+	return user, notFound(err)
+}
+
+func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+
+	var user models.User
+
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+
+	return user, notFound(err)
+}
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *PostgresUserRepository) Save(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+type PostgresAnalyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresAnalyticsRepository(db *gorm.DB) *PostgresAnalyticsRepository {
+	return &PostgresAnalyticsRepository{db: db}
+}
+
+func (r *PostgresAnalyticsRepository) List(ctx context.Context, urlID uint, from, to time.Time, offset, limit int) ([]models.Analytics, int64, error) {
+
+	query := r.inRange(ctx, urlID, from, to)
+
+	var total int64
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var analytics []models.Analytics
+
+	if err := query.Order("clicked_at desc").Offset(offset).Limit(limit).Find(&analytics).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return analytics, total, nil
+}
+
+func (r *PostgresAnalyticsRepository) Timeseries(ctx context.Context, urlID uint, from, to time.Time, interval string) ([]TimeBucket, error) {
+
+	var buckets []TimeBucket
+
+	err := r.inRange(ctx, urlID, from, to).
+		Select("date_trunc(?, clicked_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS clicks", interval).
+		Group("bucket").
+		Order("bucket").
+		Scan(&buckets).Error
+
+	return buckets, err
+}
+
+func (r *PostgresAnalyticsRepository) Breakdown(ctx context.Context, urlID uint, from, to time.Time, dimension string, limit int) ([]BreakdownItem, int64, error) {
+
+	expression, ok := analyticsDimensionColumns[dimension]
+
+	if !ok {
+		return nil, 0, errors.New("unknown analytics dimension " + dimension)
+	}
+
+	var total int64
+
+	if err := r.inRange(ctx, urlID, from, to).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]BreakdownItem, 0)
+
+	if err := r.inRange(ctx, urlID, from, to).
+		Select(expression + " AS value, COUNT(*) AS clicks").
+		Group("value").
+		Order("clicks desc, value").
+		Limit(limit).
+		Scan(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+func (r *PostgresAnalyticsRepository) RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.CreateInBatches(&events, 500).Error; err != nil {
+			return err
+		}
+
+		return incrementClicks(tx, increments)
+	})
+}
+
+// incrementClicks applies all per-URL click deltas in a single UPDATE.
+func incrementClicks(tx *gorm.DB, increments map[uint]int) error {
+
+	if len(increments) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(increments))
+	args := make([]interface{}, 0, len(increments)*2)
+
+	for urlID, count := range increments {
+		values = append(values, "(?::bigint, ?::bigint)")
+		args = append(args, urlID, count)
+	}
+
+	return tx.Exec(
+		"UPDATE urls SET clicks = urls.clicks + v.count FROM (VALUES "+strings.Join(values, ", ")+") AS v(id, count) WHERE urls.id = v.id",
+		args...,
+	).Error
+}
+
+func (r *PostgresAnalyticsRepository) inRange(ctx context.Context, urlID uint, from, to time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&models.Analytics{}).
+		Where("url_id = ? AND clicked_at >= ? AND clicked_at < ?", strconv.FormatUint(uint64(urlID), 10), from, to)
+}
+
+type PostgresDomainRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresDomainRepository(db *gorm.DB) *PostgresDomainRepository {
+	return &PostgresDomainRepository{db: db}
+}
+
+func (r *PostgresDomainRepository) Create(ctx context.Context, domain *models.Domain) error {
+	return r.db.WithContext(ctx).Create(domain).Error
+}
+
+func (r *PostgresDomainRepository) FindClaim(ctx context.Context, userID string, host string) (models.Domain, error) {
+
+	var domain models.Domain
+
+	err := r.db.WithContext(ctx).Where("host = ? AND user_id = ?", host, userID).First(&domain).Error
+
+	return domain, notFound(err)
+}
+
+func (r *PostgresDomainRepository) FindForUser(ctx context.Context, userID string, id uint) (models.Domain, error) {
+
+	var domain models.Domain
+
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&domain).Error
+
+	return domain, notFound(err)
+}
+
+func (r *PostgresDomainRepository) ListForUser(ctx context.Context, userID string) ([]models.Domain, error) {
+
+	domains := make([]models.Domain, 0)
+
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&domains).Error
+
+	return domains, err
+}
+
+func (r *PostgresDomainRepository) ListVerifiedForUser(ctx context.Context, userID string) ([]models.Domain, error) {
+
+	domains := make([]models.Domain, 0)
+
+	err := r.db.WithContext(ctx).Select("id", "host").Where("user_id = ? AND verified_at IS NOT NULL", userID).Find(&domains).Error
+
+	return domains, err
+}
+
+func (r *PostgresDomainRepository) MarkVerified(ctx context.Context, domain *models.Domain, verifiedAt time.Time) error {
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		// idx_domains_verified_host lets only one claim per host be verified
+		if err := tx.Model(domain).Update("verified_at", verifiedAt).Error; err != nil {
+			return duplicate(err)
+		}
+
+		return tx.Unscoped().Where("host = ? AND id <> ? AND verified_at IS NULL", domain.Host, domain.ID).Delete(&models.Domain{}).Error
+	})
+
+	if err != nil {
+		return err
+	}
+
+	domain.VerifiedAt = &verifiedAt
+
+	return nil
+}
+
+func (r *PostgresDomainRepository) Delete(ctx context.Context, domain *models.Domain) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(domain).Error
+}
+
+func (r *PostgresDomainRepository) FindVerified(ctx context.Context, host string) (models.Domain, error) {
+
+	var domain models.Domain
+
+	err := r.db.WithContext(ctx).Where("host = ? AND verified_at IS NOT NULL", host).First(&domain).Error
+
+	return domain, notFound(err)
+}
+
+func (r *PostgresDomainRepository) FindVerifiedForUser(ctx context.Context, userID string, host string) (models.Domain, error) {
+
+	var domain models.Domain
+
+	err := r.db.WithContext(ctx).Where("host = ? AND user_id = ? AND verified_at IS NOT NULL", host, userID).First(&domain).Error
+
+	return domain, notFound(err)
+}
+
+func (r *PostgresDomainRepository) Hosts(ctx context.Context, ids []uint) (map[uint]string, error) {
+
+	var domains []models.Domain
+
+	if len(ids) > 0 {
+		if err := r.db.WithContext(ctx).Select("id", "host").Where("id IN ?", ids).Find(&domains).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	hosts := make(map[uint]string, len(domains))
+
+	for _, domain := range domains {
+		hosts[domain.ID] = domain.Host
+	}
+
+	return hosts, nil
+}
+
+type PostgresRedirectRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRedirectRuleRepository(db *gorm.DB) *PostgresRedirectRuleRepository {
+	return &PostgresRedirectRuleRepository{db: db}
+}
+
+func (r *PostgresRedirectRuleRepository) List(ctx context.Context, urlID uint) ([]models.RedirectRule, error) {
+
+	rules := make([]models.RedirectRule, 0)
+
+	err := r.db.WithContext(ctx).Where("url_id = ?", urlID).Order("position ASC, id ASC").Find(&rules).Error
+
+	return rules, err
+}
+
+func (r *PostgresRedirectRuleRepository) Find(ctx context.Context, urlID uint, id uint) (models.RedirectRule, error) {
+
+	var rule models.RedirectRule
+
+	err := r.db.WithContext(ctx).Where("id = ? AND url_id = ?", id, urlID).First(&rule).Error
+
+	return rule, notFound(err)
+}
+
+func (r *PostgresRedirectRuleRepository) Create(ctx context.Context, rule *models.RedirectRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *PostgresRedirectRuleRepository) Update(ctx context.Context, rule *models.RedirectRule) error {
+	return r.db.WithContext(ctx).Model(rule).Select("Position", "Destination", "Devices", "OS", "Countries", "Languages", "StartsAt", "EndsAt").Updates(rule).Error
+}
+
+func (r *PostgresRedirectRuleRepository) Delete(ctx context.Context, rule *models.RedirectRule) error {
+	return r.db.WithContext(ctx).Delete(rule).Error
+}
+
+// notFound maps gorm's missing record error to ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// duplicate maps unique constraint violations to ErrDuplicate.
+func duplicate(err error) error {
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrDuplicate
+	}
+
+	return err
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"shortly-api-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type PostgresSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresSessionRepository(db *gorm.DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *models.Session, issue func(session *models.Session) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+
+		if err := issue(session); err != nil {
+			return err
+		}
+
+		return tx.Model(session).Updates(map[string]interface{}{
+			"access_token_id":   session.AccessTokenID,
+			"access_expires_at": session.AccessExpiresAt,
+		}).Error
+	})
+}
+
+func (r *PostgresSessionRepository) FindByRefreshHash(ctx context.Context, hash string) (models.Session, error) {
+
+	var session models.Session
+
+	err := r.db.WithContext(ctx).Where("refresh_token_hash = ?", hash).First(&session).Error
+
+	return session, notFound(err)
+}
+
+func (r *PostgresSessionRepository) FindByPreviousRefreshHash(ctx context.Context, hash string) (models.Session, error) {
+
+	var session models.Session
+
+	err := r.db.WithContext(ctx).Where("previous_refresh_token_hash = ? AND revoked_at IS NULL", hash).First(&session).Error
+
+	return session, notFound(err)
+}
+
+func (r *PostgresSessionRepository) FindActive(ctx context.Context, id uint) (models.Session, error) {
+
+	var session models.Session
+
+	err := r.db.WithContext(ctx).Where("id = ? AND revoked_at IS NULL", id).First(&session).Error
+
+	return session, notFound(err)
+}
+
+func (r *PostgresSessionRepository) ListActive(ctx context.Context, userID uint) ([]models.Session, error) {
+
+	sessions := make([]models.Session, 0)
+
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (r *PostgresSessionRepository) Rotate(ctx context.Context, session models.Session, previousHash string) (bool, error) {
+
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, previousHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":          session.RefreshTokenHash,
+			"previous_refresh_token_hash": previousHash,
+			"access_token_id":             session.AccessTokenID,
+			"access_expires_at":           session.AccessExpiresAt,
+			"user_agent":                  session.UserAgent,
+			"ip_address":                  session.IPAddress,
+		})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *PostgresSessionRepository) Revoke(ctx context.Context, ids []uint) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Update("revoked_at", time.Now()).Error
+}
+
+type PostgresApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresApiKeyRepository(db *gorm.DB) *PostgresApiKeyRepository {
+	return &PostgresApiKeyRepository{db: db}
+}
+
+func (r *PostgresApiKeyRepository) Create(ctx context.Context, apiKey *models.ApiKey) error {
+	return r.db.WithContext(ctx).Create(apiKey).Error
+}
+
+func (r *PostgresApiKeyRepository) FindByHash(ctx context.Context, hash string) (models.ApiKey, error) {
+
+	var apiKey models.ApiKey
+
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&apiKey).Error
+
+	return apiKey, notFound(err)
+}
+
+func (r *PostgresApiKeyRepository) CountActive(ctx context.Context, userID uint) (int64, error) {
+
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&models.ApiKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+
+	return count, err
+}
+
+func (r *PostgresApiKeyRepository) ListActive(ctx context.Context, userID uint) ([]models.ApiKey, error) {
+
+	apiKeys := make([]models.ApiKey, 0)
+
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at desc").Find(&apiKeys).Error
+
+	return apiKeys, err
+}
+
+func (r *PostgresApiKeyRepository) Revoke(ctx context.Context, userID uint, id uint) error {
+
+	result := r.db.WithContext(ctx).Model(&models.ApiKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresApiKeyRepository) Touch(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.ApiKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
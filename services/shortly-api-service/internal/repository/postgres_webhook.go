@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresWebhookRepository(db *gorm.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+func (r *PostgresWebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(hook).Error
+}
+
+func (r *PostgresWebhookRepository) CountInScope(ctx context.Context, scope authz.Scope) (int64, error) {
+
+	var count int64
+
+	err := scope.Webhooks(r.db.WithContext(ctx).Model(&models.Webhook{})).Count(&count).Error
+
+	return count, err
+}
+
+func (r *PostgresWebhookRepository) ListInScope(ctx context.Context, scope authz.Scope) ([]models.Webhook, error) {
+
+	hooks := make([]models.Webhook, 0)
+
+	err := scope.Webhooks(r.db.WithContext(ctx)).Order("created_at desc").Find(&hooks).Error
+
+	return hooks, err
+}
+
+func (r *PostgresWebhookRepository) FindInScope(ctx context.Context, scope authz.Scope, id uint) (models.Webhook, error) {
+
+	var hook models.Webhook
+
+	err := scope.Webhooks(r.db.WithContext(ctx)).Where("id = ?", id).First(&hook).Error
+
+	return hook, notFound(err)
+}
+
+func (r *PostgresWebhookRepository) Delete(ctx context.Context, hook *models.Webhook) error {
+	return r.db.WithContext(ctx).Delete(hook).Error
+}
+
+func (r *PostgresWebhookRepository) Deliveries(ctx context.Context, webhookID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	if err := query.Order("created_at desc").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *PostgresWebhookRepository) Subscribers(ctx context.Context, eventType string, url models.Url) ([]models.Webhook, error) {
+
+	hooks := make([]models.Webhook, 0)
+
+	query := r.db.WithContext(ctx).Where("active AND ? = ANY(string_to_array(events, ','))", eventType)
+
+	if url.WorkspaceID != nil {
+		query = query.Where("workspace_id = ?", *url.WorkspaceID)
+	} else {
+
+		if url.UserID == nil {
+			return hooks, nil
+		}
+
+		userID, err := strconv.ParseUint(*url.UserID, 10, 64)
+
+		if err != nil {
+			return hooks, nil
+		}
+
+		query = query.Where("workspace_id IS NULL AND user_id = ?", userID)
+	}
+
+	err := query.Find(&hooks).Error
+
+	return hooks, err
+}
+
+func (r *PostgresWebhookRepository) ClickSubscriptions(ctx context.Context, eventType string, urlIDs []uint) ([]ClickSubscription, error) {
+
+	subscriptions := make([]ClickSubscription, 0)
+
+	if len(urlIDs) == 0 {
+		return subscriptions, nil
+	}
+
+	err := r.db.WithContext(ctx).Table("urls").
+		Select("urls.id AS url_id, urls.short_key, webhooks.id AS webhook_id").
+		Joins(`JOIN webhooks ON webhooks.deleted_at IS NULL AND webhooks.active
+			AND ? = ANY(string_to_array(webhooks.events, ','))
+			AND ((urls.workspace_id IS NOT NULL AND webhooks.workspace_id = urls.workspace_id)
+			  OR (urls.workspace_id IS NULL AND webhooks.workspace_id IS NULL AND CAST(webhooks.user_id AS TEXT) = urls.user_id))`, eventType).
+		Where("urls.id IN ?", urlIDs).
+		Scan(&subscriptions).Error
+
+	return subscriptions, err
+}
+
+func (r *PostgresWebhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).CreateInBatches(&deliveries, 500).Error
+}
+
+// ClaimDeliveries locks the due rows with FOR UPDATE SKIP LOCKED, so several
+// API instances can claim from the same table without waiting on each other.
+func (r *PostgresWebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", deliveryIDs(deliveries)).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Soft deleted webhooks aren't preloaded
+	if err := r.db.WithContext(ctx).Preload("Webhook").Find(&deliveries, "id IN ?", deliveryIDs(deliveries)).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) FinishDelivery(ctx context.Context, id uint, outcome DeliveryOutcome) error {
+
+	update := map[string]interface{}{
+		"attempts":         outcome.Attempts,
+		"last_status_code": outcome.StatusCode,
+		"last_error":       outcome.Error,
+	}
+
+	if outcome.Status != "" {
+		update["status"] = outcome.Status
+	}
+
+	if !outcome.NextAttemptAt.IsZero() {
+		update["next_attempt_at"] = outcome.NextAttemptAt
+	}
+
+	if outcome.DeliveredAt != nil {
+		update["delivered_at"] = *outcome.DeliveredAt
+	}
+
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(update).Error
+}
+
+func deliveryIDs(deliveries []models.WebhookDelivery) []uint {
+	ids := make([]uint, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	return ids
+}
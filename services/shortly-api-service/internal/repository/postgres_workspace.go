@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgresWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresWorkspaceRepository(db *gorm.DB) *PostgresWorkspaceRepository {
+	return &PostgresWorkspaceRepository{db: db}
+}
+
+func (r *PostgresWorkspaceRepository) Create(ctx context.Context, workspace *models.Workspace, ownerID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      ownerID,
+			Role:        models.RoleOwner,
+		}).Error
+	})
+}
+
+func (r *PostgresWorkspaceRepository) ListForUser(ctx context.Context, userID uint) ([]WorkspaceMembership, error) {
+
+	memberships := make([]WorkspaceMembership, 0)
+
+	err := r.db.WithContext(ctx).Model(&models.Workspace{}).
+		Select("workspaces.id, workspaces.name, workspace_members.role, workspaces.created_at").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id AND workspace_members.deleted_at IS NULL").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.created_at").
+		Scan(&memberships).Error
+
+	return memberships, err
+}
+
+func (r *PostgresWorkspaceRepository) MemberRole(ctx context.Context, workspaceID uint, userID uint) (string, error) {
+
+	var member models.WorkspaceMember
+
+	if err := r.db.WithContext(ctx).Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", authz.ErrNotMember
+		}
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+func (r *PostgresWorkspaceRepository) Members(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error) {
+
+	members := make([]models.WorkspaceMember, 0)
+
+	err := r.db.WithContext(ctx).Preload("User").Where("workspace_id = ?", workspaceID).Order("created_at").Find(&members).Error
+
+	return members, err
+}
+
+func (r *PostgresWorkspaceRepository) AddMember(ctx context.Context, member *models.WorkspaceMember) error {
+	return r.db.WithContext(ctx).Omit("User").Create(member).Error
+}
+
+func (r *PostgresWorkspaceRepository) UpdateRole(ctx context.Context, workspaceID uint, userID uint, role string) (models.WorkspaceMember, error) {
+
+	var member models.WorkspaceMember
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := lockWorkspaceMember(tx, workspaceID, userID, &member); err != nil {
+			return err
+		}
+
+		if member.Role == models.RoleOwner && role != models.RoleOwner {
+			if err := ensureAnotherOwner(tx, workspaceID); err != nil {
+				return err
+			}
+		}
+
+		member.Role = role
+
+		return tx.Model(&member).Update("role", role).Error
+	})
+
+	return member, err
+}
+
+func (r *PostgresWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID uint, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		var member models.WorkspaceMember
+
+		if err := lockWorkspaceMember(tx, workspaceID, userID, &member); err != nil {
+			return err
+		}
+
+		if member.Role == models.RoleOwner {
+			if err := ensureAnotherOwner(tx, workspaceID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&member).Error
+	})
+}
+
+// lockWorkspaceMember locks the workspace row first so concurrent role
+// changes can't remove the last owner between the check and the write.
+func lockWorkspaceMember(tx *gorm.DB, workspaceID uint, userID uint, member *models.WorkspaceMember) error {
+
+	var workspace models.Workspace
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&workspace, workspaceID).Error; err != nil {
+		return notFound(err)
+	}
+
+	err := tx.Preload("User").Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(member).Error
+
+	return notFound(err)
+}
+
+func ensureAnotherOwner(tx *gorm.DB, workspaceID uint) error {
+
+	var owners int64
+
+	if err := tx.Model(&models.WorkspaceMember{}).Where("workspace_id = ? AND role = ?", workspaceID, models.RoleOwner).Count(&owners).Error; err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
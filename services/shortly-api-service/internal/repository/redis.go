@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"shortly-api-service/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisLinkCache struct {
+	client *redis.Client
+}
+
+func NewRedisLinkCache(client *redis.Client) *RedisLinkCache {
+	return &RedisLinkCache{client: client}
+}
+
+func (c *RedisLinkCache) GetUrl(ctx context.Context, domainID uint, shortKey string) (models.Url, bool) {
+
+	var url models.Url
+
+	data, err := c.client.Get(ctx, UrlCacheKey(domainID, shortKey)).Bytes()
+
+	if err != nil || len(data) == 0 {
+		return url, false
+	}
+
+	if err := json.Unmarshal(data, &url); err != nil {
+		return url, false
+	}
+
+	return url, true
+}
+
+func (c *RedisLinkCache) SetUrl(ctx context.Context, url models.Url, ttl time.Duration) error {
+
+	data, err := json.Marshal(url)
+
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, UrlCacheKey(url.DomainID, url.ShortKey), data, ttl).Err()
+}
+
+func (c *RedisLinkCache) DeleteUrl(ctx context.Context, domainID uint, shortKey string) error {
+	return c.client.Del(ctx, UrlCacheKey(domainID, shortKey), QRCacheKey(domainID, shortKey)).Err()
+}
+
+func (c *RedisLinkCache) GetDomainID(ctx context.Context, host string) (uint, bool) {
+
+	cached, err := c.client.Get(ctx, DomainCacheKey(host)).Result()
+
+	if err != nil {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(cached, 10, 64)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func (c *RedisLinkCache) SetDomainID(ctx context.Context, host string, domainID uint, ttl time.Duration) error {
+	return c.client.Set(ctx, DomainCacheKey(host), domainID, ttl).Err()
+}
+
+func (c *RedisLinkCache) DeleteDomainID(ctx context.Context, host string) error {
+	return c.client.Del(ctx, DomainCacheKey(host)).Err()
+}
+
+func (c *RedisLinkCache) GetQRCode(ctx context.Context, domainID uint, shortKey string, variant string) ([]byte, bool) {
+
+	image, err := c.client.HGet(ctx, QRCacheKey(domainID, shortKey), variant).Bytes()
+
+	if err != nil || len(image) == 0 {
+		return nil, false
+	}
+
+	return image, true
+}
+
+func (c *RedisLinkCache) SetQRCode(ctx context.Context, domainID uint, shortKey string, variant string, image []byte, ttl time.Duration) error {
+
+	cacheKey := QRCacheKey(domainID, shortKey)
+
+	pipe := c.client.TxPipeline()
+	pipe.HSet(ctx, cacheKey, variant, image)
+	pipe.Expire(ctx, cacheKey, ttl)
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+type RedisProfileCache struct {
+	client *redis.Client
+}
+
+func NewRedisProfileCache(client *redis.Client) *RedisProfileCache {
+	return &RedisProfileCache{client: client}
+}
+
+func (c *RedisProfileCache) GetProfile(ctx context.Context, email string) ([]byte, bool) {
+
+	profile, err := c.client.Get(ctx, ProfileCacheKey(email)).Bytes()
+
+	if err != nil || len(profile) == 0 {
+		return nil, false
+	}
+
+	return profile, true
+}
+
+func (c *RedisProfileCache) SetProfile(ctx context.Context, email string, profile []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, ProfileCacheKey(email), profile, ttl).Err()
+}
+
+type RedisTokenDenylist struct {
+	client *redis.Client
+}
+
+func NewRedisTokenDenylist(client *redis.Client) *RedisTokenDenylist {
+	return &RedisTokenDenylist{client: client}
+}
+
+func (d *RedisTokenDenylist) Add(ctx context.Context, jti string, expiresAt time.Time) error {
+
+	ttl := time.Until(expiresAt)
+
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+
+	return d.client.Set(ctx, TokenDenylistKey(jti), 1, ttl).Err()
+}
+
+func (d *RedisTokenDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+
+	exists, err := d.client.Exists(ctx, TokenDenylistKey(jti)).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	return exists > 0, nil
+}
+
+// UrlCacheKey keeps the original "url:<key>" layout for the shared domain.
+func UrlCacheKey(domainID uint, shortKey string) string {
+	if domainID == 0 {
+		return "url:" + shortKey
+	}
+	return "url:" + strconv.FormatUint(uint64(domainID), 10) + ":" + shortKey
+}
+
+// QRCacheKey names the Redis hash holding every rendered variant of a link's
+// QR code, so one DEL drops them all.
+func QRCacheKey(domainID uint, shortKey string) string {
+	return "url:qr:" + strconv.FormatUint(uint64(domainID), 10) + ":" + shortKey
+}
+
+func DomainCacheKey(host string) string {
+	return "domain:" + host
+}
+
+func ProfileCacheKey(email string) string {
+	return "user:profile:" + email
+}
+
+func TokenDenylistKey(jti string) string {
+	return "token:denylist:" + jti
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/models"
+)
+
+var (
+	ErrNotFound  = errors.New("record not found")
+	ErrDuplicate = errors.New("record already exists")
+	ErrLastOwner = errors.New("workspace must keep at least one owner")
+)
+
+// UrlFilter selects a single link. A non-zero ID matches on the ID alone,
+// otherwise the link is looked up on DomainID by ShortKey or OriginalURL.
+type UrlFilter struct {
+	ID          uint
+	DomainID    uint
+	ShortKey    string
+	OriginalURL string
+}
+
+type UrlRepository interface {
+	Create(ctx context.Context, url *models.Url) error
+
+	// FindByShortKey finds a link whoever owns it, for redirects and short
+	// key conflict checks.
+	FindByShortKey(ctx context.Context, domainID uint, shortKey string) (models.Url, error)
+
+	// FindInScope finds a link visible in scope, ErrNotFound otherwise.
+	FindInScope(ctx context.Context, scope authz.Scope, filter UrlFilter) (models.Url, error)
+
+	ListInScope(ctx context.Context, scope authz.Scope) ([]models.Url, error)
+
+	// Update writes the editable fields of url: short key, title, expiry,
+	// click limit and password.
+	Update(ctx context.Context, url *models.Url) error
+
+	Delete(ctx context.Context, url *models.Url) error
+
+	// ExistingShortKeys returns the keys among shortKeys used by a link on
+	// any domain, deleted links included.
+	ExistingShortKeys(ctx context.Context, shortKeys []string) ([]string, error)
+
+	// ConsumeClick increments the click count only while it is below
+	// max_clicks and reports whether the click was allowed.
+	ConsumeClick(ctx context.Context, id uint) (bool, error)
+
+	// CreateMany stores the links of a bulk request. Links whose short key
+	// is already taken on their domain are skipped instead of failing the
+	// whole batch; created reports for each link whether it was stored.
+	CreateMany(ctx context.Context, urls []models.Url) (created []bool, err error)
+
+	// ListShortened returns the links in scope whose original URL is one of
+	// originalUrls, on any domain.
+	ListShortened(ctx context.Context, scope authz.Scope, originalUrls []string) ([]models.Url, error)
+
+	// TakenShortKeys returns the keys among keys already used on their domain.
+	TakenShortKeys(ctx context.Context, keys []DomainShortKey) ([]DomainShortKey, error)
+
+	// CountOnDomain counts the links served from a custom domain.
+	CountOnDomain(ctx context.Context, domainID uint) (int64, error)
+}
+
+// DomainShortKey is a short key on one domain, 0 being the shared domain.
+type DomainShortKey struct {
+	DomainID uint
+	ShortKey string
+}
+
+type UserRepository interface {
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	Save(ctx context.Context, user *models.User) error
+}
+
+// TimeBucket is the click count of one day or hour.
+type TimeBucket struct {
+	Bucket time.Time
+	Clicks int64
+}
+
+// BreakdownItem is the click count of one value of a breakdown dimension.
+type BreakdownItem struct {
+	Value  string
+	Clicks int64
+}
+
+// AnalyticsDimensions are the values accepted by AnalyticsRepository.Breakdown.
+var AnalyticsDimensions = []string{"country", "region", "city", "device", "browser", "os", "referrer"}
+
+// AnalyticsRepository reads the click events of a link within [from, to).
+type AnalyticsRepository interface {
+	List(ctx context.Context, urlID uint, from, to time.Time, offset, limit int) ([]models.Analytics, int64, error)
+
+	// Timeseries counts clicks per "day" or "hour" in UTC. Empty buckets
+	// are left out.
+	Timeseries(ctx context.Context, urlID uint, from, to time.Time, interval string) ([]TimeBucket, error)
+
+	// Breakdown returns the top values of dimension with the total number
+	// of clicks in the range.
+	Breakdown(ctx context.Context, urlID uint, from, to time.Time, dimension string, limit int) ([]BreakdownItem, int64, error)
+
+	// RecordClicks stores click events and adds increments to the click
+	// counts of their links in one transaction.
+	RecordClicks(ctx context.Context, events []models.Analytics, increments map[uint]int) error
+}
+
+type DomainRepository interface {
+	Create(ctx context.Context, domain *models.Domain) error
+
+	// FindClaim finds the user's registration of host, verified or not.
+	FindClaim(ctx context.Context, userID string, host string) (models.Domain, error)
+
+	// FindForUser finds a domain of the user by ID, verified or not.
+	FindForUser(ctx context.Context, userID string, id uint) (models.Domain, error)
+
+	// ListForUser returns the user's domains, newest first.
+	ListForUser(ctx context.Context, userID string) ([]models.Domain, error)
+
+	// ListVerifiedForUser returns the user's verified domains.
+	ListVerifiedForUser(ctx context.Context, userID string) ([]models.Domain, error)
+
+	// MarkVerified hands the host to domain and drops the unverified claims
+	// other users hold on it. It fails with ErrDuplicate when another account
+	// verified the host first.
+	MarkVerified(ctx context.Context, domain *models.Domain, verifiedAt time.Time) error
+
+	// Delete removes the domain for good so the host can be registered again.
+	Delete(ctx context.Context, domain *models.Domain) error
+
+	// FindVerified finds the verified domain serving host.
+	FindVerified(ctx context.Context, host string) (models.Domain, error)
+
+	// FindVerifiedForUser finds host among the user's verified domains.
+	FindVerifiedForUser(ctx context.Context, userID string, host string) (models.Domain, error)
+
+	// Hosts maps domain IDs to their hosts, unknown IDs are left out.
+	Hosts(ctx context.Context, ids []uint) (map[uint]string, error)
+}
+
+// RedirectRuleRepository stores the redirect rules of links.
+type RedirectRuleRepository interface {
+	// List returns the rules of a link by ascending position, then ID.
+	List(ctx context.Context, urlID uint) ([]models.RedirectRule, error)
+
+	// Find finds a rule of the link, ErrNotFound otherwise.
+	Find(ctx context.Context, urlID uint, id uint) (models.RedirectRule, error)
+
+	Create(ctx context.Context, rule *models.RedirectRule) error
+
+	// Update writes the position, destination, conditions and time window.
+	Update(ctx context.Context, rule *models.RedirectRule) error
+
+	Delete(ctx context.Context, rule *models.RedirectRule) error
+}
+
+// LinkCache holds what the redirect path reads on every request: links by
+// short key, host to domain mappings and rendered QR codes. Misses are
+// reported with false, cache errors are treated as misses.
+type LinkCache interface {
+	GetUrl(ctx context.Context, domainID uint, shortKey string) (models.Url, bool)
+	SetUrl(ctx context.Context, url models.Url, ttl time.Duration) error
+
+	// DeleteUrl drops the link together with its rendered QR codes.
+	DeleteUrl(ctx context.Context, domainID uint, shortKey string) error
+
+	GetDomainID(ctx context.Context, host string) (uint, bool)
+	SetDomainID(ctx context.Context, host string, domainID uint, ttl time.Duration) error
+	DeleteDomainID(ctx context.Context, host string) error
+
+	// GetQRCode and SetQRCode store a rendered QR code per variant (format,
+	// size, level and margin).
+	GetQRCode(ctx context.Context, domainID uint, shortKey string, variant string) ([]byte, bool)
+	SetQRCode(ctx context.Context, domainID uint, shortKey string, variant string, image []byte, ttl time.Duration) error
+}
+
+// ProfileCache holds serialized user profiles by email, with the same miss
+// semantics as LinkCache.
+type ProfileCache interface {
+	GetProfile(ctx context.Context, email string) ([]byte, bool)
+	SetProfile(ctx context.Context, email string, profile []byte, ttl time.Duration) error
+}
+
+// TokenDenylist revokes access tokens by jti before they expire.
+type TokenDenylist interface {
+	// Add denylists jti until expiresAt. Empty and already expired tokens
+	// are ignored.
+	Add(ctx context.Context, jti string, expiresAt time.Time) error
+	Contains(ctx context.Context, jti string) (bool, error)
+}
+
+// SessionRepository stores signed-in sessions. Refresh tokens are only ever
+// handled as hashes.
+type SessionRepository interface {
+	// Create stores session and calls issue with its ID to set the access
+	// token fields, which are saved in the same transaction.
+	Create(ctx context.Context, session *models.Session, issue func(session *models.Session) error) error
+
+	// FindByRefreshHash finds the session currently holding the refresh
+	// token, revoked or not.
+	FindByRefreshHash(ctx context.Context, hash string) (models.Session, error)
+
+	// FindByPreviousRefreshHash finds the active session the refresh token
+	// was rotated away from.
+	FindByPreviousRefreshHash(ctx context.Context, hash string) (models.Session, error)
+
+	FindActive(ctx context.Context, id uint) (models.Session, error)
+	ListActive(ctx context.Context, userID uint) ([]models.Session, error)
+
+	// Rotate writes the new refresh and access tokens, user agent and IP of
+	// session, but only while the session still holds previousHash and isn't
+	// revoked. It reports whether the session was rotated.
+	Rotate(ctx context.Context, session models.Session, previousHash string) (bool, error)
+
+	Revoke(ctx context.Context, ids []uint) error
+}
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey *models.ApiKey) error
+
+	// FindByHash finds a key by the hash of its plain value, revoked or not.
+	FindByHash(ctx context.Context, hash string) (models.ApiKey, error)
+
+	CountActive(ctx context.Context, userID uint) (int64, error)
+
+	// ListActive returns the user's unrevoked keys, newest first.
+	ListActive(ctx context.Context, userID uint) ([]models.ApiKey, error)
+
+	// Revoke revokes an active key of the user, ErrNotFound otherwise.
+	Revoke(ctx context.Context, userID uint, id uint) error
+
+	// Touch records when a key was last used.
+	Touch(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+// WorkspaceMembership is a workspace with the role one member has in it.
+type WorkspaceMembership struct {
+	ID        uint
+	Name      string
+	Role      string
+	CreatedAt time.Time
+}
+
+type WorkspaceRepository interface {
+	// Create stores the workspace with ownerID as its first owner.
+	Create(ctx context.Context, workspace *models.Workspace, ownerID uint) error
+
+	// ListForUser returns the workspaces the user belongs to, oldest first.
+	ListForUser(ctx context.Context, userID uint) ([]WorkspaceMembership, error)
+
+	// MemberRole returns the user's role in the workspace, or
+	// authz.ErrNotMember. It backs authz.Resolve.
+	MemberRole(ctx context.Context, workspaceID uint, userID uint) (string, error)
+
+	// Members returns the members with their users, oldest first.
+	Members(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error)
+
+	AddMember(ctx context.Context, member *models.WorkspaceMember) error
+
+	// UpdateRole changes a member's role. It fails with ErrNotFound for
+	// unknown members and ErrLastOwner when it would demote the last owner.
+	UpdateRole(ctx context.Context, workspaceID uint, userID uint, role string) (models.WorkspaceMember, error)
+
+	// RemoveMember deletes a membership for good so the user can be invited
+	// again, with the same errors as UpdateRole.
+	RemoveMember(ctx context.Context, workspaceID uint, userID uint) error
+}
+
+// ClickSubscription is a webhook subscribed to the clicks of a link.
+type ClickSubscription struct {
+	UrlID     uint
+	ShortKey  string
+	WebhookID uint
+}
+
+// DeliveryOutcome is the result of one delivery attempt.
+type DeliveryOutcome struct {
+	// Status is left empty while the delivery has retries left
+	Status     string
+	Attempts   int
+	StatusCode int
+	Error      string
+
+	// NextAttemptAt is set when the delivery is retried
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, hook *models.Webhook) error
+	CountInScope(ctx context.Context, scope authz.Scope) (int64, error)
+
+	// ListInScope returns the webhooks of the scope, newest first.
+	ListInScope(ctx context.Context, scope authz.Scope) ([]models.Webhook, error)
+
+	// FindInScope finds a webhook of the scope, ErrNotFound otherwise.
+	FindInScope(ctx context.Context, scope authz.Scope, id uint) (models.Webhook, error)
+
+	Delete(ctx context.Context, hook *models.Webhook) error
+
+	// Deliveries pages through a webhook's delivery log, newest first. An
+	// empty status returns every delivery.
+	Deliveries(ctx context.Context, webhookID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error)
+
+	// Subscribers returns the active webhooks of url's personal space or
+	// workspace subscribed to eventType.
+	Subscribers(ctx context.Context, eventType string, url models.Url) ([]models.Webhook, error)
+
+	// ClickSubscriptions returns the active webhooks subscribed to
+	// eventType for each of the links.
+	ClickSubscriptions(ctx context.Context, eventType string, urlIDs []uint) ([]ClickSubscription, error)
+
+	// Enqueue stores pending deliveries for the dispatcher.
+	Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error
+
+	// ClaimDeliveries leases up to limit due deliveries, oldest due first,
+	// by pushing their next attempt lease into the future so no other
+	// dispatcher claims them meanwhile. Webhooks are loaded, nil once deleted.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+
+	// FinishDelivery records the outcome of an attempt.
+	FinishDelivery(ctx context.Context, id uint, outcome DeliveryOutcome) error
+}
@@ -1,18 +1,27 @@
 package routes
 
 import (
+	"shortly-api-service/internal/authz"
 	"shortly-api-service/internal/handlers"
 	"shortly-api-service/internal/middlewares"
+	"shortly-api-service/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AnalyticsRouter(router *gin.RouterGroup) {
+func AnalyticsRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
 
-	analytics := router.Group("/analytics").Use(middlewares.AuthMiddleware())
+	analytics := router.Group("/analytics").Use(auth.AuthMiddleware())
 
 	{
-		analytics.GET("/:urlId", middlewares.RateLimiter("10-m"), handlers.GetAnalytics)
+		// Paginated raw click events
+		analytics.GET("/:urlId", middlewares.RateLimiter("10-m"), middlewares.RequireScope(models.ScopeAnalyticsRead), auth.Authorize(authz.AnalyticsRead), server.GetAnalytics)
+
+		// Click counts grouped by day or hour
+		analytics.GET("/:urlId/timeseries", middlewares.RateLimiter("10-m"), middlewares.RequireScope(models.ScopeAnalyticsRead), auth.Authorize(authz.AnalyticsRead), server.GetAnalyticsTimeseries)
+
+		// Click counts grouped by country, device, browser, os or referrer domain
+		analytics.GET("/:urlId/breakdown/:dimension", middlewares.RateLimiter("10-m"), middlewares.RequireScope(models.ScopeAnalyticsRead), auth.Authorize(authz.AnalyticsRead), server.GetAnalyticsBreakdown)
 	}
 
 }
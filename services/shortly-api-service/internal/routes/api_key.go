@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"shortly-api-service/internal/handlers"
+	"shortly-api-service/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApiKeyRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
+
+	// API keys are managed from a signed-in session only, a key can't mint keys
+	apiKeys := router.Group("/api-keys").Use(auth.AuthMiddleware(), middlewares.RequireSession())
+
+	{
+		// List the user's active API keys
+		apiKeys.GET("/", middlewares.RateLimiter("20-M"), server.GetApiKeys)
+
+		// Create an API key, the plain key is returned once
+		apiKeys.POST("/", middlewares.RateLimiter("5-M"), server.CreateApiKey)
+
+		// Revoke an API key
+		apiKeys.DELETE("/:keyId", middlewares.RateLimiter("5-M"), server.RevokeApiKey)
+	}
+
+}
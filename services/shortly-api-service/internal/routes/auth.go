@@ -7,19 +7,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRouter(router *gin.RouterGroup) {
+func AuthRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
 
-	auth := router.Group("/auth")
+	group := router.Group("/auth")
 
 	{
 		// Signup or register new user
-		auth.POST("/signup", middlewares.RateLimiter("5-M"), handlers.Signup)
+		group.POST("/signup", middlewares.RateLimiter("5-M"), server.Signup)
 
 		// Signin and get token
-		auth.POST("/signin", middlewares.RateLimiter("10-M"), handlers.Signin)
+		group.POST("/signin", middlewares.RateLimiter("10-M"), server.Signin)
 
 		// Logout the current user
-		auth.POST("/logout", middlewares.RateLimiter("10-M"), handlers.Logout)
+		group.POST("/logout", middlewares.RateLimiter("10-M"), server.Logout)
+
+		// Exchange a refresh token for a new token pair
+		group.POST("/refresh", middlewares.RateLimiter("30-M"), server.RefreshToken)
+
+		// Revoke every session of the current user
+		group.POST("/logout-all", middlewares.RateLimiter("10-M"), auth.AuthMiddleware(), middlewares.RequireSession(), server.LogoutAll)
 	}
 
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"shortly-api-service/internal/handlers"
+	"shortly-api-service/internal/middlewares"
+	"shortly-api-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DomainRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
+
+	domains := router.Group("/domains").Use(auth.AuthMiddleware())
+
+	{
+		// List the user's custom domains
+		domains.GET("/", middlewares.RateLimiter("20-M"), middlewares.RequireScope(models.ScopeDomainsRead), server.GetDomains)
+
+		// Register a custom domain
+		domains.POST("/", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeDomainsWrite), server.CreateDomain)
+
+		// Verify ownership through the DNS TXT record
+		domains.POST("/:domainId/verify", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeDomainsWrite), server.VerifyDomain)
+
+		// Delete a custom domain
+		domains.DELETE("/:domainId", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeDomainsWrite), server.DeleteDomain)
+	}
+
+}
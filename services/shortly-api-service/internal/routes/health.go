@@ -9,6 +9,9 @@ import (
 func HealthRouter(router *gin.RouterGroup) {
 	health := router.Group("/health")
 	{
-		health.GET("/", handlers.HealthCheck)
+		health.GET("/", handlers.LivenessCheck)
+		health.GET("/live", handlers.LivenessCheck)
+		health.GET("/ready", handlers.ReadinessCheck)
+		health.GET("/clicks", handlers.ClickPipelineStats)
 	}
-}
\ No newline at end of file
+}
@@ -7,16 +7,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func ProfileRouter(router *gin.RouterGroup) {
+func ProfileRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
 
-	profile := router.Group("/profile").Use(middlewares.AuthMiddleware())
+	profile := router.Group("/profile").Use(auth.AuthMiddleware(), middlewares.RequireSession())
 
 	{
 		// Get the authenticated user's profile information
-		profile.GET("/", middlewares.RateLimiter("20-M"), handlers.GetUserProfile)
+		profile.GET("/", middlewares.RateLimiter("20-M"), server.GetUserProfile)
 
 		// Update the authenticated user's profile information
-		profile.PATCH("/update", middlewares.RateLimiter("5-M"), handlers.UpdateUserProfile)
+		profile.PATCH("/update", middlewares.RateLimiter("5-M"), server.UpdateUserProfile)
 	}
 
 }
@@ -1,34 +1,57 @@
 package routes
 
 import (
+	"shortly-api-service/internal/authz"
 	"shortly-api-service/internal/handlers"
 	"shortly-api-service/internal/middlewares"
+	"shortly-api-service/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UrlRouter(router *gin.RouterGroup) {
+func UrlRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
 
 	// Redirect to Original Url
-	router.GET("/url/redirect/:shortKey", middlewares.RateLimiter("50-m"), handlers.RedirectToOriginalUrl)
+	router.GET("/url/redirect/:shortKey", middlewares.RateLimiter("50-m"), server.RedirectToOriginalUrl)
 
-	url := router.Group("/url").Use(middlewares.AuthMiddleware())
+	// Unlock a password protected Url
+	router.POST("/url/redirect/:shortKey", middlewares.RateLimiter("10-M"), server.UnlockUrl)
+
+	url := router.Group("/url").Use(auth.AuthMiddleware())
 
 	{
 		// Get all URLs (for login user)
-		url.GET("/", middlewares.RateLimiter("20-M"), handlers.GetAllUrls)
+		url.GET("/", middlewares.RateLimiter("20-M"), middlewares.RequireScope(models.ScopeUrlsRead), auth.Authorize(authz.UrlsRead), server.GetAllUrls)
 
 		// Shorten a URL
-		url.POST("/shorten", middlewares.RateLimiter("5-M"), handlers.CreateUrl)
+		url.POST("/shorten", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.CreateUrl)
+
+		// Shorten many URLs at once (JSON array or CSV upload)
+		url.POST("/shorten/bulk", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.CreateBulkUrls)
 
 		// Get URL details by shortKey
-		url.GET("/:shortKey", middlewares.RateLimiter("20-M"), handlers.GetUrlDetails)
+		url.GET("/:shortKey", middlewares.RateLimiter("20-M"), middlewares.RequireScope(models.ScopeUrlsRead), auth.Authorize(authz.UrlsRead), server.GetUrlDetails)
+
+		// QR code of the short link (PNG or SVG)
+		url.GET("/:shortKey/qr", middlewares.RateLimiter("20-M"), middlewares.RequireScope(models.ScopeUrlsRead), auth.Authorize(authz.UrlsRead), server.GetUrlQRCode)
 
 		// Update an existing URL
-		url.PATCH("/:shortKey", middlewares.RateLimiter("5-M"), handlers.UpdateUrl)
+		url.PATCH("/:shortKey", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.UpdateUrl)
+
+		// Redirect rules of a URL, tried in position order before the original URL
+		url.GET("/:shortKey/rules", middlewares.RateLimiter("20-M"), middlewares.RequireScope(models.ScopeUrlsRead), auth.Authorize(authz.UrlsRead), server.GetRedirectRules)
+
+		// Add a redirect rule
+		url.POST("/:shortKey/rules", middlewares.RateLimiter("10-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.CreateRedirectRule)
+
+		// Update a redirect rule
+		url.PATCH("/:shortKey/rules/:ruleId", middlewares.RateLimiter("10-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.UpdateRedirectRule)
+
+		// Delete a redirect rule
+		url.DELETE("/:shortKey/rules/:ruleId", middlewares.RateLimiter("10-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.DeleteRedirectRule)
 
 		// Delete a URL
-		url.DELETE("/:shortKey", middlewares.RateLimiter("5-M"), handlers.DeleteUrl)
+		url.DELETE("/:shortKey", middlewares.RateLimiter("5-M"), middlewares.RequireScope(models.ScopeUrlsWrite), auth.Authorize(authz.UrlsWrite), server.DeleteUrl)
 	}
 
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/handlers"
+	"shortly-api-service/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
+
+	webhooks := router.Group("/webhooks").Use(auth.AuthMiddleware(), middlewares.RequireSession(), auth.Authorize(authz.WebhooksManage))
+
+	{
+		// List webhooks of the personal space or the X-Workspace-ID workspace
+		webhooks.GET("/", middlewares.RateLimiter("20-M"), server.GetWebhooks)
+
+		// Register a webhook, the signing secret is only returned here
+		webhooks.POST("/", middlewares.RateLimiter("5-M"), server.CreateWebhook)
+
+		// Delete a webhook
+		webhooks.DELETE("/:webhookId", middlewares.RateLimiter("10-M"), server.DeleteWebhook)
+
+		// Delivery log with status, attempts and last error
+		webhooks.GET("/:webhookId/deliveries", middlewares.RateLimiter("30-M"), server.GetWebhookDeliveries)
+
+		// Queue a webhook.test event
+		webhooks.POST("/:webhookId/test", middlewares.RateLimiter("5-M"), server.TestWebhook)
+	}
+
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"shortly-api-service/internal/authz"
+	"shortly-api-service/internal/handlers"
+	"shortly-api-service/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WorkspaceRouter(router *gin.RouterGroup, server *handlers.Server, auth *middlewares.Auth) {
+
+	workspaces := router.Group("/workspaces").Use(auth.AuthMiddleware(), middlewares.RequireSession())
+
+	{
+		// List the workspaces the user belongs to, with their role
+		workspaces.GET("/", middlewares.RateLimiter("20-M"), server.GetWorkspaces)
+
+		// Create a workspace, the creator becomes its owner
+		workspaces.POST("/", middlewares.RateLimiter("5-M"), server.CreateWorkspace)
+
+		// List members
+		workspaces.GET("/:workspaceId/members", middlewares.RateLimiter("20-M"), auth.Authorize(authz.WorkspaceRead), server.GetWorkspaceMembers)
+
+		// Add an existing user as member
+		workspaces.POST("/:workspaceId/members", middlewares.RateLimiter("10-M"), auth.Authorize(authz.WorkspaceManage), server.AddWorkspaceMember)
+
+		// Change a member's role
+		workspaces.PATCH("/:workspaceId/members/:userId", middlewares.RateLimiter("10-M"), auth.Authorize(authz.WorkspaceManage), server.UpdateWorkspaceMember)
+
+		// Remove a member
+		workspaces.DELETE("/:workspaceId/members/:userId", middlewares.RateLimiter("10-M"), auth.Authorize(authz.WorkspaceManage), server.RemoveWorkspaceMember)
+
+		// Leave a workspace
+		workspaces.POST("/:workspaceId/leave", middlewares.RateLimiter("10-M"), auth.Authorize(authz.WorkspaceRead), server.LeaveWorkspace)
+	}
+
+}
@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+)
+
+// How long a redirect waits for the visitor's country
+const countryTimeout = 20 * time.Millisecond
+
+// Devices and OS families a rule can target
+var (
+	Devices    = []string{"mobile", "desktop", "bot"}
+	OSFamilies = []string{"ios", "android", "windows", "macos", "linux", "chromeos"}
+)
+
+// Visitor is what rules are matched against. The country is only looked up
+// once a rule needs it, so links without country rules never pay for a geo
+// lookup, and only in the local database: a visitor whose country isn't
+// known in time matches no country rule.
+type Visitor struct {
+	Device    string
+	OS        string
+	Language  string
+	Time      time.Time
+	IPAddress string
+
+	country      *lib.GeoInfo
+	countryKnown bool
+	lookupGeo    func(ip string) (lib.GeoInfo, bool)
+}
+
+// NewVisitor describes the client of a redirect from its request headers.
+func NewVisitor(ipAddress string, userAgent string, acceptLanguage string) *Visitor {
+
+	device, _, os := lib.ParseUserAgent(userAgent)
+
+	return &Visitor{
+		Device:    strings.ToLower(device),
+		OS:        OSFamily(os),
+		Language:  PreferredLanguage(acceptLanguage),
+		Time:      time.Now(),
+		IPAddress: ipAddress,
+		lookupGeo: lookupCountry,
+	}
+}
+
+func lookupCountry(ip string) (lib.GeoInfo, bool) {
+	return lib.LookupCountry(ip, countryTimeout)
+}
+
+func (v *Visitor) geo() (lib.GeoInfo, bool) {
+
+	if v.country == nil {
+		info, ok := v.lookupGeo(v.IPAddress)
+		v.country = &info
+		v.countryKnown = ok
+	}
+
+	return *v.country, v.countryKnown
+}
+
+// Match returns the first rule, by position, whose conditions all hold for
+// the visitor.
+func Match(rules []models.RedirectRule, visitor *Visitor) (models.RedirectRule, bool) {
+
+	ordered := slices.Clone(rules)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Position != ordered[j].Position {
+			return ordered[i].Position < ordered[j].Position
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	for _, rule := range ordered {
+		if matches(rule, visitor) {
+			return rule, true
+		}
+	}
+
+	return models.RedirectRule{}, false
+}
+
+func matches(rule models.RedirectRule, visitor *Visitor) bool {
+
+	if rule.StartsAt != nil && visitor.Time.Before(*rule.StartsAt) {
+		return false
+	}
+
+	if rule.EndsAt != nil && !visitor.Time.Before(*rule.EndsAt) {
+		return false
+	}
+
+	if devices := rule.DeviceList(); len(devices) > 0 && !slices.Contains(devices, visitor.Device) {
+		return false
+	}
+
+	if families := rule.OSList(); len(families) > 0 && !slices.Contains(families, visitor.OS) {
+		return false
+	}
+
+	if languages := rule.LanguageList(); len(languages) > 0 && !slices.ContainsFunc(languages, func(language string) bool {
+		return languageMatches(language, visitor.Language)
+	}) {
+		return false
+	}
+
+	// Checked last, it may need a geo lookup
+	if countries := rule.CountryList(); len(countries) > 0 {
+
+		geo, ok := visitor.geo()
+
+		if !ok || !slices.ContainsFunc(countries, func(country string) bool {
+			return strings.EqualFold(country, geo.CountryCode) || strings.EqualFold(country, geo.Country)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OSFamily maps the operating system reported by lib.ParseUserAgent to one
+// of OSFamilies, or "" when it isn't one of them.
+func OSFamily(os string) string {
+
+	switch {
+	case strings.Contains(os, "like Mac OS X"):
+		// iPhone and iPad report "CPU [iPhone] OS 17_0 like Mac OS X"
+		return "ios"
+	case strings.Contains(os, "Android"):
+		return "android"
+	case strings.Contains(os, "Windows"):
+		return "windows"
+	case strings.Contains(os, "Mac OS X"):
+		return "macos"
+	case strings.Contains(os, "CrOS"):
+		return "chromeos"
+	case strings.Contains(os, "Linux"):
+		return "linux"
+	default:
+		return ""
+	}
+}
+
+// PreferredLanguage returns the language tag with the highest weight in an
+// Accept-Language header, lower cased, or "" when there is none. Only the
+// preferred language is used, most browsers also list English as a fallback.
+func PreferredLanguage(header string) string {
+
+	best := ""
+	bestWeight := 0.0
+
+	for _, part := range strings.Split(header, ",") {
+
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		weight := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+
+			parsed, err := strconv.ParseFloat(q, 64)
+
+			if err != nil {
+				continue
+			}
+
+			weight = parsed
+		}
+
+		// Ties keep the earlier tag
+		if weight > bestWeight {
+			best = tag
+			bestWeight = weight
+		}
+	}
+
+	return best
+}
+
+// languageMatches tells whether the rule language covers the visitor's:
+// "pt" matches "pt" and "pt-br", "pt-br" only matches "pt-br".
+func languageMatches(language string, visitor string) bool {
+	return visitor == language || strings.HasPrefix(visitor, language+"-")
+}
@@ -0,0 +1,38 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/lib"
+	"shortly-api-service/internal/models"
+)
+
+func visitorIn(info lib.GeoInfo, known bool) *Visitor {
+	return &Visitor{
+		Time:      time.Now(),
+		IPAddress: "203.0.113.7",
+		lookupGeo: func(ip string) (lib.GeoInfo, bool) { return info, known },
+	}
+}
+
+func TestCountryRules(t *testing.T) {
+
+	rules := []models.RedirectRule{
+		{Position: 1, Countries: "DE,AT", Destination: "https://example.de"},
+		{Position: 2, Countries: "Unknown", Destination: "https://example.com/unknown"},
+	}
+
+	if rule, ok := Match(rules, visitorIn(lib.GeoInfo{Country: "Austria", CountryCode: "AT"}, true)); !ok || rule.Position != 1 {
+		t.Fatalf("visitor from AT matched %+v, %v, want the first rule", rule, ok)
+	}
+
+	if rule, ok := Match(rules, visitorIn(lib.GeoInfo{Country: "France", CountryCode: "FR"}, true)); ok {
+		t.Fatalf("visitor from FR matched %+v", rule)
+	}
+
+	// A failed or late lookup matches no country rule, whatever it returned
+	if rule, ok := Match(rules, visitorIn(lib.GeoInfo{Country: "Unknown"}, false)); ok {
+		t.Fatalf("visitor of unknown country matched %+v", rule)
+	}
+}
@@ -1,20 +1,81 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"shortly-api-service/config"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID uint, email string) (string, error) {
+// GenerateToken issues a short-lived access token bound to a session. The
+// returned jti identifies the token for revocation.
+func GenerateToken(userID uint, email string, sessionID uint) (string, string, time.Time, error) {
+
+	jti, err := GenerateRandomToken(16)
+
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.AppConfig.ACCESS_TOKEN_TTL)
 
 	payload := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"sid":     sessionID,
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	signed, err := token.SignedString([]byte(config.AppConfig.JWT_SECRET))
+
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return signed, jti, expiresAt, nil
+
+}
+
+// ExtractToken reads the access token from the token cookie, falling back to
+// the Authorization: Bearer header.
+func ExtractToken(ctx *gin.Context) string {
+
+	if token, err := ctx.Cookie("token"); err == nil && token != "" {
+		return token
+	}
+
+	parts := strings.Split(ctx.GetHeader("Authorization"), " ")
+
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// GenerateUnlockToken signs a short-lived token proving the holder entered
+// the password of a protected link. It is bound to the link itself, not its
+// short key, which other domains may reuse, and to the current password hash
+// so changing the password revokes every token issued before.
+func GenerateUnlockToken(urlID uint, domainID uint, passwordHash string, ttl time.Duration) (string, error) {
+
+	payload := jwt.MapClaims{
+		"typ":       "unlock",
+		"url_id":    urlID,
+		"domain_id": domainID,
+		"pwd":       passwordFingerprint(passwordHash),
+		"exp":       time.Now().Add(ttl).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
@@ -22,6 +83,32 @@ func GenerateToken(userID uint, email string) (string, error) {
 
 }
 
+func VerifyUnlockToken(tokenString string, urlID uint, domainID uint, passwordHash string) bool {
+
+	claims, err := VerifyToken(tokenString)
+
+	if err != nil {
+		return false
+	}
+
+	typ, _ := claims["typ"].(string)
+	tokenUrlID, _ := claims["url_id"].(float64)
+	tokenDomainID, _ := claims["domain_id"].(float64)
+	fingerprint, _ := claims["pwd"].(string)
+
+	return typ == "unlock" &&
+		tokenUrlID == float64(urlID) &&
+		tokenDomainID == float64(domainID) &&
+		subtle.ConstantTimeCompare([]byte(fingerprint), []byte(passwordFingerprint(passwordHash))) == 1
+}
+
+// passwordFingerprint identifies a password hash without revealing it. The
+// bcrypt hash is salted, so every password change yields a new fingerprint.
+func passwordFingerprint(passwordHash string) string {
+	sum := sha256.Sum256([]byte(passwordHash))
+	return hex.EncodeToString(sum[:16])
+}
+
 func VerifyToken(tokenString string) (jwt.MapClaims, error) {
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -2,6 +2,8 @@ package validators
 
 import (
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -18,19 +20,92 @@ type SigninValidator struct {
 }
 
 type CreateUrlValidator struct {
-	OriginalURL string `json:"original_url" validate:"required,url"`
-	ShortKey    string `json:"short_key" validate:"omitempty,min=2,max=50,shortkeychars"`
-	Title       string `json:"title" validate:"omitempty,max=255"`
+	OriginalURL string     `json:"original_url" validate:"required,url"`
+	ShortKey    string     `json:"short_key" validate:"omitempty,min=2,max=50,shortkeychars"`
+	Title       string     `json:"title" validate:"omitempty,max=255"`
+	ExpiresAt   *time.Time `json:"expires_at" validate:"omitempty,futuretime"`
+	MaxClicks   *int       `json:"max_clicks" validate:"omitempty,min=1"`
+	Password    string     `json:"password" validate:"omitempty,min=4,max=72"`
+	Domain      string     `json:"domain" validate:"omitempty,fqdn"`
 }
 
 var (
 	validate      = validator.New()
 	validShortKey = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	validLanguage = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
 )
 
 type UpdateUrlValidator struct {
-	ShortKey string `json:"short_url" validate:"omitempty,min=2,max=50,shortkeychars"`
-	Title    string `json:"title" validate:"omitempty,max=255"`
+	ShortKey  string     `json:"short_url" validate:"omitempty,min=2,max=50,shortkeychars"`
+	Title     string     `json:"title" validate:"omitempty,max=255"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,futuretime"`
+	MaxClicks *int       `json:"max_clicks" validate:"omitempty,min=1"`
+	Password  string     `json:"password" validate:"omitempty,min=4,max=72"`
+
+	// Remove the expiry or click limit, a link can't set and clear one at once
+	ClearExpiresAt bool `json:"clear_expires_at" validate:"excluded_with=ExpiresAt"`
+	ClearMaxClicks bool `json:"clear_max_clicks" validate:"excluded_with=MaxClicks"`
+}
+
+type CreateDomainValidator struct {
+	Host string `json:"host" validate:"required,fqdn,max=255"`
+}
+
+type CreateApiKeyValidator struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,unique,dive,oneof=urls:read urls:write analytics:read domains:read domains:write"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,futuretime"`
+}
+
+type CreateWorkspaceValidator struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type AddWorkspaceMemberValidator struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type UpdateWorkspaceMemberValidator struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type CreateWebhookValidator struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=url.created url.updated url.deleted url.clicked"`
+}
+
+// Lists are replaced as a whole when set, position defaults to after the
+// link's last rule
+type CreateRedirectRuleValidator struct {
+	Destination string     `json:"destination" validate:"required,http_url,max=2048"`
+	Position    *int       `json:"position" validate:"omitempty,min=0"`
+	Devices     []string   `json:"devices" validate:"omitempty,unique,dive,oneof=mobile desktop bot"`
+	OS          []string   `json:"os" validate:"omitempty,unique,dive,oneof=ios android windows macos linux chromeos"`
+	Countries   []string   `json:"countries" validate:"omitempty,max=100,unique,dive,min=2,max=60,listitem"`
+	Languages   []string   `json:"languages" validate:"omitempty,max=50,unique,dive,languagetag"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+}
+
+type UpdateRedirectRuleValidator struct {
+	Destination string     `json:"destination" validate:"omitempty,http_url,max=2048"`
+	Position    *int       `json:"position" validate:"omitempty,min=0"`
+	Devices     []string   `json:"devices" validate:"omitempty,unique,dive,oneof=mobile desktop bot"`
+	OS          []string   `json:"os" validate:"omitempty,unique,dive,oneof=ios android windows macos linux chromeos"`
+	Countries   []string   `json:"countries" validate:"omitempty,max=100,unique,dive,min=2,max=60,listitem"`
+	Languages   []string   `json:"languages" validate:"omitempty,max=50,unique,dive,languagetag"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+}
+
+// The refresh token may come from the refresh_token cookie instead
+type RefreshTokenValidator struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UnlockUrlValidator struct {
+	Password string `json:"password" form:"password" validate:"required,max=72"`
 }
 
 func init() {
@@ -38,6 +113,20 @@ func init() {
 		key := fl.Field().String()
 		return validShortKey.MatchString(key)
 	})
+
+	// Rule conditions are stored comma separated
+	_ = validate.RegisterValidation("listitem", func(fl validator.FieldLevel) bool {
+		return !strings.Contains(fl.Field().String(), ",")
+	})
+
+	_ = validate.RegisterValidation("languagetag", func(fl validator.FieldLevel) bool {
+		return validLanguage.MatchString(fl.Field().String())
+	})
+
+	_ = validate.RegisterValidation("futuretime", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
 }
 
 func ValidateSignupData(input SignupValidator) map[string]string {
@@ -56,6 +145,42 @@ func ValidateUpdateUrlData(input UpdateUrlValidator) map[string]string {
 	return validateStruct(input)
 }
 
+func ValidateCreateDomainData(input CreateDomainValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateCreateApiKeyData(input CreateApiKeyValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateCreateWorkspaceData(input CreateWorkspaceValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateAddWorkspaceMemberData(input AddWorkspaceMemberValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateUpdateWorkspaceMemberData(input UpdateWorkspaceMemberValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateCreateWebhookData(input CreateWebhookValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateCreateRedirectRuleData(input CreateRedirectRuleValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateUpdateRedirectRuleData(input UpdateRedirectRuleValidator) map[string]string {
+	return validateStruct(input)
+}
+
+func ValidateUnlockUrlData(input UnlockUrlValidator) map[string]string {
+	return validateStruct(input)
+}
+
 func validateStruct(input interface{}) map[string]string {
 	errs := make(map[string]string)
 	if err := validate.Struct(input); err != nil {
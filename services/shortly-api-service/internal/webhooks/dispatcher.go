@@ -0,0 +1,330 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"shortly-api-service/config"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+)
+
+const (
+	SignatureHeader = "X-Shortly-Signature"
+	TimestampHeader = "X-Shortly-Timestamp"
+	EventHeader     = "X-Shortly-Event"
+	DeliveryHeader  = "X-Shortly-Delivery"
+
+	claimBatchSize = 50
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+)
+
+var errPrivateAddress = errors.New("webhook address is not publicly routable")
+
+// Dispatcher delivers pending webhook deliveries. Deliveries are claimed
+// under a lease that pushes their next attempt forward, so several API
+// instances can run dispatchers against the same table and a crash
+// mid-delivery only delays the retry.
+type Dispatcher struct {
+	deliveries   repository.WebhookRepository
+	client       *http.Client
+	workers      int
+	maxAttempts  int
+	pollInterval time.Duration
+	lease        time.Duration
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+var Deliveries *Dispatcher
+
+func Start(webhooks repository.WebhookRepository) {
+
+	Deliveries = NewDispatcher(
+		webhooks,
+		config.AppConfig.WEBHOOK_WORKERS,
+		config.AppConfig.WEBHOOK_MAX_ATTEMPTS,
+		config.AppConfig.WEBHOOK_TIMEOUT,
+		config.AppConfig.WEBHOOK_POLL_INTERVAL,
+		config.AppConfig.WEBHOOK_ALLOW_PRIVATE,
+	)
+
+	utils.Log.Info("✅ Webhook dispatcher started", "workers", config.AppConfig.WEBHOOK_WORKERS)
+}
+
+func NewDispatcher(deliveries repository.WebhookRepository, workers int, maxAttempts int, timeout time.Duration, pollInterval time.Duration, allowPrivate bool) *Dispatcher {
+
+	workers = max(workers, 1)
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	if !allowPrivate {
+		dialer.Control = rejectPrivateAddress
+	}
+
+	d := &Dispatcher{
+		deliveries: deliveries,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Redirects could point the request at an internal address
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		workers:      workers,
+		maxAttempts:  max(maxAttempts, 1),
+		pollInterval: pollInterval,
+		lease:        batchLease(workers, timeout),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	go d.run()
+
+	return d
+}
+
+// Wake makes the dispatcher poll right away instead of at the next tick.
+func Wake() {
+	if Deliveries == nil {
+		return
+	}
+
+	select {
+	case Deliveries.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops claiming new deliveries and waits for in-flight ones or ctx.
+// Anything left pending is picked up again on the next start.
+func (d *Dispatcher) Close(ctx context.Context) error {
+
+	d.once.Do(func() { close(d.stop) })
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook dispatcher not stopped: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) run() {
+
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := d.claim()
+
+		if err != nil {
+			utils.Log.Error("Failed to claim webhook deliveries", "error", err)
+		}
+
+		if len(deliveries) > 0 {
+			d.deliverAll(deliveries)
+
+			// A full batch probably means more work is due
+			if len(deliveries) == claimBatchSize {
+				select {
+				case <-d.stop:
+					return
+				default:
+					continue
+				}
+			}
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) claim() ([]models.WebhookDelivery, error) {
+	return d.deliveries.ClaimDeliveries(context.Background(), claimBatchSize, d.lease)
+}
+
+func (d *Dispatcher) deliverAll(deliveries []models.WebhookDelivery) {
+
+	sem := make(chan struct{}, d.workers)
+	var wg sync.WaitGroup
+
+	for _, delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(delivery models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.deliver(delivery)
+		}(delivery)
+	}
+
+	wg.Wait()
+}
+
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) {
+
+	attempts := delivery.Attempts + 1
+
+	if delivery.Webhook == nil || !delivery.Webhook.Active {
+		d.finish(delivery.ID, repository.DeliveryOutcome{
+			Status:     models.DeliveryFailed,
+			Attempts:   delivery.Attempts,
+			StatusCode: delivery.LastStatusCode,
+			Error:      "webhook was deleted or disabled",
+		})
+		return
+	}
+
+	statusCode, err := d.send(*delivery.Webhook, delivery)
+
+	if err == nil {
+		deliveredAt := time.Now()
+
+		d.finish(delivery.ID, repository.DeliveryOutcome{
+			Status:      models.DeliverySucceeded,
+			Attempts:    attempts,
+			StatusCode:  statusCode,
+			DeliveredAt: &deliveredAt,
+		})
+		return
+	}
+
+	outcome := repository.DeliveryOutcome{
+		Attempts:   attempts,
+		StatusCode: statusCode,
+		Error:      truncate(err.Error(), 500),
+	}
+
+	if attempts >= d.maxAttempts {
+		outcome.Status = models.DeliveryFailed
+		utils.Log.Warn("Webhook delivery failed permanently", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "attempts", attempts, "error", err)
+	} else {
+		outcome.NextAttemptAt = time.Now().Add(backoff(attempts))
+	}
+
+	d.finish(delivery.ID, outcome)
+}
+
+func (d *Dispatcher) send(hook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewBufferString(delivery.Payload))
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Shortly-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) finish(id uint, outcome repository.DeliveryOutcome) {
+	if err := d.deliveries.FinishDelivery(context.Background(), id, outcome); err != nil {
+		utils.Log.Error("Failed to record webhook delivery", "delivery_id", id, "error", err)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<payload>". Receivers
+// recompute it with their secret and should reject stale timestamps.
+func Sign(secret string, timestamp string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff doubles from 30s up to 6h, with jitter so retries of one outage
+// don't all land at the same time.
+func backoff(attempts int) time.Duration {
+
+	delay := maxBackoff
+
+	if attempts < 20 {
+		delay = min(baseBackoff<<(attempts-1), maxBackoff)
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func rejectPrivateAddress(network string, address string, _ syscall.RawConn) error {
+
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errPrivateAddress
+	}
+
+	return nil
+}
+
+// batchLease covers a full claimed batch: the last rows wait for
+// claimBatchSize/workers deliveries ahead of them, each up to timeout, plus
+// one timeout of slack for recording the results.
+func batchLease(workers int, timeout time.Duration) time.Duration {
+
+	rounds := (claimBatchSize + workers - 1) / workers
+
+	return time.Duration(rounds+1) * timeout
+}
+
+func truncate(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+)
+
+func newTestDispatcher(t *testing.T, status int) (*repository.MemoryWebhookRepository, string) {
+
+	t.Helper()
+
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Header.Get(SignatureHeader) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	repo := repository.NewMemoryWebhookRepository(repository.NewMemoryUrlRepository())
+
+	// The test endpoint listens on loopback
+	d := NewDispatcher(repo, 2, 3, time.Second, 10*time.Millisecond, true)
+	t.Cleanup(func() { d.Close(context.Background()) })
+
+	return repo, endpoint.URL
+}
+
+func enqueue(t *testing.T, repo *repository.MemoryWebhookRepository, hook *models.Webhook) {
+
+	t.Helper()
+
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, hook); err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	err := repo.Enqueue(ctx, []models.WebhookDelivery{{
+		WebhookID:     hook.ID,
+		EventID:       "evt_1",
+		EventType:     EventTest,
+		Payload:       `{"event":"webhook.test"}`,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}})
+
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+}
+
+// waitForDelivery polls the delivery log until check accepts the delivery.
+func waitForDelivery(t *testing.T, repo *repository.MemoryWebhookRepository, webhookID uint, check func(models.WebhookDelivery) bool) models.WebhookDelivery {
+
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for {
+		deliveries, _, _ := repo.Deliveries(context.Background(), webhookID, "", 0, 10)
+
+		if len(deliveries) == 1 && check(deliveries[0]) {
+			return deliveries[0]
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery never got there: %+v", deliveries)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcherRecordsSuccess(t *testing.T) {
+
+	repo, url := newTestDispatcher(t, http.StatusNoContent)
+
+	hook := &models.Webhook{UserID: 1, URL: url, Secret: "s3cret", Events: EventTest, Active: true}
+	enqueue(t, repo, hook)
+
+	delivery := waitForDelivery(t, repo, hook.ID, func(d models.WebhookDelivery) bool {
+		return d.Status == models.DeliverySucceeded
+	})
+
+	if delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusNoContent || delivery.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v, want one attempt answered 204", delivery)
+	}
+}
+
+func TestDispatcherSchedulesRetry(t *testing.T) {
+
+	repo, url := newTestDispatcher(t, http.StatusBadGateway)
+
+	hook := &models.Webhook{UserID: 1, URL: url, Secret: "s3cret", Events: EventTest, Active: true}
+	enqueue(t, repo, hook)
+
+	delivery := waitForDelivery(t, repo, hook.ID, func(d models.WebhookDelivery) bool {
+		return d.Attempts == 1
+	})
+
+	if delivery.Status != models.DeliveryPending || delivery.LastStatusCode != http.StatusBadGateway {
+		t.Fatalf("delivery = %+v, want a pending retry after a 502", delivery)
+	}
+
+	if wait := time.Until(delivery.NextAttemptAt); wait < baseBackoff/2-time.Second {
+		t.Fatalf("retry due in %s, want at least %s", wait, baseBackoff/2)
+	}
+}
+
+func TestDispatcherFailsDisabledWebhook(t *testing.T) {
+
+	repo, url := newTestDispatcher(t, http.StatusNoContent)
+
+	hook := &models.Webhook{UserID: 1, URL: url, Secret: "s3cret", Events: EventTest, Active: false}
+	enqueue(t, repo, hook)
+
+	delivery := waitForDelivery(t, repo, hook.ID, func(d models.WebhookDelivery) bool {
+		return d.Status == models.DeliveryFailed
+	})
+
+	if delivery.Attempts != 0 || delivery.LastError == "" {
+		t.Fatalf("delivery = %+v, want it failed without an attempt", delivery)
+	}
+}
@@ -0,0 +1,243 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"shortly-api-service/internal/dto"
+	"shortly-api-service/internal/models"
+	"shortly-api-service/internal/repository"
+	"shortly-api-service/internal/utils"
+)
+
+const (
+	EventUrlCreated = "url.created"
+	EventUrlUpdated = "url.updated"
+	EventUrlDeleted = "url.deleted"
+	EventUrlClicked = "url.clicked"
+	EventTest       = "webhook.test"
+)
+
+// Click is what the click pipeline reports for a stored click event.
+type Click struct {
+	UrlID     uint
+	ClickedAt time.Time
+	Country   string
+	Device    string
+	Browser   string
+	OS        string
+	Referrer  string
+}
+
+// Emitter turns link activity into pending deliveries. Failures are logged,
+// they never fail the request or flush that triggered the event.
+type Emitter struct {
+	webhooks repository.WebhookRepository
+}
+
+func NewEmitter(webhooks repository.WebhookRepository) *Emitter {
+	return &Emitter{webhooks: webhooks}
+}
+
+// EmitUrlEvent queues eventType for every active webhook of the links'
+// personal space or workspace that subscribed to it. The links must share
+// one owner, as the links of a single request do.
+func (e *Emitter) EmitUrlEvent(eventType string, urls ...models.Url) {
+
+	if len(urls) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+
+	hooks, err := e.webhooks.Subscribers(ctx, eventType, urls[0])
+
+	if err != nil {
+		utils.Log.Error("Failed to load webhooks", "event", eventType, "error", err)
+		return
+	}
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(hooks)*len(urls))
+
+	for _, url := range urls {
+
+		event, err := newEvent(eventType, urlData(url))
+
+		if err != nil {
+			utils.Log.Error("Failed to build webhook event", "event", eventType, "error", err)
+			continue
+		}
+
+		for _, hook := range hooks {
+			deliveries = append(deliveries, event.delivery(hook.ID))
+		}
+	}
+
+	e.enqueue(ctx, deliveries)
+}
+
+// EmitClicks queues url.clicked events for a flushed batch of clicks, with
+// one lookup for the subscribed webhooks of all links in the batch.
+func (e *Emitter) EmitClicks(batch []Click) {
+
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+
+	urlIDs := make([]uint, 0, len(batch))
+	seen := make(map[uint]bool)
+
+	for _, click := range batch {
+		if !seen[click.UrlID] {
+			seen[click.UrlID] = true
+			urlIDs = append(urlIDs, click.UrlID)
+		}
+	}
+
+	subscriptions, err := e.webhooks.ClickSubscriptions(ctx, EventUrlClicked, urlIDs)
+
+	if err != nil {
+		utils.Log.Error("Failed to load click webhooks", "error", err)
+		return
+	}
+
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	hooks := make(map[uint][]uint)
+	shortKeys := make(map[uint]string)
+
+	for _, subscription := range subscriptions {
+		hooks[subscription.UrlID] = append(hooks[subscription.UrlID], subscription.WebhookID)
+		shortKeys[subscription.UrlID] = subscription.ShortKey
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+
+	for _, click := range batch {
+
+		if len(hooks[click.UrlID]) == 0 {
+			continue
+		}
+
+		event, err := newEvent(EventUrlClicked, dto.WebhookClickDataDTO{
+			UrlID:     click.UrlID,
+			ShortKey:  shortKeys[click.UrlID],
+			ClickedAt: click.ClickedAt,
+			Country:   click.Country,
+			Device:    click.Device,
+			Browser:   click.Browser,
+			OS:        click.OS,
+			Referrer:  click.Referrer,
+		})
+
+		if err != nil {
+			utils.Log.Error("Failed to build webhook event", "event", EventUrlClicked, "error", err)
+			continue
+		}
+
+		for _, hookID := range hooks[click.UrlID] {
+			deliveries = append(deliveries, event.delivery(hookID))
+		}
+	}
+
+	e.enqueue(ctx, deliveries)
+}
+
+// EmitTest queues a webhook.test event for a single webhook, regardless of
+// its subscriptions.
+func (e *Emitter) EmitTest(hook models.Webhook) (models.WebhookDelivery, error) {
+
+	event, err := newEvent(EventTest, map[string]interface{}{
+		"webhook_id": hook.ID,
+		"message":    "This is a test event from Shortly",
+	})
+
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	deliveries := []models.WebhookDelivery{event.delivery(hook.ID)}
+
+	if err := e.webhooks.Enqueue(context.Background(), deliveries); err != nil {
+		return deliveries[0], err
+	}
+
+	Wake()
+
+	return deliveries[0], nil
+}
+
+type event struct {
+	id      string
+	kind    string
+	payload string
+}
+
+func newEvent(eventType string, data interface{}) (event, error) {
+
+	id, err := utils.GenerateRandomToken(16)
+
+	if err != nil {
+		return event{}, err
+	}
+
+	payload, err := json.Marshal(dto.WebhookEventDTO{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+
+	if err != nil {
+		return event{}, err
+	}
+
+	return event{id: id, kind: eventType, payload: string(payload)}, nil
+}
+
+func (e event) delivery(webhookID uint) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       e.id,
+		EventType:     e.kind,
+		Payload:       e.payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+func (e *Emitter) enqueue(ctx context.Context, deliveries []models.WebhookDelivery) {
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := e.webhooks.Enqueue(ctx, deliveries); err != nil {
+		utils.Log.Error("Failed to queue webhook deliveries", "count", len(deliveries), "error", err)
+		return
+	}
+
+	Wake()
+}
+
+func urlData(url models.Url) dto.WebhookUrlDataDTO {
+	return dto.WebhookUrlDataDTO{
+		ID:          url.ID,
+		OriginalURL: url.OriginalURL,
+		ShortKey:    url.ShortKey,
+		DomainID:    url.DomainID,
+		WorkspaceID: url.WorkspaceID,
+		Title:       url.Title,
+		Clicks:      url.Clicks,
+		ExpiresAt:   url.ExpiresAt,
+	}
+}
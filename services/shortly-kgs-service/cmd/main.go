@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/auth"
 	"shortly-kgs-service/internal/database"
+	"shortly-kgs-service/internal/health"
+	"shortly-kgs-service/internal/kgs"
+	"shortly-kgs-service/internal/metrics"
 	"shortly-kgs-service/internal/redis"
 	"shortly-kgs-service/internal/service"
 	"shortly-kgs-service/internal/utils"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"shortly-proto/gen/key"
 )
@@ -32,14 +43,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	defer database.CloseMongoDB()
+	if err := database.EnsureIndexes(); err != nil {
+		os.Exit(1)
+	}
+
+	if err := kgs.InitPools(config.AppConfig.KEY_POOLS); err != nil {
+		utils.Log.Error("❌ Invalid key pool configuration", "error", err)
+		os.Exit(1)
+	}
 
 	if err := redis.ConnectRedis(); err != nil {
 		utils.Log.Error("❌ Failed to connect to Redis", "error", err)
 		os.Exit(1)
 	}
 
-	defer redis.RedisClient.Close()
+	// Re-queue expired reservations and keys a Redis loss dropped
+	kgs.StartReconciler(config.AppConfig.KEY_RECONCILE_INTERVAL)
+
+	// Keys are generated in the background, never inside a request
+	kgs.StartRefiller(
+		config.AppConfig.KEY_POOL_LOW_WATERMARK,
+		config.AppConfig.KEY_POOL_HIGH_WATERMARK,
+		config.AppConfig.KEY_REFILL_INTERVAL,
+		config.AppConfig.KEY_REFILL_LOCK_TTL,
+	)
 
 	listener, err := net.Listen("tcp", ":"+config.AppConfig.PORT)
 
@@ -48,30 +75,117 @@ func main() {
 		os.Exit(1)
 	}
 
-	grpcServer := grpc.NewServer()
+	metrics.RegisterQueueLength(kgs.QueueLengths)
+
+	authenticator := auth.NewAuthenticator(config.AppConfig.AUTH_TOKEN, config.AppConfig.AUTH_ALLOWED_CLIENTS)
+
+	// Rejected calls still show up in the request metrics
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), authenticator.UnaryServerInterceptor()),
+	}
+
+	if config.AppConfig.TLS_CERT_FILE != "" {
+
+		creds, err := auth.ServerCredentials(config.AppConfig.TLS_CERT_FILE, config.AppConfig.TLS_KEY_FILE, config.AppConfig.TLS_CLIENT_CA_FILE)
+
+		if err != nil {
+			utils.Log.Error("❌ Failed to load TLS credentials", "error", err)
+			os.Exit(1)
+		}
+
+		serverOptions = append(serverOptions, grpc.Creds(creds))
+
+		utils.Log.Info("✅ gRPC TLS enabled", "mtls", config.AppConfig.TLS_CLIENT_CA_FILE != "")
+	} else {
+		utils.Log.Warn("gRPC server is running without TLS")
+	}
+
+	if !authenticator.Enabled() {
+		utils.Log.Warn("gRPC callers are not authenticated, set AUTH_TOKEN or AUTH_ALLOWED_CLIENTS")
+	}
+
+	grpcServer := grpc.NewServer(serverOptions...)
 
 	key.RegisterKeyServiceServer(grpcServer, service.NewKeyServiceServer())
 
-	go func() {
-		http.HandleFunc("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"success": true, "message": "KGS server is up and running"}`))
-		})
+	// Standard gRPC health checking protocol, refreshed from the dependency checks
+	healthpb.RegisterHealthServer(grpcServer, health.Server)
+	health.Watch()
+
+	mux := http.NewServeMux()
 
-		healthPort := ":8081"
-		utils.Log.Info("✅ Health check server running on " + healthPort)
+	mux.HandleFunc("/api/v1/health", health.Live)
+	mux.HandleFunc("/api/v1/health/live", health.Live)
+	mux.HandleFunc("/api/v1/health/ready", health.Ready)
+
+	// Prometheus scrape endpoint
+	mux.Handle("/metrics", promhttp.Handler())
+
+	healthServer := &http.Server{
+		Addr:    ":8081",
+		Handler: mux,
+	}
 
-		if err := http.ListenAndServe(healthPort, nil); err != nil {
+	go func() {
+		utils.Log.Info("✅ Health check and metrics server running on " + healthServer.Addr)
+
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			utils.Log.Error("❌ Failed to start health check server", "error", err)
 		}
 	}()
 
-	utils.Log.Info("Shortly KGS Service is running...")
+	go func() {
+		utils.Log.Info("Shortly KGS Service is running...")
 
-	if err := grpcServer.Serve(listener); err != nil {
-		utils.Log.Error("❌ Failed to serve gRPC server", "error", err)
-		os.Exit(1)
+		if err := grpcServer.Serve(listener); err != nil {
+			utils.Log.Error("❌ Failed to serve gRPC server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	utils.Log.Info("Shutting down KGS server", "timeout", config.AppConfig.SHUTDOWN_TIMEOUT)
+
+	health.Shutdown()
+
+	// GracefulStop refuses new calls and waits for in-flight ones, which
+	// may still be moving keys between Redis and MongoDB
+	stopped := make(chan struct{})
+
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(config.AppConfig.SHUTDOWN_TIMEOUT):
+		utils.Log.Error("In-flight gRPC calls did not finish in time, forcing shutdown")
+		grpcServer.Stop()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		utils.Log.Error("Health check server forced to shutdown", "error", err)
 	}
 
+	// Let a running refill finish its chunk, the lock expires on its own otherwise
+	if err := kgs.Refill.Stop(shutdownCtx); err != nil {
+		utils.Log.Error("Key pool refiller did not stop in time", "error", err)
+	}
+
+	if err := kgs.Recovery.Stop(shutdownCtx); err != nil {
+		utils.Log.Error("Key pool reconciler did not stop in time", "error", err)
+	}
+
+	redis.CloseRedis()
+	database.CloseMongoDB()
+
+	utils.Log.Info("KGS server stopped")
+
 }
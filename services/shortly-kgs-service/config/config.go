@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,32 @@ type Config struct {
 	MONGO_URI     string
 	MONGO_DB_NAME string
 	REDIS_ADDR    string
+	KEY_POOLS     string
+
+	// Refill a pool's queue up to the high watermark once it drops below the low one
+	KEY_POOL_LOW_WATERMARK  int
+	KEY_POOL_HIGH_WATERMARK int
+	KEY_REFILL_INTERVAL     time.Duration
+	KEY_REFILL_LOCK_TTL     time.Duration
+
+	// Popped keys not marked as used within the lease go back to the queue
+	KEY_RESERVATION_LEASE  time.Duration
+	KEY_RECONCILE_INTERVAL time.Duration
+
+	// Serve gRPC over TLS when a certificate is set, and require client
+	// certificates signed by TLS_CLIENT_CA_FILE when that is set too
+	TLS_CERT_FILE      string
+	TLS_KEY_FILE       string
+	TLS_CLIENT_CA_FILE string
+
+	// Callers must send AUTH_TOKEN or present a client certificate named in
+	// AUTH_ALLOWED_CLIENTS, no check when both are empty
+	AUTH_TOKEN           string
+	AUTH_ALLOWED_CLIENTS string
+
+	HEALTH_CHECK_TIMEOUT  time.Duration
+	HEALTH_CHECK_INTERVAL time.Duration
+	SHUTDOWN_TIMEOUT      time.Duration
 }
 
 var AppConfig Config
@@ -29,6 +57,42 @@ func Init() error {
 		MONGO_URI:     GetEnvOrPanic("MONGO_URI"),
 		MONGO_DB_NAME: GetEnvOrPanic("MONGO_DB_NAME"),
 		REDIS_ADDR:    GetEnvOrPanic("REDIS_ADDR"),
+		KEY_POOLS:     GetEnvOrDefault("KEY_POOLS", "default:6:base62:random"),
+
+		KEY_POOL_LOW_WATERMARK:  GetEnvAsInt("KEY_POOL_LOW_WATERMARK", 2000),
+		KEY_POOL_HIGH_WATERMARK: GetEnvAsInt("KEY_POOL_HIGH_WATERMARK", 10000),
+		KEY_REFILL_INTERVAL:     GetEnvAsDuration("KEY_REFILL_INTERVAL", 2*time.Second),
+		KEY_REFILL_LOCK_TTL:     GetEnvAsDuration("KEY_REFILL_LOCK_TTL", 30*time.Second),
+
+		KEY_RESERVATION_LEASE:  GetEnvAsDuration("KEY_RESERVATION_LEASE", 30*time.Second),
+		KEY_RECONCILE_INTERVAL: GetEnvAsDuration("KEY_RECONCILE_INTERVAL", time.Minute),
+
+		TLS_CERT_FILE:      os.Getenv("TLS_CERT_FILE"),
+		TLS_KEY_FILE:       os.Getenv("TLS_KEY_FILE"),
+		TLS_CLIENT_CA_FILE: os.Getenv("TLS_CLIENT_CA_FILE"),
+
+		AUTH_TOKEN:           os.Getenv("AUTH_TOKEN"),
+		AUTH_ALLOWED_CLIENTS: os.Getenv("AUTH_ALLOWED_CLIENTS"),
+
+		HEALTH_CHECK_TIMEOUT:  GetEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		HEALTH_CHECK_INTERVAL: GetEnvAsDuration("HEALTH_CHECK_INTERVAL", 5*time.Second),
+		SHUTDOWN_TIMEOUT:      GetEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+	}
+
+	if AppConfig.KEY_POOL_LOW_WATERMARK < 1 || AppConfig.KEY_POOL_HIGH_WATERMARK <= AppConfig.KEY_POOL_LOW_WATERMARK {
+		return fmt.Errorf("KEY_POOL_HIGH_WATERMARK (%d) must be greater than KEY_POOL_LOW_WATERMARK (%d)", AppConfig.KEY_POOL_HIGH_WATERMARK, AppConfig.KEY_POOL_LOW_WATERMARK)
+	}
+
+	if (AppConfig.TLS_CERT_FILE == "") != (AppConfig.TLS_KEY_FILE == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if AppConfig.TLS_CLIENT_CA_FILE != "" && AppConfig.TLS_CERT_FILE == "" {
+		return fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if AppConfig.AUTH_ALLOWED_CLIENTS != "" && AppConfig.TLS_CLIENT_CA_FILE == "" {
+		return fmt.Errorf("AUTH_ALLOWED_CLIENTS requires TLS_CLIENT_CA_FILE to verify client certificates")
 	}
 
 	return nil
@@ -45,3 +109,46 @@ func GetEnvOrPanic(key string) string {
 	return value
 
 }
+
+func GetEnvOrDefault(key string, fallback string) string {
+
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		panic(fmt.Sprintf("❌ Invalid duration for environment variable %s: %s", key, value))
+	}
+
+	return duration
+}
+
+func GetEnvAsInt(key string, fallback int) int {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		panic(fmt.Sprintf("❌ Invalid integer for environment variable %s: %s", key, value))
+	}
+
+	return parsed
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TokenHeader is the metadata key callers send the service token in, as
+// "Bearer <token>".
+const TokenHeader = "authorization"
+
+// ServerCredentials loads the KGS certificate and key. When clientCAFile is
+// set every caller must present a certificate signed by one of its CAs
+// (mTLS), otherwise the connection is plain server side TLS.
+func ServerCredentials(certFile string, keyFile string, clientCAFile string) (credentials.TransportCredentials, error) {
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("load KGS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+
+		pool, err := loadCertPool(clientCAFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+
+	pem, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+
+	return pool, nil
+}
+
+// Authenticator decides whether a caller may use the key service. A caller
+// is let in when it sends the shared service token, or when it presented a
+// verified client certificate whose common name or a DNS name is in the
+// allowed clients. With neither a token nor allowed clients configured every
+// caller is accepted, which keeps local setups working as before.
+type Authenticator struct {
+	token   string
+	clients []string
+}
+
+func NewAuthenticator(token string, allowedClients string) *Authenticator {
+
+	clients := make([]string, 0)
+
+	for _, client := range strings.Split(allowedClients, ",") {
+		if client = strings.TrimSpace(client); client != "" {
+			clients = append(clients, client)
+		}
+	}
+
+	return &Authenticator{
+		token:   token,
+		clients: clients,
+	}
+}
+
+// Enabled reports whether callers are checked at all.
+func (a *Authenticator) Enabled() bool {
+	return a.token != "" || len(a.clients) > 0
+}
+
+// UnaryServerInterceptor rejects unauthenticated calls with Unauthenticated.
+// The gRPC health service stays open so probes and load balancers can still
+// check KGS without credentials.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		if !a.Enabled() || strings.HasPrefix(info.FullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+			return handler(ctx, req)
+		}
+
+		if err := a.Authenticate(ctx); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// Authenticate checks the client certificate first, then the token.
+func (a *Authenticator) Authenticate(ctx context.Context) error {
+
+	if len(a.clients) > 0 {
+		if identity, ok := peerIdentity(ctx); ok && slices.ContainsFunc(identity, a.allowed) {
+			return nil
+		}
+	}
+
+	if a.token != "" && a.validToken(ctx) {
+		return nil
+	}
+
+	return errors.New("missing or invalid service credentials")
+}
+
+func (a *Authenticator) allowed(name string) bool {
+	return slices.Contains(a.clients, name)
+}
+
+func (a *Authenticator) validToken(ctx context.Context) bool {
+
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if !ok {
+		return false
+	}
+
+	for _, value := range md.Get(TokenHeader) {
+
+		token, found := strings.CutPrefix(value, "Bearer ")
+
+		if found && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// peerIdentity returns the common name and DNS names of the verified client
+// certificate, if the caller presented one.
+func peerIdentity(ctx context.Context) ([]string, bool) {
+
+	p, ok := peer.FromContext(ctx)
+
+	if !ok {
+		return nil, false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+
+	identity := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+
+	return identity, true
+}
@@ -0,0 +1,309 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const serverName = "kgs.internal"
+
+// testCA signs the certificates of one test, everything lives in memory.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+
+	t.Helper()
+
+	privateKey := newKey(t)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  privateKey,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue signs a leaf certificate for commonName, usable as server or client.
+func (ca *testCA) issue(t *testing.T, commonName string, dnsNames ...string) tls.Certificate {
+
+	t.Helper()
+
+	privateKey := newKey(t)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	if err != nil {
+		t.Fatalf("serial: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &privateKey.PublicKey, ca.key)
+
+	if err != nil {
+		t.Fatalf("issue %s: %v", commonName, err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	return privateKey
+}
+
+// writePEM stores cert as cert.pem/key.pem in dir, the way ServerCredentials
+// reads them from disk.
+func writePEM(t *testing.T, dir string, cert tls.Certificate) (string, string) {
+
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+
+	t.Helper()
+
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+type keyService struct {
+	key.UnimplementedKeyServiceServer
+}
+
+func (keyService) GetKey(ctx context.Context, req *key.Empty) (*key.KeyResponse, error) {
+	return &key.KeyResponse{Key: "abc123"}, nil
+}
+
+// startServer runs KGS auth over mTLS with the given CA trusted for clients.
+func startServer(t *testing.T, ca *testCA, authenticator *Authenticator) string {
+
+	t.Helper()
+
+	dir := t.TempDir()
+
+	certFile, keyFile := writePEM(t, dir, ca.issue(t, serverName, serverName))
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem)
+
+	creds, err := ServerCredentials(certFile, keyFile, caFile)
+
+	if err != nil {
+		t.Fatalf("server credentials: %v", err)
+	}
+
+	server := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(authenticator.UnaryServerInterceptor()))
+
+	key.RegisterKeyServiceServer(server, keyService{})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+// dial connects with serverCA trusted and client presented as certificate.
+func dial(t *testing.T, address string, serverCA *testCA, client tls.Certificate) *grpc.ClientConn {
+
+	t.Helper()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCA.cert)
+
+	creds := credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		ServerName:   serverName,
+		Certificates: []tls.Certificate{client},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func getKey(conn *grpc.ClientConn, token string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, TokenHeader, "Bearer "+token)
+	}
+
+	_, err := key.NewKeyServiceClient(conn).GetKey(ctx, &key.Empty{})
+
+	return err
+}
+
+func TestServiceToken(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	address := startServer(t, ca, NewAuthenticator("s3cret", ""))
+	conn := dial(t, address, ca, ca.issue(t, "shortly-api"))
+
+	if err := getKey(conn, "s3cret"); err != nil {
+		t.Fatalf("right token: %v", err)
+	}
+
+	for name, token := range map[string]string{"wrong token": "guess", "missing token": ""} {
+		if err := getKey(conn, token); status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("%s: got %v, want Unauthenticated", name, err)
+		}
+	}
+}
+
+func TestAllowedClientCertificate(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	address := startServer(t, ca, NewAuthenticator("", "shortly-api"))
+
+	if err := getKey(dial(t, address, ca, ca.issue(t, "shortly-api")), ""); err != nil {
+		t.Fatalf("allowed client: %v", err)
+	}
+
+	// A DNS name of the certificate counts as well as the common name
+	if err := getKey(dial(t, address, ca, ca.issue(t, "api-7f9c", "shortly-api")), ""); err != nil {
+		t.Fatalf("allowed DNS name: %v", err)
+	}
+
+	if err := getKey(dial(t, address, ca, ca.issue(t, "intruder")), ""); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("unknown client: got %v, want Unauthenticated", err)
+	}
+}
+
+func TestUntrustedClientCA(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	rogue := newTestCA(t, "rogue CA")
+
+	address := startServer(t, ca, NewAuthenticator("s3cret", "shortly-api"))
+
+	// The handshake fails before any token or name is looked at
+	err := getKey(dial(t, address, ca, rogue.issue(t, "shortly-api")), "s3cret")
+
+	if err == nil {
+		t.Fatal("client certificate from an untrusted CA was accepted")
+	}
+
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+}
+
+func TestHealthIsExempt(t *testing.T) {
+
+	ca := newTestCA(t, "shortly test CA")
+	address := startServer(t, ca, NewAuthenticator("s3cret", "shortly-api"))
+	conn := dial(t, address, ca, ca.issue(t, "load-balancer"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+
+	if response.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("health status %v, want SERVING", response.Status)
+	}
+
+	// The same caller is still kept out of the key service
+	if err := getKey(conn, ""); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("key call: got %v, want Unauthenticated", err)
+	}
+}
@@ -2,5 +2,5 @@ package constants
 
 const RedisQueueName = "shortly-kgs-redis-queue"
 const RedisCounter = "shortly-kgs-queue-counter"
-const QueueLength = 200
-const KeyCount = 1000
\ No newline at end of file
+const KeyCount = 1000
+const MaxKeysPerRequest = 1000
\ No newline at end of file
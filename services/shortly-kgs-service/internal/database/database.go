@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/metrics"
 	"shortly-kgs-service/internal/utils"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -18,7 +20,7 @@ func ConnectDB() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	clientOpts := options.Client().ApplyURI(config.AppConfig.MONGO_URI)
+	clientOpts := options.Client().ApplyURI(config.AppConfig.MONGO_URI).SetMonitor(metrics.MongoMonitor())
 
 	client, err := mongo.Connect(ctx, clientOpts)
 
@@ -38,6 +40,72 @@ func ConnectDB() error {
 	return nil
 }
 
+// How long the one-off removal of duplicate keys may take on upgrade
+const dedupeTimeout = 10 * time.Minute
+
+// EnsureIndexes creates the unique index that rejects duplicate short keys
+// and the ones behind reservations and reconciliation. Keys stored twice
+// before the unique index existed are removed first, or building it fails.
+func EnsureIndexes() error {
+
+	collection := MongoClient.Database(config.AppConfig.MONGO_DB_NAME).Collection("shortkeys")
+
+	if err := dedupeBeforeIndex(collection); err != nil {
+		utils.Log.Error("❌ Failed to remove duplicate short keys", "error", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	if err := createIndexes(ctx, collection); err != nil {
+		utils.Log.Error("❌ Failed to create shortkeys index", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// dedupeBeforeIndex removes duplicate keys unless the unique index is
+// already in place, which is the case on every start after the first.
+func dedupeBeforeIndex(collection *mongo.Collection) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), dedupeTimeout)
+	defer cancel()
+
+	unique, err := hasUniqueKeyIndex(ctx, collection)
+
+	if err != nil || unique {
+		return err
+	}
+
+	_, err = DedupeKeys(ctx, collection)
+
+	return err
+}
+
+func createIndexes(ctx context.Context, collection *mongo.Collection) error {
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "leasedUntil", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "reservationId", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "pool", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+		},
+	})
+
+	return err
+}
+
 func CloseMongoDB() {
 	if MongoClient != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"shortly-kgs-service/internal/models"
+	"shortly-kgs-service/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Documents deleted per DeleteMany while deduplicating
+const dedupeBatchSize = 1000
+
+// keyCopy is one of the documents holding the same key.
+type keyCopy struct {
+	ID        primitive.ObjectID `bson:"id"`
+	Status    string             `bson:"status"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// How far along a key is, the copy furthest along is the one kept
+var statusRank = map[string]int{
+	models.Used:      3,
+	models.Reserved:  2,
+	models.Available: 1,
+}
+
+// DedupeKeys deletes all but one document of every key stored more than
+// once, as the generator could insert before keys were unique. It must run
+// before the unique index on key is built, which fails over duplicates.
+//
+// The copy kept is the one furthest along: a key already handed out stays
+// used, and a copy of it still queued in Redis then fails to be claimed and
+// is skipped. Ties keep the oldest document. Returns how many were deleted.
+func DedupeKeys(ctx context.Context, collection *mongo.Collection) (int64, error) {
+
+	cursor, err := collection.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{
+			"_id":    "$key",
+			"count":  bson.M{"$sum": 1},
+			"copies": bson.M{"$push": bson.M{"id": "$_id", "status": "$status", "createdAt": "$createdAt"}},
+		}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var deleted int64
+	extra := make([]primitive.ObjectID, 0, dedupeBatchSize)
+
+	flush := func() error {
+
+		if len(extra) == 0 {
+			return nil
+		}
+
+		res, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": extra}})
+
+		if err != nil {
+			return err
+		}
+
+		deleted += res.DeletedCount
+		extra = extra[:0]
+
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+
+		var group struct {
+			Key    string    `bson:"_id"`
+			Copies []keyCopy `bson:"copies"`
+		}
+
+		if err := cursor.Decode(&group); err != nil {
+			return deleted, err
+		}
+
+		kept := keeper(group.Copies)
+
+		for i, copy := range group.Copies {
+			if i != kept {
+				extra = append(extra, copy.ID)
+			}
+		}
+
+		if len(extra) >= dedupeBatchSize {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return deleted, err
+	}
+
+	if err := flush(); err != nil {
+		return deleted, err
+	}
+
+	if deleted > 0 {
+		utils.Log.Warn("Removed duplicate short keys", "deleted", deleted)
+	}
+
+	return deleted, nil
+}
+
+// keeper returns the index of the copy to keep.
+func keeper(copies []keyCopy) int {
+
+	kept := 0
+
+	for i, candidate := range copies[1:] {
+
+		current := copies[kept]
+
+		switch {
+		case statusRank[candidate.Status] > statusRank[current.Status]:
+			kept = i + 1
+		case statusRank[candidate.Status] == statusRank[current.Status] && candidate.CreatedAt.Before(current.CreatedAt):
+			kept = i + 1
+		}
+	}
+
+	return kept
+}
+
+// hasUniqueKeyIndex tells whether the unique index on key already exists, in
+// which case there can't be duplicates to remove.
+func hasUniqueKeyIndex(ctx context.Context, collection *mongo.Collection) (bool, error) {
+
+	cursor, err := collection.Indexes().List(ctx)
+
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+
+		var index struct {
+			Key    bson.D `bson:"key"`
+			Unique bool   `bson:"unique"`
+		}
+
+		if err := cursor.Decode(&index); err != nil {
+			return false, err
+		}
+
+		if index.Unique && len(index.Key) == 1 && index.Key[0].Key == "key" {
+			return true, nil
+		}
+	}
+
+	return false, cursor.Err()
+}
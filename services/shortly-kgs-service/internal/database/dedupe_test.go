@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"shortly-kgs-service/internal/models"
+	"shortly-kgs-service/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	if utils.Log == nil {
+		utils.InitLogger()
+	}
+}
+
+func TestKeeper(t *testing.T) {
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	cases := []struct {
+		name   string
+		copies []keyCopy
+		want   int
+	}{
+		{"used wins over available", []keyCopy{{Status: models.Available, CreatedAt: older}, {Status: models.Used, CreatedAt: newer}}, 1},
+		{"reserved wins over available", []keyCopy{{Status: models.Reserved, CreatedAt: newer}, {Status: models.Available, CreatedAt: older}}, 0},
+		{"used wins over reserved", []keyCopy{{Status: models.Reserved}, {Status: models.Available}, {Status: models.Used}}, 2},
+		{"oldest of the same status", []keyCopy{{Status: models.Available, CreatedAt: newer}, {Status: models.Available, CreatedAt: older}}, 1},
+	}
+
+	for _, c := range cases {
+		if got := keeper(c.copies); got != c.want {
+			t.Fatalf("%s: kept copy %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDedupeKeysDeletesTheExtraCopies(t *testing.T) {
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicates", func(mt *mtest.T) {
+
+		used := primitive.NewObjectID()
+		spare := primitive.NewObjectID()
+		third := primitive.NewObjectID()
+
+		ns := mt.Coll.Database().Name() + "." + mt.Coll.Name()
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "abc1234"},
+				{Key: "count", Value: 3},
+				{Key: "copies", Value: bson.A{
+					bson.D{{Key: "id", Value: spare}, {Key: "status", Value: models.Available}},
+					bson.D{{Key: "id", Value: used}, {Key: "status", Value: models.Used}},
+					bson.D{{Key: "id", Value: third}, {Key: "status", Value: models.Available}},
+				}},
+			}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}),
+		)
+
+		deleted, err := DedupeKeys(context.Background(), mt.Coll)
+
+		if err != nil {
+			mt.Fatalf("dedupe: %v", err)
+		}
+
+		if deleted != 2 {
+			mt.Fatalf("deleted %d, want 2", deleted)
+		}
+
+		started := mt.GetAllStartedEvents()
+		deletion := started[len(started)-1]
+
+		if deletion.CommandName != "delete" {
+			mt.Fatalf("last command %s, want delete", deletion.CommandName)
+		}
+
+		filter := deletion.Command.Lookup("deletes", "0", "q", "_id", "$in").Array().String()
+
+		for _, id := range []primitive.ObjectID{spare, third} {
+			if !strings.Contains(filter, id.Hex()) {
+				mt.Fatalf("copy %s not deleted: %s", id.Hex(), filter)
+			}
+		}
+
+		if strings.Contains(filter, used.Hex()) {
+			mt.Fatalf("the used copy was deleted: %s", filter)
+		}
+	})
+}
+
+// TestEnsureIndexesOverDuplicates runs the upgrade path against a real
+// server, set MONGO_TEST_URI to run it.
+func TestEnsureIndexesOverDuplicates(t *testing.T) {
+
+	uri := os.Getenv("MONGO_TEST_URI")
+
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database("shortly_kgs_test").Collection("shortkeys_" + primitive.NewObjectID().Hex())
+	defer collection.Drop(ctx)
+
+	now := time.Now().UTC()
+
+	// What the generator could leave behind before keys were unique
+	_, err = collection.InsertMany(ctx, []any{
+		models.ShortKey{ID: primitive.NewObjectID(), Key: "dup0001", Status: models.Available, CreatedAt: now},
+		models.ShortKey{ID: primitive.NewObjectID(), Key: "dup0001", Status: models.Used, CreatedAt: now.Add(time.Second)},
+		models.ShortKey{ID: primitive.NewObjectID(), Key: "dup0002", Status: models.Available, CreatedAt: now.Add(2 * time.Second)},
+		models.ShortKey{ID: primitive.NewObjectID(), Key: "dup0002", Status: models.Available, CreatedAt: now},
+		models.ShortKey{ID: primitive.NewObjectID(), Key: "uniq001", Status: models.Available, CreatedAt: now},
+	})
+
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	if err := createIndexes(ctx, collection); err == nil {
+		t.Fatal("unique index built over duplicates")
+	}
+
+	if err := dedupeBeforeIndex(collection); err != nil {
+		t.Fatalf("dedupe: %v", err)
+	}
+
+	if err := createIndexes(ctx, collection); err != nil {
+		t.Fatalf("indexes after dedupe: %v", err)
+	}
+
+	if count, _ := collection.CountDocuments(ctx, bson.M{}); count != 3 {
+		t.Fatalf("%d keys left, want 3", count)
+	}
+
+	var kept models.ShortKey
+
+	if err := collection.FindOne(ctx, bson.M{"key": "dup0001"}).Decode(&kept); err != nil || kept.Status != models.Used {
+		t.Fatalf("dup0001 kept as %q (%v), want the used copy", kept.Status, err)
+	}
+
+	if err := collection.FindOne(ctx, bson.M{"key": "dup0002"}).Decode(&kept); err != nil || !kept.CreatedAt.Equal(now.Truncate(time.Millisecond)) {
+		t.Fatalf("dup0002 kept from %s (%v), want the oldest copy", kept.CreatedAt, err)
+	}
+
+	// Once the index exists there is nothing left to scan
+	if err := dedupeBeforeIndex(collection); err != nil {
+		t.Fatalf("second start: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/database"
+	"shortly-kgs-service/internal/kgs"
+	"shortly-kgs-service/internal/redis"
+	"shortly-kgs-service/internal/utils"
+)
+
+// RunDriftReport prints, per pool, how the Redis queue and the shortkeys
+// collection disagree. It exits with status 1 when they drift, so it can
+// run from a cron job or CI check. With -fix it runs a reconciliation pass
+// first.
+func RunDriftReport() {
+
+	fix := flag.Bool("fix", false, "re-queue expired reservations and missing keys before reporting")
+	flag.Parse()
+
+	utils.InitLogger()
+
+	if err := config.Init(); err != nil {
+		utils.Log.Error("❌ Failed to load env variables", "error", err)
+		os.Exit(1)
+	}
+
+	if err := database.ConnectDB(); err != nil {
+		utils.Log.Error("❌ Failed to connect to MongoDB", "error", err)
+		os.Exit(1)
+	}
+
+	defer database.CloseMongoDB()
+
+	if err := redis.ConnectRedis(); err != nil {
+		utils.Log.Error("❌ Failed to connect to Redis", "error", err)
+		os.Exit(1)
+	}
+
+	defer redis.CloseRedis()
+
+	if err := kgs.InitPools(config.AppConfig.KEY_POOLS); err != nil {
+		utils.Log.Error("❌ Invalid key pool configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	if *fix {
+		if err := kgs.Reconcile(ctx); err != nil {
+			utils.Log.Error("❌ Reconciliation failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(table, "POOL\tQUEUED\tAVAILABLE\tRESERVED\tEXPIRED\tUSED\tMISSING FROM QUEUE\tQUEUED NOT AVAILABLE\tDUPLICATES")
+
+	drift := false
+
+	for _, pool := range kgs.Pools() {
+
+		report, err := kgs.CheckDrift(ctx, pool)
+
+		if err != nil {
+			utils.Log.Error("❌ Failed to check drift", "pool", pool.Name, "error", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			report.Pool,
+			report.QueueLength,
+			report.Available,
+			report.Reserved,
+			report.ExpiredReservations,
+			report.Used,
+			len(report.MissingFromQueue),
+			report.NotAvailable,
+			report.Duplicates,
+		)
+
+		drift = drift || report.HasDrift()
+	}
+
+	table.Flush()
+
+	if drift {
+		os.Exit(1)
+	}
+}
+
+func main() {
+	RunDriftReport()
+}
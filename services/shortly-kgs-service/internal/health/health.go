@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/database"
+	"shortly-kgs-service/internal/redis"
+	"shortly-kgs-service/internal/utils"
+	"shortly-proto/gen/key"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Dependency states reported by Ready
+const (
+	dependencyUp   = "up"
+	dependencyDown = "down"
+)
+
+// DependencyStatus is the readiness result of a single dependency.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+var checks = map[string]func(ctx context.Context) error{
+	"mongodb": func(ctx context.Context) error {
+		return database.MongoClient.Ping(ctx, nil)
+	},
+	"redis_queue": func(ctx context.Context) error {
+		return redis.RedisClient.Ping(ctx).Err()
+	},
+}
+
+// Server implements the standard gRPC health checking protocol. Both the
+// overall ("") and the key.KeyService status follow the dependency checks.
+var Server = health.NewServer()
+
+var stopWatch = make(chan struct{})
+
+// Check pings every dependency in parallel, each with HEALTH_CHECK_TIMEOUT.
+func Check(ctx context.Context) (map[string]DependencyStatus, bool) {
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		ready   = true
+		results = make(map[string]DependencyStatus, len(checks))
+	)
+
+	for name, check := range checks {
+		wg.Add(1)
+
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, config.AppConfig.HEALTH_CHECK_TIMEOUT)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+
+			result := DependencyStatus{
+				Status:    dependencyUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			if err != nil {
+				result.Status = dependencyDown
+				result.Error = err.Error()
+				utils.Log.Warn("Readiness check failed", "dependency", name, "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[name] = result
+			ready = ready && err == nil
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	return results, ready
+}
+
+// Watch refreshes the gRPC serving status every HEALTH_CHECK_INTERVAL.
+func Watch() {
+
+	update := func() {
+
+		status := healthpb.HealthCheckResponse_SERVING
+
+		if _, ready := Check(context.Background()); !ready {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		Server.SetServingStatus("", status)
+		Server.SetServingStatus(key.KeyService_ServiceDesc.ServiceName, status)
+	}
+
+	update()
+
+	go func() {
+		ticker := time.NewTicker(config.AppConfig.HEALTH_CHECK_INTERVAL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopWatch:
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+}
+
+// Shutdown reports NOT_SERVING for good so clients move to other instances
+// while in-flight calls finish.
+func Shutdown() {
+	close(stopWatch)
+	Server.Shutdown()
+}
+
+// Live only tells that the process is able to answer.
+func Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "KGS server is up and running",
+	})
+}
+
+// Ready pings every dependency and answers 503 when one is down.
+func Ready(w http.ResponseWriter, r *http.Request) {
+
+	results, ready := Check(r.Context())
+
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"success": false,
+			"data":    results,
+			"error":   "One or more dependencies are unavailable",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    results,
+		"message": "KGS server is ready",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		utils.Log.Error("Failed to write health response", "error", err)
+	}
+}
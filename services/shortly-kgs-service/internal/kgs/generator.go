@@ -2,58 +2,159 @@ package kgs
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"shortly-kgs-service/config"
-	"shortly-kgs-service/internal/constants"
 	"shortly-kgs-service/internal/database"
+	"shortly-kgs-service/internal/metrics"
 	"shortly-kgs-service/internal/models"
 	"shortly-kgs-service/internal/redis"
 	"shortly-kgs-service/internal/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func GenerateKeys(count int) error {
+// Rounds of regeneration allowed when candidates collide with existing keys
+const maxGenerateAttempts = 5
+
+func GenerateKeys(pool Pool, count int) error {
 
+	start := time.Now()
 	ctx := context.Background()
 	collection := database.MongoClient.Database(config.AppConfig.MONGO_DB_NAME).Collection("shortkeys")
 
-	var keys []interface{}
-	var redisKeys []string
+	generated := 0
+
+	for attempt := 0; generated < count && attempt < maxGenerateAttempts; attempt++ {
+
+		candidates, err := candidateKeys(ctx, pool, count-generated)
 
-	for i := 0; i < count; i++ {
+		if err != nil {
+			return err
+		}
 
-		key, err := utils.GenerateRandomKey(6)
+		redisKeys, err := insertKeys(ctx, collection, pool, candidates)
 
 		if err != nil {
 			return err
 		}
 
-		keys = append(keys, models.ShortKey{
+		if len(redisKeys) > 0 {
+			err := redis.RedisClient.LPush(ctx, pool.QueueName(), redisKeys).Err()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		generated += len(redisKeys)
+		metrics.KeysGenerated.WithLabelValues(pool.Name).Add(float64(len(redisKeys)))
+	}
+
+	metrics.KeyGenerationDuration.WithLabelValues(pool.Name).Observe(time.Since(start).Seconds())
+
+	if generated < count {
+		return fmt.Errorf("generated only %d of %d keys for pool %s, key space may be nearly exhausted", generated, count, pool.Name)
+	}
+
+	utils.Log.Info("✅ Successfully generated and stored keys", "pool", pool.Name, "count", count)
+	return nil
+}
+
+func candidateKeys(ctx context.Context, pool Pool, count int) ([]string, error) {
+
+	keys := make([]string, 0, count)
+
+	if pool.Strategy == StrategyCounter {
+
+		end, err := redis.RedisClient.IncrBy(ctx, pool.CounterName(), int64(count)).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for n := end - int64(count) + 1; n <= end; n++ {
+			key, err := utils.EncodeKey(uint64(n), pool.Length, pool.Alphabet)
+
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+		}
+
+		return keys, nil
+	}
+
+	seen := make(map[string]bool, count)
+
+	for len(keys) < count {
+
+		key, err := utils.GenerateRandomKey(pool.Length, pool.Alphabet)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// insertKeys stores the candidates and returns the ones that were inserted.
+// Candidates rejected by the unique index on "key" are silently dropped.
+func insertKeys(ctx context.Context, collection *mongo.Collection, pool Pool, candidates []string) ([]string, error) {
+
+	docs := make([]interface{}, 0, len(candidates))
+
+	for _, key := range candidates {
+		docs = append(docs, models.ShortKey{
 			Key:       key,
-			Status:    "available",
+			Pool:      pool.Name,
+			Status:    models.Available,
 			CreatedAt: time.Now(),
 		})
+	}
 
-		redisKeys = append(redisKeys, key)
+	_, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
 
+	if err == nil {
+		return candidates, nil
 	}
 
-	if len(keys) > 0 {
-		_, err := collection.InsertMany(ctx, keys)
+	var bulkErr mongo.BulkWriteException
 
-		if err != nil {
-			return err
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return nil, err
+	}
+
+	rejected := make(map[int]bool, len(bulkErr.WriteErrors))
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return nil, err
 		}
+		rejected[writeErr.Index] = true
 	}
 
-	if len(redisKeys) > 0 {
-		err := redis.RedisClient.LPush(ctx, constants.RedisQueueName, redisKeys).Err()
+	utils.Log.Warn("Dropped duplicate keys", "pool", pool.Name, "count", len(rejected))
 
-		if err != nil {
-			return err
+	inserted := make([]string, 0, len(candidates)-len(rejected))
+
+	for i, key := range candidates {
+		if !rejected[i] {
+			inserted = append(inserted, key)
 		}
 	}
 
-	utils.Log.Info("✅ Successfully generated and stored keys", "count", count)
-	return nil
+	return inserted, nil
 }
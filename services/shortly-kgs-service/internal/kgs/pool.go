@@ -0,0 +1,151 @@
+package kgs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shortly-kgs-service/internal/constants"
+	"shortly-kgs-service/internal/redis"
+	"shortly-kgs-service/internal/utils"
+)
+
+const DefaultPoolName = "default"
+
+// Key generation strategies
+const (
+	StrategyRandom  = "random"
+	StrategyCounter = "counter"
+)
+
+var alphabets = map[string]string{
+	"base62":    utils.Base62Alphabet,
+	"base58":    utils.Base58Alphabet,
+	"lowercase": utils.LowercaseAlphabet,
+}
+
+// Pool describes one family of keys with its own Redis queue.
+type Pool struct {
+	Name     string
+	Length   int
+	Alphabet string
+	Strategy string
+}
+
+var pools = map[string]Pool{}
+
+// QueueName keeps the original queue name for the default pool so existing
+// deployments don't lose their pre-generated keys.
+func (p Pool) QueueName() string {
+	if p.Name == DefaultPoolName {
+		return constants.RedisQueueName
+	}
+	return constants.RedisQueueName + ":" + p.Name
+}
+
+func (p Pool) CounterName() string {
+	if p.Name == DefaultPoolName {
+		return constants.RedisCounter
+	}
+	return constants.RedisCounter + ":" + p.Name
+}
+
+// LockName is the Redis key of the lock held while refilling the pool.
+func (p Pool) LockName() string {
+	return constants.RedisQueueName + ":refill-lock:" + p.Name
+}
+
+// InitPools parses a comma separated list of name:length:alphabet:strategy
+// entries, e.g. "default:6:base62:random,branded:7:lowercase:counter".
+// The list must contain a pool named "default".
+func InitPools(spec string) error {
+
+	parsed := map[string]Pool{}
+
+	for _, entry := range strings.Split(spec, ",") {
+
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+
+		if len(parts) != 4 {
+			return fmt.Errorf("invalid key pool %q, expected name:length:alphabet:strategy", entry)
+		}
+
+		length, err := strconv.Atoi(parts[1])
+
+		if err != nil || length < 1 || length > 50 {
+			return fmt.Errorf("invalid key length %q for pool %s", parts[1], parts[0])
+		}
+
+		alphabet, ok := alphabets[parts[2]]
+
+		if !ok {
+			return fmt.Errorf("unknown alphabet %q for pool %s (use base62, base58 or lowercase)", parts[2], parts[0])
+		}
+
+		if parts[3] != StrategyRandom && parts[3] != StrategyCounter {
+			return fmt.Errorf("unknown strategy %q for pool %s (use random or counter)", parts[3], parts[0])
+		}
+
+		parsed[parts[0]] = Pool{
+			Name:     parts[0],
+			Length:   length,
+			Alphabet: alphabet,
+			Strategy: parts[3],
+		}
+	}
+
+	if _, ok := parsed[DefaultPoolName]; !ok {
+		return fmt.Errorf("key pools must include a %q pool", DefaultPoolName)
+	}
+
+	pools = parsed
+
+	return nil
+}
+
+// GetPool resolves a pool by name, an empty name meaning the default pool.
+func GetPool(name string) (Pool, bool) {
+
+	if name == "" {
+		name = DefaultPoolName
+	}
+
+	pool, ok := pools[name]
+
+	return pool, ok
+}
+
+// Pools returns the configured pools sorted by name.
+func Pools() []Pool {
+
+	list := make([]Pool, 0, len(pools))
+
+	for _, pool := range pools {
+		list = append(list, pool)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// QueueLengths returns the number of keys waiting in each pool's queue.
+func QueueLengths(ctx context.Context) (map[string]int64, error) {
+
+	lengths := make(map[string]int64, len(pools))
+
+	for name, pool := range pools {
+
+		length, err := redis.RedisClient.LLen(ctx, pool.QueueName()).Result()
+
+		if err != nil {
+			return nil, err
+		}
+
+		lengths[name] = length
+	}
+
+	return lengths, nil
+}
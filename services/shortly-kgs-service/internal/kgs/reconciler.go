@@ -0,0 +1,370 @@
+package kgs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"shortly-kgs-service/internal/constants"
+	"shortly-kgs-service/internal/metrics"
+	"shortly-kgs-service/internal/models"
+	"shortly-kgs-service/internal/redis"
+	"shortly-kgs-service/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// Available keys younger than this may be between the Mongo insert and
+	// the Redis push of a refill, they are not treated as missing yet
+	rebuildGrace = time.Minute
+
+	reconcileLockTTL = 5 * time.Minute
+	pushBatchSize    = 1000
+)
+
+var reconcileLockName = constants.RedisQueueName + ":reconcile-lock"
+
+// DriftReport compares a pool's Redis queue with its shortkeys documents.
+type DriftReport struct {
+	Pool                string
+	QueueLength         int64
+	Available           int64
+	Reserved            int64
+	ExpiredReservations int64
+	Used                int64
+
+	// Available in MongoDB for longer than rebuildGrace but not queued
+	MissingFromQueue []string
+	// Queued in Redis but reserved, used or unknown in MongoDB
+	NotAvailable int64
+	// Extra copies of keys queued more than once
+	Duplicates int64
+}
+
+// HasDrift tells whether the stores disagree beyond in-flight work.
+func (d DriftReport) HasDrift() bool {
+	return len(d.MissingFromQueue) > 0 || d.NotAvailable > 0 || d.Duplicates > 0 || d.ExpiredReservations > 0
+}
+
+// Reconciler repairs what a crash or a Redis flush leaves behind: it puts
+// expired reservations back on the queue and re-queues available keys the
+// queue lost. Replicas share a Redis lock so only one reconciles at a time.
+type Reconciler struct {
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+var Recovery *Reconciler
+
+// StartReconciler runs a first pass right away, so a flushed queue is
+// rebuilt before the refiller generates keys it doesn't need, then one
+// every interval.
+func StartReconciler(interval time.Duration) {
+
+	Recovery = &Reconciler{
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := Reconcile(context.Background()); err != nil {
+		utils.Log.Error("Startup reconciliation failed", "error", err)
+	}
+
+	go Recovery.run()
+
+	utils.Log.Info("✅ Key pool reconciler started", "interval", interval)
+}
+
+func (r *Reconciler) run() {
+
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := Reconcile(context.Background()); err != nil {
+				utils.Log.Error("Reconciliation failed", "error", err)
+			}
+		}
+	}
+}
+
+// Stop waits for the running pass, if any, to finish.
+func (r *Reconciler) Stop(ctx context.Context) error {
+
+	r.once.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reconcile runs one pass over every pool, unless another replica holds
+// the reconcile lock.
+func Reconcile(ctx context.Context) error {
+
+	token, err := utils.GenerateRandomKey(32, utils.Base62Alphabet)
+
+	if err != nil {
+		return err
+	}
+
+	acquired, err := redis.RedisClient.SetNX(ctx, reconcileLockName, token, reconcileLockTTL).Result()
+
+	if err != nil || !acquired {
+		return err
+	}
+
+	defer func() {
+		if err := releaseLock.Run(ctx, redis.RedisClient, []string{reconcileLockName}, token).Err(); err != nil {
+			utils.Log.Warn("Failed to release reconcile lock", "error", err)
+		}
+	}()
+
+	if err := requeueExpired(ctx); err != nil {
+		return err
+	}
+
+	for _, pool := range pools {
+
+		report, err := CheckDrift(ctx, pool)
+
+		if err != nil {
+			return err
+		}
+
+		if len(report.MissingFromQueue) == 0 {
+			continue
+		}
+
+		if err := pushKeys(ctx, pool, report.MissingFromQueue); err != nil {
+			return err
+		}
+
+		metrics.ReconciledKeys.WithLabelValues(pool.Name, "rebuilt").Add(float64(len(report.MissingFromQueue)))
+		utils.Log.Warn("Re-queued available keys missing from Redis", "pool", pool.Name, "count", len(report.MissingFromQueue))
+	}
+
+	return nil
+}
+
+// requeueExpired makes expired reservations available again and queues
+// them. They are tagged with a fresh reservation id first, so only the keys
+// this pass flipped are pushed, not ones a slow request just marked used.
+func requeueExpired(ctx context.Context) error {
+
+	requeueID, err := utils.GenerateRandomKey(24, utils.Base62Alphabet)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := shortKeys().UpdateMany(ctx,
+		bson.M{"status": models.Reserved, "leasedUntil": bson.M{"$lt": time.Now()}},
+		bson.M{
+			"$set":   bson.M{"status": models.Available, "reservationId": requeueID},
+			"$unset": bson.M{"leasedUntil": ""},
+		},
+	)
+
+	if err != nil || res.ModifiedCount == 0 {
+		return err
+	}
+
+	cursor, err := shortKeys().Find(ctx,
+		bson.M{"reservationId": requeueID, "status": models.Available},
+		options.Find().SetProjection(bson.M{"key": 1, "pool": 1}),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	var docs []models.ShortKey
+
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	byPool := make(map[string][]string)
+
+	for _, doc := range docs {
+		name := doc.Pool
+
+		if name == "" {
+			name = DefaultPoolName
+		}
+
+		byPool[name] = append(byPool[name], doc.Key)
+	}
+
+	for name, keys := range byPool {
+
+		pool, ok := GetPool(name)
+
+		if !ok {
+			utils.Log.Warn("Expired reservations belong to an unconfigured pool", "pool", name, "count", len(keys))
+			continue
+		}
+
+		if err := pushKeys(ctx, pool, keys); err != nil {
+			return err
+		}
+
+		metrics.ReconciledKeys.WithLabelValues(pool.Name, "requeued").Add(float64(len(keys)))
+		utils.Log.Warn("Re-queued expired key reservations", "pool", pool.Name, "count", len(keys))
+	}
+
+	return nil
+}
+
+// CheckDrift reads a pool's whole queue and compares it with MongoDB.
+func CheckDrift(ctx context.Context, pool Pool) (DriftReport, error) {
+
+	report := DriftReport{Pool: pool.Name}
+
+	queued, err := redis.RedisClient.LRange(ctx, pool.QueueName(), 0, -1).Result()
+
+	if err != nil {
+		return report, err
+	}
+
+	report.QueueLength = int64(len(queued))
+
+	inQueue := make(map[string]bool, len(queued))
+
+	for _, key := range queued {
+		if inQueue[key] {
+			report.Duplicates++
+			continue
+		}
+		inQueue[key] = true
+	}
+
+	if err := countStatuses(ctx, pool, &report); err != nil {
+		return report, err
+	}
+
+	// Queued keys that are still available, checked in batches
+	unique := make([]string, 0, len(inQueue))
+
+	for key := range inQueue {
+		unique = append(unique, key)
+	}
+
+	var queuedAvailable int64
+
+	for start := 0; start < len(unique); start += pushBatchSize {
+
+		batch := unique[start:min(start+pushBatchSize, len(unique))]
+
+		count, err := shortKeys().CountDocuments(ctx, bson.M{"key": bson.M{"$in": batch}, "status": models.Available})
+
+		if err != nil {
+			return report, err
+		}
+
+		queuedAvailable += count
+	}
+
+	report.NotAvailable = int64(len(unique)) - queuedAvailable
+
+	cursor, err := shortKeys().Find(ctx,
+		bson.M{"$and": bson.A{
+			poolFilter(pool),
+			bson.M{"status": models.Available, "createdAt": bson.M{"$lt": time.Now().Add(-rebuildGrace)}},
+		}},
+		options.Find().SetProjection(bson.M{"key": 1}),
+	)
+
+	if err != nil {
+		return report, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+
+		var doc models.ShortKey
+
+		if err := cursor.Decode(&doc); err != nil {
+			return report, err
+		}
+
+		if !inQueue[doc.Key] {
+			report.MissingFromQueue = append(report.MissingFromQueue, doc.Key)
+		}
+	}
+
+	return report, cursor.Err()
+}
+
+func countStatuses(ctx context.Context, pool Pool, report *DriftReport) error {
+
+	cursor, err := shortKeys().Aggregate(ctx, bson.A{
+		bson.M{"$match": poolFilter(pool)},
+		bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var groups []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		switch group.Status {
+		case models.Available:
+			report.Available = group.Count
+		case models.Reserved:
+			report.Reserved = group.Count
+		case models.Used:
+			report.Used = group.Count
+		}
+	}
+
+	expired, err := shortKeys().CountDocuments(ctx, bson.M{"$and": bson.A{
+		poolFilter(pool),
+		bson.M{"status": models.Reserved, "leasedUntil": bson.M{"$lt": time.Now()}},
+	}})
+
+	if err != nil {
+		return err
+	}
+
+	report.ExpiredReservations = expired
+
+	return nil
+}
+
+func pushKeys(ctx context.Context, pool Pool, keys []string) error {
+
+	for start := 0; start < len(keys); start += pushBatchSize {
+		if err := redis.RedisClient.LPush(ctx, pool.QueueName(), keys[start:min(start+pushBatchSize, len(keys))]).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package kgs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"shortly-kgs-service/internal/constants"
+	"shortly-kgs-service/internal/metrics"
+	"shortly-kgs-service/internal/redis"
+	"shortly-kgs-service/internal/utils"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Results of a refill pass, as reported in metrics
+const (
+	refillRefilled = "refilled"
+	refillLocked   = "locked"
+	refillFailed   = "failed"
+)
+
+var errLockLost = errors.New("refill lock expired or was taken over")
+
+// The lock is only released or extended by the replica holding its token
+var (
+	releaseLock = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	extendLock = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Refiller tops every pool's queue up to the high watermark once it drops
+// below the low one, outside of any request. A Redis lock per pool makes
+// sure only one KGS replica generates keys for a pool at a time.
+type Refiller struct {
+	low      int
+	high     int
+	interval time.Duration
+	lockTTL  time.Duration
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	mu     sync.Mutex
+	filled chan struct{}
+}
+
+var Refill *Refiller
+
+func StartRefiller(low int, high int, interval time.Duration, lockTTL time.Duration) {
+
+	Refill = &Refiller{
+		low:      low,
+		high:     high,
+		interval: interval,
+		lockTTL:  lockTTL,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		filled:   make(chan struct{}),
+	}
+
+	for name := range pools {
+		metrics.Watermark.WithLabelValues(name, "low").Set(float64(low))
+		metrics.Watermark.WithLabelValues(name, "high").Set(float64(high))
+	}
+
+	go Refill.run()
+
+	utils.Log.Info("✅ Key pool refiller started", "low", low, "high", high, "interval", interval)
+}
+
+// Wake makes the refiller check the pools right away.
+func (r *Refiller) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wait wakes the refiller and returns once a pass of this replica pushed
+// new keys, after pollInterval (another replica may be refilling), or when
+// ctx is done.
+func (r *Refiller) Wait(ctx context.Context, pollInterval time.Duration) error {
+
+	r.mu.Lock()
+	filled := r.filled
+	r.mu.Unlock()
+
+	r.Wake()
+
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-filled:
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// Stop waits for the running pass, if any, to finish.
+func (r *Refiller) Stop(ctx context.Context) error {
+
+	r.once.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Refiller) run() {
+
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		refilled := false
+
+		for _, pool := range pools {
+			if r.refill(pool) {
+				refilled = true
+			}
+		}
+
+		if refilled {
+			r.mu.Lock()
+			close(r.filled)
+			r.filled = make(chan struct{})
+			r.mu.Unlock()
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// refill tops pool up when it is below the low watermark and reports
+// whether keys were pushed.
+func (r *Refiller) refill(pool Pool) bool {
+
+	ctx := context.Background()
+
+	queueLen, err := redis.RedisClient.LLen(ctx, pool.QueueName()).Result()
+
+	if err != nil {
+		utils.Log.Error("Failed to read queue length", "pool", pool.Name, "error", err)
+		return false
+	}
+
+	if queueLen >= int64(r.low) {
+		return false
+	}
+
+	token, err := utils.GenerateRandomKey(32, utils.Base62Alphabet)
+
+	if err != nil {
+		utils.Log.Error("Failed to generate refill lock token", "error", err)
+		return false
+	}
+
+	acquired, err := redis.RedisClient.SetNX(ctx, pool.LockName(), token, r.lockTTL).Result()
+
+	if err != nil {
+		utils.Log.Error("Failed to acquire refill lock", "pool", pool.Name, "error", err)
+		metrics.Refills.WithLabelValues(pool.Name, refillFailed).Inc()
+		return false
+	}
+
+	if !acquired {
+		metrics.Refills.WithLabelValues(pool.Name, refillLocked).Inc()
+		return false
+	}
+
+	defer func() {
+		if err := releaseLock.Run(ctx, redis.RedisClient, []string{pool.LockName()}, token).Err(); err != nil {
+			utils.Log.Warn("Failed to release refill lock", "pool", pool.Name, "error", err)
+		}
+	}()
+
+	// Read again under the lock, another replica may just have refilled
+	queueLen, err = redis.RedisClient.LLen(ctx, pool.QueueName()).Result()
+
+	if err != nil || queueLen >= int64(r.low) {
+		return false
+	}
+
+	utils.Log.Info("Queue below low watermark, refilling", "pool", pool.Name, "length", queueLen, "target", r.high)
+
+	// Generate in chunks, extending the lock between them so a slow
+	// refill isn't taken over by another replica halfway through
+	for missing := r.high - int(queueLen); missing > 0; missing -= constants.KeyCount {
+
+		select {
+		case <-r.stop:
+			return true
+		default:
+		}
+
+		if err := GenerateKeys(pool, min(missing, constants.KeyCount)); err != nil {
+			utils.Log.Error("Failed to refill key pool", "pool", pool.Name, "error", err)
+			metrics.Refills.WithLabelValues(pool.Name, refillFailed).Inc()
+			return true
+		}
+
+		extended, err := extendLock.Run(ctx, redis.RedisClient, []string{pool.LockName()}, token, r.lockTTL.Milliseconds()).Int()
+
+		if err != nil || extended == 0 {
+			if err == nil {
+				err = errLockLost
+			}
+			utils.Log.Warn("Stopped refill early", "pool", pool.Name, "error", err)
+			metrics.Refills.WithLabelValues(pool.Name, refillFailed).Inc()
+			return true
+		}
+	}
+
+	metrics.Refills.WithLabelValues(pool.Name, refillRefilled).Inc()
+
+	return true
+}
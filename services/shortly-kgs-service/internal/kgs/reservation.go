@@ -0,0 +1,103 @@
+package kgs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/database"
+	"shortly-kgs-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrLeaseExpired = errors.New("reservation lease expired before the keys were marked as used")
+
+func shortKeys() *mongo.Collection {
+	return database.MongoClient.Database(config.AppConfig.MONGO_DB_NAME).Collection("shortkeys")
+}
+
+// Claim reserves the popped keys that are still available under
+// reservationID and returns them. Keys already reserved or used, e.g. a
+// duplicate left in Redis by a crash, are not returned so they can never be
+// issued twice.
+func Claim(ctx context.Context, keys []string, reservationID string, lease time.Duration) ([]string, error) {
+
+	_, err := shortKeys().UpdateMany(ctx,
+		bson.M{"key": bson.M{"$in": keys}, "status": models.Available},
+		bson.M{"$set": bson.M{
+			"status":        models.Reserved,
+			"reservationId": reservationID,
+			"leasedUntil":   time.Now().Add(lease),
+		}},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return findKeys(ctx, bson.M{"key": bson.M{"$in": keys}, "reservationId": reservationID, "status": models.Reserved})
+}
+
+// Commit marks the count keys reserved under reservationID as used. It fails
+// when the reconciler already took some of them back after their lease.
+func Commit(ctx context.Context, reservationID string, count int) error {
+
+	res, err := shortKeys().UpdateMany(ctx,
+		bson.M{"reservationId": reservationID, "status": models.Reserved},
+		bson.M{
+			"$set":   bson.M{"status": models.Used, "usedAt": time.Now()},
+			"$unset": bson.M{"leasedUntil": ""},
+		},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if res.ModifiedCount < int64(count) {
+		return ErrLeaseExpired
+	}
+
+	return nil
+}
+
+func findKeys(ctx context.Context, filter bson.M) ([]string, error) {
+
+	cursor, err := shortKeys().Find(ctx, filter, options.Find().SetProjection(bson.M{"key": 1}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []models.ShortKey
+
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(docs))
+
+	for _, doc := range docs {
+		keys = append(keys, doc.Key)
+	}
+
+	return keys, nil
+}
+
+// poolFilter matches the keys of pool. Keys stored before pools existed have
+// no pool field and belong to the default pool.
+func poolFilter(pool Pool) bson.M {
+
+	if pool.Name == DefaultPoolName {
+		return bson.M{"$or": bson.A{
+			bson.M{"pool": pool.Name},
+			bson.M{"pool": bson.M{"$exists": false}},
+		}}
+	}
+
+	return bson.M{"pool": pool.Name}
+}
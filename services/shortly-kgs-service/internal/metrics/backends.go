@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor counts failed MongoDB commands. Duplicate keys rejected by
+// an unordered insert are write errors of a successful command and aren't
+// counted.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Failed: func(context.Context, *event.CommandFailedEvent) {
+			BackendErrors.WithLabelValues(BackendMongo).Inc()
+		},
+	}
+}
+
+// InstrumentRedis counts failed commands of client. redis.Nil only means
+// the key doesn't exist and isn't counted.
+func InstrumentRedis(client *redis.Client) {
+	client.AddHook(redisHook{})
+}
+
+type redisHook struct{}
+
+func (redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+
+		conn, err := next(ctx, network, addr)
+
+		if err != nil {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return conn, err
+	}
+}
+
+func (redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+
+		err := next(ctx, cmd)
+
+		if err != nil && !errors.Is(err, redis.Nil) {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return err
+	}
+}
+
+func (redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+
+		err := next(ctx, cmds)
+
+		if err != nil && !errors.Is(err, redis.Nil) {
+			BackendErrors.WithLabelValues(BackendRedis).Inc()
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "shortly_kgs"
+
+// Backends whose failures are counted in BackendErrors
+const (
+	BackendMongo = "mongo"
+	BackendRedis = "redis"
+)
+
+var (
+	KeyGenerationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "key_generation_duration_seconds",
+		Help:      "Time spent generating, storing and queueing a batch of keys.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"pool"})
+
+	KeysGenerated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "keys_generated_total",
+		Help:      "Keys generated and pushed onto the Redis queue.",
+	}, []string{"pool"})
+
+	Refills = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refills_total",
+		Help:      "Background refill passes of a pool below its low watermark, by result (refilled, locked, failed).",
+	}, []string{"pool", "result"})
+
+	Watermark = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_watermark",
+		Help:      "Configured refill watermarks of each pool's queue, to compare with queue_length.",
+	}, []string{"pool", "level"})
+
+	RefillWaits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refill_waits_total",
+		Help:      "Requests that found their pool's queue too short and waited for a refill.",
+	}, []string{"pool"})
+
+	ReconciledKeys = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconciled_keys_total",
+		Help:      "Keys put back on the queue by the reconciler, by action (requeued expired reservation, rebuilt after a Redis loss).",
+	}, []string{"pool", "action"})
+
+	BackendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_errors_total",
+		Help:      "Failed MongoDB and Redis commands.",
+	}, []string{"backend"})
+
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// UnaryServerInterceptor records the latency of every unary gRPC call.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		RequestDuration.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+// queueCollector reads the queue lengths at scrape time, so the gauge is
+// never staler than the scrape itself.
+type queueCollector struct {
+	desc    *prometheus.Desc
+	lengths func(ctx context.Context) (map[string]int64, error)
+}
+
+// RegisterQueueLength exposes shortly_kgs_queue_length per pool, read
+// through lengths on every scrape.
+func RegisterQueueLength(lengths func(ctx context.Context) (map[string]int64, error)) {
+	prometheus.MustRegister(&queueCollector{
+		desc:    prometheus.NewDesc(namespace+"_queue_length", "Pre-generated keys waiting in the Redis queue.", []string{"pool"}, nil),
+		lengths: lengths,
+	})
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	lengths, err := c.lengths(ctx)
+
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for pool, length := range lengths {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(length), pool)
+	}
+}
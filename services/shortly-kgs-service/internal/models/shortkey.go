@@ -6,14 +6,22 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// A key is popped from Redis, reserved under a lease while the request
+// records it, then used. Reservations whose lease expired were never handed
+// out and go back to available.
 type ShortKey struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Key       string             `bson:"key"`
-	Status    string             `bson:"status"`
-	CreatedAt time.Time          `bson:"createdAt"`
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Key           string             `bson:"key"`
+	Pool          string             `bson:"pool"`
+	Status        string             `bson:"status"`
+	ReservationID string             `bson:"reservationId,omitempty"`
+	LeasedUntil   *time.Time         `bson:"leasedUntil,omitempty"`
+	UsedAt        *time.Time         `bson:"usedAt,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
 }
 
 const (
 	Available = "available"
+	Reserved  = "reserved"
 	Used      = "used"
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"shortly-kgs-service/config"
+	"shortly-kgs-service/internal/metrics"
 	"shortly-kgs-service/internal/utils"
 
 	"github.com/redis/go-redis/v9"
@@ -19,6 +20,8 @@ func ConnectRedis() error {
 		DB:       0,
 	})
 
+	metrics.InstrumentRedis(RedisClient)
+
 	_, err := RedisClient.Ping(context.Background()).Result()
 
 	if err != nil {
@@ -31,3 +34,13 @@ func ConnectRedis() error {
 	return nil
 
 }
+
+func CloseRedis() {
+	if RedisClient != nil {
+		if err := RedisClient.Close(); err != nil {
+			utils.Log.Warn("Error closing Redis connection", "error", err)
+		} else {
+			utils.Log.Info("Redis connection closed")
+		}
+	}
+}
@@ -2,17 +2,26 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"shortly-kgs-service/config"
 	"shortly-kgs-service/internal/constants"
-	"shortly-kgs-service/internal/database"
 	"shortly-kgs-service/internal/kgs"
-	"shortly-kgs-service/internal/models"
+	"shortly-kgs-service/internal/metrics"
 	"shortly-kgs-service/internal/redis"
 	"shortly-kgs-service/internal/utils"
 	"shortly-proto/gen/key"
 
-	"go.mongodb.org/mongo-driver/bson"
+	goredis "github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	refillWaitTimeout  = 5 * time.Second
+	refillPollInterval = 250 * time.Millisecond
 )
 
 type KeyServiceServer struct {
@@ -25,47 +34,123 @@ func NewKeyServiceServer() key.KeyServiceServer {
 
 func (s *KeyServiceServer) GetKey(ctx context.Context, req *key.Empty) (*key.KeyResponse, error) {
 
-	queueLen, err := redis.RedisClient.LLen(context.Background(), constants.RedisQueueName).Result()
+	pool, _ := kgs.GetPool(kgs.DefaultPoolName)
+
+	keys, err := issueKeys(ctx, pool, 1)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if queueLen < constants.QueueLength {
-		utils.Log.Info("Queue length is low, generating more keys")
-		if err := kgs.GenerateKeys(constants.KeyCount); err != nil {
-			return nil, err
-		}
+	return &key.KeyResponse{Key: keys[0]}, nil
+}
+
+func (s *KeyServiceServer) GetKeys(ctx context.Context, req *key.GetKeysRequest) (*key.KeysResponse, error) {
+
+	count := int(req.GetCount())
+
+	if count < 1 || count > constants.MaxKeysPerRequest {
+		return nil, status.Errorf(codes.InvalidArgument, "count must be between 1 and %d", constants.MaxKeysPerRequest)
+	}
+
+	pool, ok := kgs.GetPool(req.GetPool())
+
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown key pool %q", req.GetPool())
 	}
 
-	keyVal, err := redis.RedisClient.RPop(ctx, constants.RedisQueueName).Result()
+	keys, err := issueKeys(ctx, pool, count)
 
 	if err != nil {
 		return nil, err
 	}
 
-	collection := database.MongoClient.Database(config.AppConfig.MONGO_DB_NAME).Collection("shortkeys")
+	return &key.KeysResponse{Keys: keys}, nil
+}
+
+// issueKeys moves count keys from available to reserved to used. A crash
+// before the reservation leaves the key available in MongoDB only, one
+// before the commit leaves an expired reservation: the reconciler queues
+// both again since neither was handed out.
+func issueKeys(ctx context.Context, pool kgs.Pool, count int) ([]string, error) {
 
-	filter := bson.M{"key": keyVal}
+	reservationID, err := utils.GenerateRandomKey(24, utils.Base62Alphabet)
 
-	update := bson.M{
-		"$set": bson.M{
-			"status": models.Used,
-		},
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := collection.UpdateOne(
-		context.Background(),
-		filter,
-		update,
-	)
+	// Once popped, keys must be recorded even if the caller goes away
+	storeCtx := context.WithoutCancel(ctx)
+
+	issued := make([]string, 0, count)
+
+	for len(issued) < count {
+
+		keys, err := takeKeys(ctx, pool, count-len(issued))
+
+		if err != nil {
+			return nil, err
+		}
+
+		claimed, err := kgs.Claim(storeCtx, keys, reservationID, config.AppConfig.KEY_RESERVATION_LEASE)
 
-	if err != nil || res.ModifiedCount == 0 {
-		_ = redis.RedisClient.LPush(ctx, constants.RedisQueueName, keyVal).Err()
-		return nil, fmt.Errorf("failed to update key status in DB, pushed key %s back to Redis", keyVal)
+		if err != nil {
+			_ = redis.RedisClient.LPush(storeCtx, pool.QueueName(), keys).Err()
+			return nil, fmt.Errorf("failed to reserve keys in DB, pushed %d keys back to Redis: %w", len(keys), err)
+		}
+
+		if len(claimed) < len(keys) {
+			utils.Log.Warn("Dropped queued keys that were no longer available", "pool", pool.Name, "count", len(keys)-len(claimed))
+		}
+
+		issued = append(issued, claimed...)
+	}
+
+	if err := kgs.Commit(storeCtx, reservationID, len(issued)); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to mark keys as used: %v", err)
 	}
 
-	utils.Log.Info("Key status update in database")
+	utils.Log.Info("Key batch status updated in database", "pool", pool.Name, "count", len(issued))
 
-	return &key.KeyResponse{Key: keyVal}, nil
+	return issued, nil
+}
+
+// takeKeys pops count keys from the pool's queue. Keys are generated by the
+// background refiller only: when the queue runs short the request wakes it
+// and waits, up to refillWaitTimeout, for enough keys to show up.
+func takeKeys(ctx context.Context, pool kgs.Pool, count int) ([]string, error) {
+
+	deadline := time.Now().Add(refillWaitTimeout)
+
+	for {
+		// RPOP with a count pops the whole batch in one atomic command
+		keys, err := redis.RedisClient.RPopCount(ctx, pool.QueueName(), count).Result()
+
+		if err != nil && !errors.Is(err, goredis.Nil) {
+			return nil, err
+		}
+
+		if len(keys) == count {
+			kgs.Refill.Wake()
+			return keys, nil
+		}
+
+		// Not enough keys, put the partial batch back for the next caller
+		if len(keys) > 0 {
+			if err := redis.RedisClient.RPush(ctx, pool.QueueName(), keys).Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, status.Errorf(codes.Unavailable, "key pool %s is being refilled, retry later", pool.Name)
+		}
+
+		metrics.RefillWaits.WithLabelValues(pool.Name).Inc()
+
+		if err := kgs.Refill.Wait(ctx, refillPollInterval); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+)
+
+const (
+	Base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	// Base58 drops the easily confused 0, O, I and l
+	Base58Alphabet = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+	// Lowercase suits domains where paths are matched case-insensitively
+	LowercaseAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// GenerateRandomKey draws every character independently and uniformly from
+// the alphabet using crypto/rand.
+func GenerateRandomKey(length int, alphabet string) (string, error) {
+
+	base := len(alphabet)
+
+	if base < 2 || base > 256 {
+		return "", errors.New("alphabet must contain between 2 and 256 characters")
+	}
+
+	// Bytes at or above this bound are rejected so every character is
+	// equally likely (no modulo bias)
+	bound := 256 - 256%base
+
+	var result strings.Builder
+	result.Grow(length)
+
+	buf := make([]byte, length)
+
+	for result.Len() < length {
+
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+
+		for _, b := range buf {
+			if int(b) >= bound {
+				continue
+			}
+
+			result.WriteByte(alphabet[int(b)%base])
+
+			if result.Len() == length {
+				break
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+// EncodeKey writes n in the given alphabet, left padded to length.
+// It fails when n does not fit in length characters.
+func EncodeKey(n uint64, length int, alphabet string) (string, error) {
+
+	base := uint64(len(alphabet))
+	key := make([]byte, length)
+
+	for i := length - 1; i >= 0; i-- {
+		key[i] = alphabet[n%base]
+		n /= base
+	}
+
+	if n > 0 {
+		return "", errors.New("key space exhausted for this length")
+	}
+
+	return string(key), nil
+}